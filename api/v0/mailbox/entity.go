@@ -0,0 +1,92 @@
+package mailbox
+
+// ListQueuesRequest is a request to list the queues that belong to a
+// session. The session can either be provided directly, as it appears
+// in the gateway logs, or it can be derived from the AAD and the
+// session key used by the client
+type ListQueuesRequest struct {
+	// Session is the session to which the queues belong
+	Session string `json:"session"`
+
+	// AAD is the AAD of the client as set by the authentication
+	// provider. It is used along with SessionKey if Session is not set
+	AAD string `json:"aad"`
+
+	// SessionKey is the session key provided by the client
+	SessionKey string `json:"sessionKey"`
+
+	// Tenant is the tenant of the client, if any
+	Tenant string `json:"tenant"`
+}
+
+// Queue is the summary of the state of a queue
+type Queue struct {
+	// Key unique identifier of the queue
+	Key string `json:"key"`
+
+	// Offset is the base offset of the window of the queue
+	Offset uint64 `json:"offset"`
+
+	// Next is the offset that the next request on the queue will get
+	Next uint64 `json:"next"`
+
+	// Elements is the number of elements set in the window
+	Elements uint64 `json:"elements"`
+
+	// Unset is the number of slots in the window for which the
+	// request has not completed yet
+	Unset uint64 `json:"unset"`
+
+	// Discarded is the number of slots in the window that have been
+	// discarded but the window has not slided past yet
+	Discarded uint64 `json:"discarded"`
+}
+
+// ListQueuesResponse is the response to a ListQueuesRequest
+type ListQueuesResponse struct {
+	// Session for which the queues have been listed
+	Session string `json:"session"`
+
+	// Queues that belong to the session
+	Queues []Queue `json:"queues"`
+}
+
+// InspectQueueRequest is a request to retrieve the contents of
+// a queue without modifying it
+type InspectQueueRequest struct {
+	// Key unique identifier of the queue
+	Key string `json:"key"`
+
+	// Offset at which the returned slots start
+	Offset uint64 `json:"offset"`
+
+	// Count is the maximum number of slots returned
+	Count uint `json:"count"`
+}
+
+// Slot is the state of an offset in the window of a queue
+type Slot struct {
+	// Offset of the slot within the queue
+	Offset uint64 `json:"offset"`
+
+	// Set is true if the element for the slot has been inserted
+	Set bool `json:"set"`
+
+	// Discarded is true if the slot has been discarded
+	Discarded bool `json:"discarded"`
+
+	// Type is the type of the element if it is set
+	Type string `json:"type,omitempty"`
+
+	// Value is the value of the element if it is set
+	Value string `json:"value,omitempty"`
+}
+
+// InspectQueueResponse is the response to an InspectQueueRequest
+type InspectQueueResponse struct {
+	Queue
+
+	// Slots are the slots of the queue starting at the
+	// requested offset
+	Slots []Slot `json:"slots"`
+}
@@ -0,0 +1,198 @@
+package mailbox
+
+import (
+	"context"
+	stderr "errors"
+	"strings"
+
+	auth "github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/log"
+	mqueue "github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/rpc"
+)
+
+// maxInspectCount is the maximum number of slots that can be
+// returned on a single InspectQueueRequest
+const maxInspectCount uint = 1024
+
+// Client interface for the underlying operations needed for the API
+// implementation
+type Client interface {
+	Exists(context.Context, mqueue.ExistsRequest) (bool, error)
+	Keys(context.Context, mqueue.KeysRequest) ([]mqueue.KeyInfo, error)
+	Inspect(context.Context, mqueue.InspectRequest) (mqueue.Inspection, error)
+}
+
+type Services struct {
+	Logger log.Logger
+	Client Client
+}
+
+// MailboxHandler implements the handlers used by operators to
+// inspect the contents of the mailbox
+type MailboxHandler struct {
+	logger log.Logger
+	client Client
+}
+
+// NewMailboxHandler creates a new instance of a mailbox handler
+func NewMailboxHandler(services Services) MailboxHandler {
+	if services.Client == nil {
+		panic("Client must be provided as a service")
+	}
+	if services.Logger == nil {
+		panic("Logger must be provided as a service")
+	}
+
+	return MailboxHandler{
+		logger: services.Logger.ForClass("mailbox", "handler"),
+		client: services.Client,
+	}
+}
+
+func makeQueue(inspection mqueue.Inspection) Queue {
+	return Queue{
+		Key:       inspection.Key,
+		Offset:    inspection.Offset,
+		Next:      inspection.Next,
+		Elements:  inspection.Elements,
+		Unset:     inspection.Unset,
+		Discarded: inspection.Discarded,
+	}
+}
+
+// ListQueues lists all the queues that belong to a session along
+// with a summary of their state
+func (h MailboxHandler) ListQueues(ctx context.Context, v interface{}) (interface{}, error) {
+	req := v.(*ListQueuesRequest)
+
+	session := req.Session
+	if len(session) == 0 {
+		if len(req.AAD) == 0 || len(req.SessionKey) == 0 {
+			err := errors.New(errors.ErrEmptyInput, stderr.New("either session or aad and sessionKey must be set"))
+			h.logger.Debug(ctx, "failed to handle request", log.MapFields{
+				"call_type": "ListQueuesFailure",
+			}, err)
+			return nil, err
+		}
+
+		session = auth.MakeSession(req.Tenant, req.AAD, req.SessionKey)
+	}
+
+	infos, derr := h.client.Keys(ctx, mqueue.KeysRequest{Prefix: session})
+	if derr != nil {
+		err := errors.New(errors.ErrQueueKeys, derr)
+		h.logger.Debug(ctx, "failed to list queues", log.MapFields{
+			"call_type": "ListQueuesFailure",
+		}, err)
+		return nil, err
+	}
+
+	queues := make([]Queue, 0, len(infos))
+	for _, info := range infos {
+		// the prefix also matches sessions that start with the
+		// same characters, which need to be ignored
+		if info.Key != session && !strings.HasPrefix(info.Key, session+":") {
+			continue
+		}
+
+		inspection, derr := h.client.Inspect(ctx, mqueue.InspectRequest{Key: info.Key})
+		if derr != nil {
+			// the queue may have expired since it was listed
+			h.logger.Debug(ctx, "failed to inspect queue", log.MapFields{
+				"call_type": "ListQueuesInspectFailure",
+				"key":       info.Key,
+				"err":       derr.Error(),
+			})
+			continue
+		}
+
+		queues = append(queues, makeQueue(inspection))
+	}
+
+	return &ListQueuesResponse{
+		Session: session,
+		Queues:  queues,
+	}, nil
+}
+
+// InspectQueue returns the state of a queue along with the
+// contents of its slots
+func (h MailboxHandler) InspectQueue(ctx context.Context, v interface{}) (interface{}, error) {
+	req := v.(*InspectQueueRequest)
+
+	if len(req.Key) == 0 {
+		err := errors.New(errors.ErrEmptyInput, stderr.New("key must be set"))
+		h.logger.Debug(ctx, "failed to handle request", log.MapFields{
+			"call_type": "InspectQueueFailure",
+		}, err)
+		return nil, err
+	}
+
+	if req.Count > maxInspectCount {
+		err := errors.New(errors.ErrOutOfRange, stderr.New("count exceeds the maximum number of slots"))
+		h.logger.Debug(ctx, "failed to handle request", log.MapFields{
+			"call_type": "InspectQueueFailure",
+		}, err)
+		return nil, err
+	}
+
+	ok, derr := h.client.Exists(ctx, mqueue.ExistsRequest{Key: req.Key})
+	if derr != nil {
+		err := errors.New(errors.ErrQueueInspect, derr)
+		h.logger.Debug(ctx, "failed to inspect queue", log.MapFields{
+			"call_type": "InspectQueueFailure",
+		}, err)
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(errors.ErrQueueNotFound, nil)
+	}
+
+	inspection, derr := h.client.Inspect(ctx, mqueue.InspectRequest{
+		Key:    req.Key,
+		Offset: req.Offset,
+		Count:  req.Count,
+	})
+	if derr != nil {
+		err := errors.New(errors.ErrQueueInspect, derr)
+		h.logger.Debug(ctx, "failed to inspect queue", log.MapFields{
+			"call_type": "InspectQueueFailure",
+		}, err)
+		return nil, err
+	}
+
+	slots := make([]Slot, 0, len(inspection.Slots))
+	for _, slot := range inspection.Slots {
+		slots = append(slots, Slot{
+			Offset:    slot.Offset,
+			Set:       slot.Set,
+			Discarded: slot.Discarded,
+			Type:      slot.Element.Type,
+			Value:     slot.Element.Value,
+		})
+	}
+
+	h.logger.Info(ctx, "queue inspected", log.MapFields{
+		"call_type": "InspectQueueSuccess",
+		"key":       req.Key,
+		"offset":    req.Offset,
+		"count":     req.Count,
+	})
+
+	return &InspectQueueResponse{
+		Queue: makeQueue(inspection),
+		Slots: slots,
+	}, nil
+}
+
+// BindHandler binds the mailbox handler to the handler binder
+func BindHandler(services Services, binder rpc.HandlerBinder) {
+	handler := NewMailboxHandler(services)
+
+	binder.Bind("POST", "/v0/api/admin/mailbox/list", rpc.HandlerFunc(handler.ListQueues),
+		rpc.EntityFactoryFunc(func() interface{} { return &ListQueuesRequest{} }))
+	binder.Bind("POST", "/v0/api/admin/mailbox/inspect", rpc.HandlerFunc(handler.InspectQueue),
+		rpc.EntityFactoryFunc(func() interface{} { return &InspectQueueRequest{} }))
+}
@@ -0,0 +1,102 @@
+package mailbox
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	auth "github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/log"
+	mqueue "github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/mqueue/mailboxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var Context = context.TODO()
+
+var Logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Output: ioutil.Discard,
+})
+
+func createMailboxHandler() (MailboxHandler, *mailboxtest.Mailbox) {
+	client := &mailboxtest.Mailbox{}
+	return NewMailboxHandler(Services{
+		Logger: Logger,
+		Client: client,
+	}), client
+}
+
+func TestListQueuesEmptyInput(t *testing.T) {
+	h, _ := createMailboxHandler()
+
+	_, err := h.ListQueues(Context, &ListQueuesRequest{AAD: "aad"})
+	assert.Equal(t, errors.ErrEmptyInput, err.(errors.Err).ErrorCode())
+}
+
+func TestListQueues(t *testing.T) {
+	h, client := createMailboxHandler()
+	session := auth.MakeSession("", "aad", "key")
+
+	client.On("Keys", mock.Anything, mqueue.KeysRequest{Prefix: session}).Return([]mqueue.KeyInfo{
+		{Key: session},
+		{Key: session + "2"},
+		{Key: session + ":sub:0"},
+	}, nil)
+	client.On("Inspect", mock.Anything, mqueue.InspectRequest{Key: session}).Return(mqueue.Inspection{
+		KeyInfo:  mqueue.KeyInfo{Key: session, Offset: 1, Next: 3},
+		Elements: 1,
+		Unset:    1,
+	}, nil)
+	client.On("Inspect", mock.Anything, mqueue.InspectRequest{Key: session + ":sub:0"}).Return(
+		mqueue.Inspection{}, errors.New(errors.ErrQueueNotFound, nil))
+
+	res, err := h.ListQueues(Context, &ListQueuesRequest{AAD: "aad", SessionKey: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, &ListQueuesResponse{
+		Session: session,
+		Queues: []Queue{
+			{Key: session, Offset: 1, Next: 3, Elements: 1, Unset: 1},
+		},
+	}, res)
+}
+
+func TestInspectQueueNotFound(t *testing.T) {
+	h, client := createMailboxHandler()
+	client.On("Exists", mock.Anything, mqueue.ExistsRequest{Key: "key"}).Return(false, nil)
+
+	_, err := h.InspectQueue(Context, &InspectQueueRequest{Key: "key"})
+	assert.Equal(t, errors.ErrQueueNotFound, err.(errors.Err).ErrorCode())
+}
+
+func TestInspectQueueCountOutOfRange(t *testing.T) {
+	h, _ := createMailboxHandler()
+
+	_, err := h.InspectQueue(Context, &InspectQueueRequest{Key: "key", Count: maxInspectCount + 1})
+	assert.Equal(t, errors.ErrOutOfRange, err.(errors.Err).ErrorCode())
+}
+
+func TestInspectQueue(t *testing.T) {
+	h, client := createMailboxHandler()
+	client.On("Exists", mock.Anything, mqueue.ExistsRequest{Key: "key"}).Return(true, nil)
+	client.On("Inspect", mock.Anything, mqueue.InspectRequest{Key: "key", Offset: 0, Count: 2}).Return(mqueue.Inspection{
+		KeyInfo:   mqueue.KeyInfo{Key: "key", Offset: 0, Next: 2},
+		Elements:  1,
+		Discarded: 1,
+		Slots: []mqueue.Slot{
+			{Offset: 0, Discarded: true},
+			{Offset: 1, Set: true, Element: mqueue.Element{Offset: 1, Type: "type", Value: "value"}},
+		},
+	}, nil)
+
+	res, err := h.InspectQueue(Context, &InspectQueueRequest{Key: "key", Count: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, &InspectQueueResponse{
+		Queue: Queue{Key: "key", Offset: 0, Next: 2, Elements: 1, Discarded: 1},
+		Slots: []Slot{
+			{Offset: 0, Discarded: true},
+			{Offset: 1, Set: true, Type: "type", Value: "value"},
+		},
+	}, res)
+}
@@ -0,0 +1,26 @@
+package wallet
+
+// ListWalletsRequest is a request to list the wallets
+// used to send transactions
+type ListWalletsRequest struct{}
+
+// ListWalletsResponse is the response to a ListWalletsRequest
+type ListWalletsResponse struct {
+	// Addresses of the wallets
+	Addresses []string `json:"addresses"`
+}
+
+// DeriveWalletsRequest is a request to raise the number of
+// wallets derived from the HD wallet
+type DeriveWalletsRequest struct {
+	// Count is the total number of wallets derived from the HD
+	// wallet after the request. It cannot be lower than the
+	// number of wallets already derived
+	Count int `json:"count"`
+}
+
+// DeriveWalletsResponse is the response to a DeriveWalletsRequest
+type DeriveWalletsResponse struct {
+	// Addresses of the wallets derived by the request
+	Addresses []string `json:"addresses"`
+}
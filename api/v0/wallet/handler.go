@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/rpc"
+)
+
+// Client interface for the underlying operations needed for the API
+// implementation
+type Client interface {
+	Senders() []common.Address
+	DeriveWallets(context.Context, int) ([]common.Address, errors.Err)
+}
+
+type Services struct {
+	Logger log.Logger
+	Client Client
+}
+
+// WalletHandler implements the handlers used by operators to
+// manage the wallets that send transactions
+type WalletHandler struct {
+	logger log.Logger
+	client Client
+}
+
+// NewWalletHandler creates a new instance of a wallet handler
+func NewWalletHandler(services Services) WalletHandler {
+	if services.Client == nil {
+		panic("Client must be provided as a service")
+	}
+	if services.Logger == nil {
+		panic("Logger must be provided as a service")
+	}
+
+	return WalletHandler{
+		logger: services.Logger.ForClass("wallet", "handler"),
+		client: services.Client,
+	}
+}
+
+func makeAddresses(addresses []common.Address) []string {
+	hexes := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		hexes = append(hexes, address.Hex())
+	}
+
+	return hexes
+}
+
+// ListWallets lists the addresses of the wallets
+func (h WalletHandler) ListWallets(ctx context.Context, v interface{}) (interface{}, error) {
+	return &ListWalletsResponse{
+		Addresses: makeAddresses(h.client.Senders()),
+	}, nil
+}
+
+// DeriveWallets raises the number of wallets derived from the HD
+// wallet, which start sending transactions right away
+func (h WalletHandler) DeriveWallets(ctx context.Context, v interface{}) (interface{}, error) {
+	req := v.(*DeriveWalletsRequest)
+
+	addresses, err := h.client.DeriveWallets(ctx, req.Count)
+	if err != nil {
+		h.logger.Debug(ctx, "failed to derive wallets", log.MapFields{
+			"call_type": "DeriveWalletsFailure",
+			"count":     req.Count,
+		}, err)
+		return nil, err
+	}
+
+	h.logger.Info(ctx, "wallets derived", log.MapFields{
+		"call_type": "DeriveWalletsSuccess",
+		"count":     req.Count,
+		"derived":   len(addresses),
+	})
+
+	return &DeriveWalletsResponse{
+		Addresses: makeAddresses(addresses),
+	}, nil
+}
+
+// BindHandler binds the wallet handler to the handler binder
+func BindHandler(services Services, binder rpc.HandlerBinder) {
+	handler := NewWalletHandler(services)
+
+	binder.Bind("POST", "/v0/api/admin/wallet/list", rpc.HandlerFunc(handler.ListWallets),
+		rpc.EntityFactoryFunc(func() interface{} { return &ListWalletsRequest{} }))
+	binder.Bind("POST", "/v0/api/admin/wallet/derive", rpc.HandlerFunc(handler.DeriveWallets),
+		rpc.EntityFactoryFunc(func() interface{} { return &DeriveWalletsRequest{} }))
+}
@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/log"
+	stderr "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var Context = context.TODO()
+
+var Logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Output: ioutil.Discard,
+})
+
+var (
+	wallet1 = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	wallet2 = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+type mockClient struct {
+	mock.Mock
+}
+
+func (c *mockClient) Senders() []common.Address {
+	args := c.Called()
+	return args.Get(0).([]common.Address)
+}
+
+func (c *mockClient) DeriveWallets(ctx context.Context, count int) ([]common.Address, errors.Err) {
+	args := c.Called(ctx, count)
+	if args.Get(1) == nil {
+		return args.Get(0).([]common.Address), nil
+	}
+
+	return nil, args.Get(1).(errors.Err)
+}
+
+func createWalletHandler() (WalletHandler, *mockClient) {
+	client := &mockClient{}
+	return NewWalletHandler(Services{
+		Logger: Logger,
+		Client: client,
+	}), client
+}
+
+func TestListWallets(t *testing.T) {
+	h, client := createWalletHandler()
+	client.On("Senders").Return([]common.Address{wallet1, wallet2})
+
+	res, err := h.ListWallets(Context, &ListWalletsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, &ListWalletsResponse{
+		Addresses: []string{wallet1.Hex(), wallet2.Hex()},
+	}, res)
+}
+
+func TestDeriveWallets(t *testing.T) {
+	h, client := createWalletHandler()
+	client.On("DeriveWallets", mock.Anything, 3).Return([]common.Address{wallet2}, nil)
+
+	res, err := h.DeriveWallets(Context, &DeriveWalletsRequest{Count: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, &DeriveWalletsResponse{
+		Addresses: []string{wallet2.Hex()},
+	}, res)
+}
+
+func TestDeriveWalletsOutOfRange(t *testing.T) {
+	h, client := createWalletHandler()
+	client.On("DeriveWallets", mock.Anything, 1).Return(nil,
+		errors.New(errors.ErrOutOfRange, stderr.New("count must be between 2 and 1000")))
+
+	_, err := h.DeriveWallets(Context, &DeriveWalletsRequest{Count: 1})
+	assert.Equal(t, errors.ErrOutOfRange, err.(errors.Err).ErrorCode())
+}
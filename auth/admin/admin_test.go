@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+var Logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Output: ioutil.Discard,
+})
+
+const tokenFile = `
+# admin tokens
+token1,ops
+token2,monitor
+`
+
+const rolesFile = `{
+  "principals": {"ops": ["operator"], "monitor": ["viewer"]},
+  "routes": {
+    "/v0/api/health": ["viewer", "operator"],
+    "*": ["operator"]
+  }
+}`
+
+func newTokenAuth(t *testing.T) *TokenAuth {
+	tokens, err := ReadTokens(strings.NewReader(tokenFile))
+	assert.Nil(t, err)
+	return NewTokenAuth(tokens)
+}
+
+func newRequest(t *testing.T, path, token string) *http.Request {
+	req, err := http.NewRequest("POST", "http://127.0.0.1:1234"+path, nil)
+	assert.Nil(t, err)
+	if len(token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestReadTokens(t *testing.T) {
+	tokens, err := ReadTokens(strings.NewReader("token\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Token{{Name: DefaultTokenName, secret: "token"}}, tokens)
+
+	_, err = ReadTokens(strings.NewReader("token1,ops\ntoken2,ops\n"))
+	assert.Error(t, err)
+
+	_, err = ReadTokens(strings.NewReader("# no tokens\n"))
+	assert.Error(t, err)
+}
+
+func TestTokenAuthAuthenticate(t *testing.T) {
+	auth := newTokenAuth(t)
+
+	req, err := auth.Authenticate(newRequest(t, "/v0/api/health", "token2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "monitor", core.MustGetAAD(req.Context()))
+
+	_, err = auth.Authenticate(newRequest(t, "/v0/api/health", "token3"))
+	assert.Equal(t, ErrUnknownToken, err)
+
+	_, err = auth.Authenticate(newRequest(t, "/v0/api/health", ""))
+	assert.Equal(t, ErrNoToken, err)
+
+	assert.Equal(t, uint64(1), auth.Stats()["tokenSuccesses"])
+	assert.Equal(t, uint64(2), auth.Stats()["tokenFailures"])
+}
+
+func TestReadRoles(t *testing.T) {
+	_, err := ReadRoles(strings.NewReader(`{"principals": {"ops": ["operator"]}}`))
+	assert.Error(t, err)
+
+	_, err = ReadRoles(strings.NewReader(`{"routes": {"/v0/api/health": []}}`))
+	assert.Error(t, err)
+
+	_, err = ReadRoles(strings.NewReader(`{"routes": {"/v0/api/health": ["*"]}, "unknown": 1}`))
+	assert.Error(t, err)
+}
+
+func TestRolesAllowed(t *testing.T) {
+	roles, err := ReadRoles(strings.NewReader(rolesFile))
+	assert.Nil(t, err)
+
+	assert.True(t, roles.Allowed("ops", "/v0/api/health"))
+	assert.True(t, roles.Allowed("monitor", "/v0/api/health"))
+	assert.True(t, roles.Allowed("ops", "/v0/api/admin/mailbox/list"))
+	assert.False(t, roles.Allowed("monitor", "/v0/api/admin/mailbox/list"))
+	assert.False(t, roles.Allowed("unknown", "/v0/api/health"))
+
+	roles, err = ReadRoles(strings.NewReader(`{"routes": {"/v0/api/health": ["*"]}}`))
+	assert.Nil(t, err)
+	assert.True(t, roles.Allowed("unknown", "/v0/api/health"))
+	assert.False(t, roles.Allowed("unknown", "/v0/api/admin/mailbox/list"))
+}
+
+func TestHttpMiddlewareAdmin(t *testing.T) {
+	roles, err := ReadRoles(strings.NewReader(rolesFile))
+	assert.Nil(t, err)
+
+	middleware := NewHttpMiddlewareAdmin(HttpMiddlewareAdminProps{
+		Auth:   newTokenAuth(t),
+		Roles:  roles,
+		Logger: Logger,
+	}, rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		return req.Context().Value(Principal{}), nil
+	}))
+
+	res, err := middleware.ServeHTTP(newRequest(t, "/v0/api/health", "token2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "monitor", res)
+
+	_, err = middleware.ServeHTTP(newRequest(t, "/v0/api/admin/mailbox/list", "token2"))
+	assert.Equal(t, http.StatusForbidden, err.(*rpc.HttpError).StatusCode)
+
+	_, err = middleware.ServeHTTP(newRequest(t, "/v0/api/health", "token3"))
+	assert.Equal(t, http.StatusUnauthorized, err.(*rpc.HttpError).StatusCode)
+}
+
+func TestHttpMiddlewareAdminNoRoles(t *testing.T) {
+	middleware := NewHttpMiddlewareAdmin(HttpMiddlewareAdminProps{
+		Auth:   newTokenAuth(t),
+		Logger: Logger,
+	}, rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		return req.Context().Value(Principal{}), nil
+	}))
+
+	res, err := middleware.ServeHTTP(newRequest(t, "/v0/api/admin/mailbox/list", "token2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "monitor", res)
+}
@@ -0,0 +1,225 @@
+package admin
+
+import (
+	"os"
+	"plugin"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/auth/mtls"
+	"github.com/oasislabs/oasis-gateway/config"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Provider is the mechanism used to authenticate
+// requests to the admin API
+type Provider string
+
+const (
+	// ProviderNone does not authenticate requests to the admin API,
+	// so the admin API is only served if Insecure is set
+	ProviderNone Provider = "none"
+
+	// ProviderToken authenticates requests with the
+	// bearer tokens of a token file
+	ProviderToken Provider = "token"
+
+	// ProviderMtls authenticates requests by their
+	// client certificate
+	ProviderMtls Provider = "mtls"
+
+	// ProviderPlugin authenticates requests with an
+	// auth provider loaded from a plugin
+	ProviderPlugin Provider = "plugin"
+)
+
+// Config is the configuration of the authentication and
+// authorization of the admin API served by the private router
+type Config struct {
+	Provider     Provider
+	Insecure     bool
+	TokenFile    string
+	Tokens       []Token
+	MtlsIdentity mtls.Identity
+	Plugin       string
+	Auth         core.Auth
+	RolesFile    string
+	Roles        *Roles
+}
+
+func (c *Config) Log(fields log.Fields) {
+	fields.Add("admin.provider", c.Provider)
+
+	switch c.Provider {
+	case ProviderNone:
+		fields.Add("admin.insecure", c.Insecure)
+	case ProviderToken:
+		// do not log the tokens themselves
+		fields.Add("admin.token_file", c.TokenFile)
+		fields.Add("admin.tokens", len(c.Tokens))
+	case ProviderMtls:
+		fields.Add("admin.mtls.identity", c.MtlsIdentity)
+	case ProviderPlugin:
+		fields.Add("admin.plugin", c.Plugin)
+	}
+
+	if c.Roles != nil {
+		fields.Add("admin.roles_file", c.RolesFile)
+	}
+}
+
+func (c *Config) Configure(v *viper.Viper) error {
+	c.Provider = Provider(v.GetString("admin.provider"))
+	c.Insecure = v.GetBool("admin.insecure")
+	if c.Insecure && c.Provider != ProviderNone {
+		return config.ErrInvalidValue{
+			Key:          "admin.insecure",
+			InvalidValue: "true",
+			Values:       []string{"admin.insecure requires admin.provider " + string(ProviderNone)},
+		}
+	}
+
+	switch c.Provider {
+	case ProviderNone:
+	case ProviderToken:
+		if err := c.configureToken(v); err != nil {
+			return err
+		}
+	case ProviderMtls:
+		c.MtlsIdentity = mtls.Identity(v.GetString("admin.mtls.identity"))
+		switch c.MtlsIdentity {
+		case mtls.IdentitySubject, mtls.IdentityCommonName, mtls.IdentityDNS, mtls.IdentityEmail, mtls.IdentityURI:
+		default:
+			return config.ErrInvalidValue{
+				Key:          "admin.mtls.identity",
+				InvalidValue: string(c.MtlsIdentity),
+				Values: []string{
+					string(mtls.IdentitySubject),
+					string(mtls.IdentityCommonName),
+					string(mtls.IdentityDNS),
+					string(mtls.IdentityEmail),
+					string(mtls.IdentityURI),
+				},
+			}
+		}
+	case ProviderPlugin:
+		if err := c.configurePlugin(v); err != nil {
+			return err
+		}
+	default:
+		return config.ErrInvalidValue{
+			Key:          "admin.provider",
+			InvalidValue: string(c.Provider),
+			Values:       []string{string(ProviderNone), string(ProviderToken), string(ProviderMtls), string(ProviderPlugin)},
+		}
+	}
+
+	c.RolesFile = v.GetString("admin.roles_file")
+	c.Roles = nil
+	if len(c.RolesFile) == 0 {
+		return nil
+	}
+	if c.Provider == ProviderNone {
+		return config.ErrInvalidValue{
+			Key:          "admin.roles_file",
+			InvalidValue: c.RolesFile,
+			Values:       []string{"roles require an admin.provider other than " + string(ProviderNone)},
+		}
+	}
+
+	f, err := os.Open(c.RolesFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	c.Roles, err = ReadRoles(f)
+	if err != nil {
+		return config.ErrInvalidValue{Key: "admin.roles_file", InvalidValue: c.RolesFile, Values: []string{err.Error()}}
+	}
+
+	return nil
+}
+
+func (c *Config) configureToken(v *viper.Viper) error {
+	c.TokenFile = v.GetString("admin.token_file")
+	if len(c.TokenFile) == 0 {
+		return config.ErrKeyNotSet{Key: "admin.token_file"}
+	}
+
+	f, err := os.Open(c.TokenFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	c.Tokens, err = ReadTokens(f)
+	if err != nil {
+		return config.ErrInvalidValue{Key: "admin.token_file", InvalidValue: c.TokenFile, Values: []string{err.Error()}}
+	}
+
+	return nil
+}
+
+func (c *Config) configurePlugin(v *viper.Viper) error {
+	c.Plugin = v.GetString("admin.plugin")
+	if len(c.Plugin) == 0 {
+		return config.ErrKeyNotSet{Key: "admin.plugin"}
+	}
+
+	plug, err := plugin.Open(c.Plugin)
+	if err != nil {
+		return config.ErrInvalidValue{Key: "admin.plugin", InvalidValue: c.Plugin}
+	}
+	symbol, err := plug.Lookup("Auth")
+	if err != nil {
+		return config.ErrInvalidValue{Key: "admin.plugin", InvalidValue: c.Plugin}
+	}
+	auth, ok := symbol.(core.Auth)
+	if !ok {
+		return config.ErrInvalidValue{Key: "admin.plugin", InvalidValue: c.Plugin}
+	}
+	c.Auth = auth
+
+	return nil
+}
+
+func (c *Config) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("admin.provider", string(ProviderNone),
+		"provider for the authentication of the requests to the private router. Options are "+
+			string(ProviderNone)+", "+string(ProviderToken)+", "+string(ProviderMtls)+", "+string(ProviderPlugin)+".")
+	cmd.PersistentFlags().Bool("admin.insecure", false,
+		"serve the admin routes of the private router without authentication when admin.provider is "+
+			string(ProviderNone)+". Only meant for local testing")
+	cmd.PersistentFlags().String("admin.token_file", "",
+		"path to the file with the bearer tokens of the admins as <token>,<name> lines")
+	cmd.PersistentFlags().String("admin.mtls.identity", string(mtls.IdentitySubject),
+		"part of the client certificate used as the name of the admin. Options are "+
+			string(mtls.IdentitySubject)+", "+string(mtls.IdentityCommonName)+", "+
+			string(mtls.IdentityDNS)+", "+string(mtls.IdentityEmail)+", "+string(mtls.IdentityURI)+".")
+	cmd.PersistentFlags().String("admin.plugin", "", "plugin for the authentication of the admins")
+	cmd.PersistentFlags().String("admin.roles_file", "",
+		"path to the file with the roles of the admins and of the routes of the private router. "+
+			"If not set authenticated admins can access all the routes")
+	return nil
+}
+
+// NewAuth creates the Auth that authenticates the requests
+// to the admin API. It returns nil if the requests
+// are not authenticated
+func NewAuth(config *Config) core.Auth {
+	switch config.Provider {
+	case ProviderToken:
+		return NewTokenAuth(config.Tokens)
+	case ProviderMtls:
+		return mtls.NewMtlsAuth(mtls.Props{
+			Identity:   config.MtlsIdentity,
+			SessionKey: mtls.SessionKeyIdentity,
+		})
+	case ProviderPlugin:
+		return config.Auth
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/rpc"
+)
+
+// Principal is the context key with the name of the principal
+// that issued a request to the admin API
+type Principal struct{}
+
+// HttpMiddlewareAdmin authenticates the requests to the admin API
+// and only lets them through if the principal that issued the
+// request has one of the roles required by the route
+type HttpMiddlewareAdmin struct {
+	auth   core.Auth
+	roles  *Roles
+	logger log.Logger
+	next   rpc.HttpMiddleware
+}
+
+// HttpMiddlewareAdminProps are the properties used to create
+// an HttpMiddlewareAdmin
+type HttpMiddlewareAdminProps struct {
+	// Auth authenticates the principal of the requests. The
+	// AAD it sets is the name of the principal
+	Auth core.Auth
+
+	// Roles are the roles required by each route. If not set
+	// every authenticated principal can access every route
+	Roles *Roles
+
+	Logger log.Logger
+}
+
+// NewHttpMiddlewareAdmin creates a new HttpMiddlewareAdmin
+func NewHttpMiddlewareAdmin(props HttpMiddlewareAdminProps, next rpc.HttpMiddleware) *HttpMiddlewareAdmin {
+	if props.Auth == nil {
+		panic("auth must be set")
+	}
+
+	if props.Logger == nil {
+		panic("log must be set")
+	}
+
+	if next == nil {
+		panic("next must be set")
+	}
+
+	return &HttpMiddlewareAdmin{
+		auth:   props.Auth,
+		roles:  props.Roles,
+		logger: props.Logger.ForClass("auth/admin", "HttpMiddlewareAdmin"),
+		next:   next,
+	}
+}
+
+func (m *HttpMiddlewareAdmin) ServeHTTP(req *http.Request) (interface{}, error) {
+	route := req.URL.Path
+
+	req, err := m.auth.Authenticate(req)
+	if err != nil {
+		newErr := errors.New(errors.ErrAuthenticateRequest, err)
+		return nil, &rpc.HttpError{
+			Cause:      &newErr,
+			StatusCode: http.StatusUnauthorized,
+		}
+	}
+
+	principal, _ := req.Context().Value(core.AAD{}).(string)
+	if m.roles != nil && !m.roles.Allowed(principal, route) {
+		m.logger.Info(req.Context(), "admin request denied", log.MapFields{
+			"call_type": "AdminRequestDenied",
+			"principal": principal,
+			"route":     route,
+		})
+
+		newErr := errors.New(errors.ErrAuthorizeRequest,
+			fmt.Errorf("%s does not have any of the roles of route %s", principal, route))
+		return nil, &rpc.HttpError{
+			Cause:      &newErr,
+			StatusCode: http.StatusForbidden,
+		}
+	}
+
+	m.logger.Debug(req.Context(), "admin request allowed", log.MapFields{
+		"call_type": "AdminRequestAllowed",
+		"principal": principal,
+		"route":     route,
+	})
+
+	req = req.WithContext(context.WithValue(req.Context(), Principal{}, principal))
+	return m.next.ServeHTTP(req)
+}
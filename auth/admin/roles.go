@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// AnyRoute is the route used for the roles of the
+	// routes that are not in the roles file
+	AnyRoute = "*"
+
+	// AnyPrincipal is the role that every authenticated
+	// principal has
+	AnyPrincipal = "*"
+)
+
+// Roles assigns roles to the principals that access the admin
+// API and the roles required by each admin route
+type Roles struct {
+	// Principals are the roles of each principal by its name
+	Principals map[string][]string `json:"principals"`
+
+	// Routes are the roles that are allowed to access each
+	// route by the path of the route. A principal needs one
+	// of the roles of the route to access the route
+	Routes map[string][]string `json:"routes"`
+}
+
+// ReadRoles reads and validates a roles file
+func ReadRoles(r io.Reader) (*Roles, error) {
+	var roles Roles
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&roles); err != nil {
+		return nil, err
+	}
+
+	if len(roles.Routes) == 0 {
+		return nil, stderr.New("roles file does not define the roles of any route")
+	}
+	for route, values := range roles.Routes {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("route %s does not have any role", route)
+		}
+	}
+
+	return &roles, nil
+}
+
+// Allowed returns true if the principal has one of the roles
+// required by the route. Routes that are not in the roles file
+// use the roles of AnyRoute, and are not allowed otherwise
+func (r *Roles) Allowed(principal, route string) bool {
+	required, ok := r.Routes[route]
+	if !ok {
+		required = r.Routes[AnyRoute]
+	}
+
+	granted := make(map[string]bool)
+	for _, role := range r.Principals[principal] {
+		granted[role] = true
+	}
+
+	for _, role := range required {
+		if role == AnyPrincipal || granted[role] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	stderr "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// DefaultTokenName is the name of the principal of the tokens
+// that are not given a name in the token file
+const DefaultTokenName = "admin"
+
+var (
+	// ErrNoToken is returned when the request does not
+	// have a bearer token
+	ErrNoToken = stderr.New("Authorization header does not have a bearer token")
+
+	// ErrUnknownToken is returned when the bearer token of the
+	// request is not in the token file
+	ErrUnknownToken = stderr.New("unknown bearer token")
+)
+
+// Token is a bearer token that grants access to the admin API
+type Token struct {
+	// Name of the principal that holds the token, which is used
+	// as the AAD of the requests authenticated with it
+	Name string
+
+	secret string
+}
+
+// ReadTokens reads a token file. Each line of the file has a token
+// and optionally the name of its principal separated by a comma, as
+// in "<token>,<name>". Empty lines and lines that start with # are
+// ignored
+func ReadTokens(r io.Reader) ([]Token, error) {
+	var tokens []Token
+	names := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, ",", 2)
+		token := Token{secret: strings.TrimSpace(parts[0]), Name: DefaultTokenName}
+		if len(parts) == 2 {
+			token.Name = strings.TrimSpace(parts[1])
+		}
+
+		if len(token.secret) == 0 || len(token.Name) == 0 {
+			return nil, fmt.Errorf("line %d of token file is not <token>,<name>", line)
+		}
+		if names[token.Name] {
+			return nil, fmt.Errorf("line %d of token file has duplicate name %s", line, token.Name)
+		}
+		names[token.Name] = true
+		tokens = append(tokens, token)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, stderr.New("token file does not have any token")
+	}
+
+	return tokens, nil
+}
+
+// TokenAuth authenticates requests to the admin API with
+// the bearer tokens from a token file
+type TokenAuth struct {
+	logger log.Logger
+	tokens []Token
+
+	successes stats.Counter
+	failures  stats.Counter
+}
+
+// NewTokenAuth creates a new TokenAuth that accepts
+// the provided tokens
+func NewTokenAuth(tokens []Token) *TokenAuth {
+	if len(tokens) == 0 {
+		panic("tokens must be set")
+	}
+
+	return &TokenAuth{tokens: tokens}
+}
+
+func (a *TokenAuth) Name() string {
+	return "auth.admin.TokenAuth"
+}
+
+func (a *TokenAuth) Stats() stats.Metrics {
+	return stats.Metrics{
+		"tokenSuccesses": a.successes.Value(),
+		"tokenFailures":  a.failures.Value(),
+	}
+}
+
+// Authenticate the request with the bearer token in the
+// Authorization header. The name of the token is the AAD
+func (a *TokenAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	value := req.Header.Get("Authorization")
+	if !strings.HasPrefix(value, "Bearer ") {
+		a.failures.Incr()
+		return req, ErrNoToken
+	}
+	secret := strings.TrimSpace(strings.TrimPrefix(value, "Bearer "))
+
+	// all the tokens are compared so that the time taken
+	// does not depend on which token matches
+	var name string
+	for _, token := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.secret), []byte(secret)) == 1 {
+			name = token.Name
+		}
+	}
+
+	if len(name) == 0 {
+		a.failures.Incr()
+		return req, ErrUnknownToken
+	}
+
+	a.successes.Incr()
+	ctx := context.WithValue(req.Context(), core.AAD{}, name)
+	return req.WithContext(ctx), nil
+}
+
+// Verify is not used by the admin API, which does not
+// accept requests with data to verify
+func (a *TokenAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	return stderr.New("admin tokens cannot be used to verify requests")
+}
+
+func (a *TokenAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/admin", "TokenAuth")
+}
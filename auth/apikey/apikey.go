@@ -0,0 +1,111 @@
+package apikey
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// ApiKeyHeader is the header in the *http.Request that contains
+// the API key
+const ApiKeyHeader = "X-OASIS-API-KEY"
+
+// keyContext is the context key used to keep the metadata of the
+// API key of an authenticated request
+type keyContext struct{}
+
+// ApiKeyAuth authenticates requests with an API key that is
+// looked up in a key store
+type ApiKeyAuth struct {
+	logger log.Logger
+	store  *Store
+
+	successes stats.Counter
+	failures  stats.Counter
+}
+
+// NewApiKeyAuth creates a new ApiKeyAuth that looks up keys
+// in the provided store
+func NewApiKeyAuth(store *Store) *ApiKeyAuth {
+	if store == nil {
+		panic("store must be set")
+	}
+
+	return &ApiKeyAuth{store: store}
+}
+
+func (a *ApiKeyAuth) Name() string {
+	return "auth.apikey.ApiKeyAuth"
+}
+
+func (a *ApiKeyAuth) Stats() stats.Metrics {
+	metrics := a.store.Stats()
+	metrics["apiKeySuccesses"] = a.successes.Value()
+	metrics["apiKeyFailures"] = a.failures.Value()
+	return metrics
+}
+
+// Authenticate looks up the API key of the request and sets the
+// owner of the key as the AAD
+func (a *ApiKeyAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	value := req.Header.Get(ApiKeyHeader)
+	if len(value) == 0 {
+		a.failures.Incr()
+		return req, fmt.Errorf("%s header not set", ApiKeyHeader)
+	}
+
+	key, ok := a.store.Lookup(value)
+	if !ok {
+		a.failures.Incr()
+		if a.logger != nil {
+			a.logger.Debug(req.Context(), "unknown api key", log.MapFields{
+				"call_type": "AuthenticateFailure",
+			})
+		}
+		return req, stderr.New("invalid api key")
+	}
+
+	a.successes.Incr()
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, key.Owner)
+	ctx = context.WithValue(ctx, keyContext{}, key)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, map[string]interface{}{"owner": key.Owner})
+	if len(key.SessionKey) > 0 {
+		ctx = context.WithValue(ctx, core.SessionKey{}, key.SessionKey)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// Verify that the API key allows the requested API and that
+// the AAD in the request data matches the owner of the key
+func (a *ApiKeyAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	key, ok := ctx.Value(keyContext{}).(Key)
+	if !ok {
+		return stderr.New("request was not authenticated with an api key")
+	}
+
+	if !key.Allows(req.API) {
+		return fmt.Errorf("api key is not allowed to use %s", req.API)
+	}
+
+	// deploy requests do not carry an AAD
+	if req.API == "Deploy" {
+		return nil
+	}
+
+	if string(req.AAD) != key.Owner {
+		return stderr.New("AAD does not match")
+	}
+
+	return nil
+}
+
+func (a *ApiKeyAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/apikey", "ApiKeyAuth")
+}
@@ -0,0 +1,74 @@
+package apikey
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAuth(t *testing.T) (*ApiKeyAuth, string) {
+	store, _, path := createStore(t,
+		Key{Hash: HashKey("key1"), Owner: "owner1", APIs: []string{"Execute"}},
+		Key{Hash: HashKey("key2"), Owner: "owner2", SessionKey: "session", APIs: []string{"Deploy"}})
+	return NewApiKeyAuth(store), filepath.Dir(path)
+}
+
+func newRequest(t *testing.T, key string) *http.Request {
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+	if len(key) > 0 {
+		req.Header.Add(ApiKeyHeader, key)
+	}
+	return req
+}
+
+func TestAuthenticate(t *testing.T) {
+	auth, dir := createAuth(t)
+	defer os.RemoveAll(dir)
+
+	req, err := auth.Authenticate(newRequest(t, "key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner1", req.Context().Value(core.AAD{}))
+	assert.Nil(t, req.Context().Value(core.SessionKey{}))
+
+	req, err = auth.Authenticate(newRequest(t, "key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner2", req.Context().Value(core.AAD{}))
+	assert.Equal(t, "session", req.Context().Value(core.SessionKey{}))
+	assert.Equal(t, uint64(2), auth.Stats()["apiKeySuccesses"])
+}
+
+func TestAuthenticateFailure(t *testing.T) {
+	auth, dir := createAuth(t)
+	defer os.RemoveAll(dir)
+
+	_, err := auth.Authenticate(newRequest(t, ""))
+	assert.Equal(t, "X-OASIS-API-KEY header not set", err.Error())
+
+	_, err = auth.Authenticate(newRequest(t, "key3"))
+	assert.Equal(t, "invalid api key", err.Error())
+	assert.Equal(t, uint64(2), auth.Stats()["apiKeyFailures"])
+}
+
+func TestVerify(t *testing.T) {
+	auth, dir := createAuth(t)
+	defer os.RemoveAll(dir)
+
+	req, err := auth.Authenticate(newRequest(t, "key1"))
+	assert.Nil(t, err)
+	ctx := req.Context()
+
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("owner1")}))
+	assert.Equal(t, "AAD does not match",
+		auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("owner2")}).Error())
+	assert.Equal(t, "api key is not allowed to use Deploy",
+		auth.Verify(ctx, core.AuthRequest{API: "Deploy"}).Error())
+
+	req, err = auth.Authenticate(newRequest(t, "key2"))
+	assert.Nil(t, err)
+	assert.Nil(t, auth.Verify(req.Context(), core.AuthRequest{API: "Deploy"}))
+}
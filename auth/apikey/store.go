@@ -0,0 +1,230 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// Key is the metadata associated with an API key
+type Key struct {
+	// Hash is the hex encoded SHA-256 hash of the API key. The
+	// API keys themselves are never stored
+	Hash string `json:"hash"`
+
+	// Owner identifies the owner of the key. It is used as the
+	// AAD of the requests authenticated with the key
+	Owner string `json:"owner"`
+
+	// SessionKey if set is the session key of the requests
+	// authenticated with the key instead of the session key header
+	SessionKey string `json:"sessionKey"`
+
+	// APIs are the APIs that the owner of the key is allowed
+	// to use, such as Deploy or Execute
+	APIs []string `json:"apis"`
+}
+
+// Allows returns true if the key allows the use of the API
+func (k Key) Allows(api string) bool {
+	for _, allowed := range k.APIs {
+		if allowed == api {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyFile is the format of the key store file
+type KeyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+// HashKey returns the hash of an API key as expected in the
+// key store file
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// StoreProps are the properties used to create a Store
+type StoreProps struct {
+	// Path to the key store file
+	Path string
+
+	// ReloadInterval is the minimum time between two checks of
+	// the key store file for changes
+	ReloadInterval time.Duration
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// Store keeps the API keys loaded from a key store file. The file
+// is checked for changes when keys are looked up, so that keys can be
+// added and revoked without restarting the gateway
+type Store struct {
+	path           string
+	reloadInterval time.Duration
+	now            func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]Key
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+
+	reloads        stats.Counter
+	reloadFailures stats.Counter
+}
+
+// NewStore creates a new Store and loads the key store file
+func NewStore(props StoreProps) (*Store, error) {
+	if len(props.Path) == 0 {
+		panic("Path must be set")
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	s := &Store{
+		path:           props.Path,
+		reloadInterval: props.ReloadInterval,
+		now:            now,
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.load(info); err != nil {
+		return nil, err
+	}
+
+	s.lastCheck = now()
+	return s, nil
+}
+
+// Stats returns the metrics collected by the store
+func (s *Store) Stats() stats.Metrics {
+	s.mu.RLock()
+	keys := len(s.keys)
+	s.mu.RUnlock()
+
+	return stats.Metrics{
+		"apiKeys":              keys,
+		"apiKeyReloads":        s.reloads.Value(),
+		"apiKeyReloadFailures": s.reloadFailures.Value(),
+	}
+}
+
+// Lookup returns the metadata of the API key, if the key is in
+// the store
+func (s *Store) Lookup(key string) (Key, bool) {
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[HashKey(key)]
+	return k, ok
+}
+
+func (s *Store) reloadIfChanged() {
+	now := s.now()
+
+	s.mu.RLock()
+	check := now.Sub(s.lastCheck) >= s.reloadInterval
+	s.mu.RUnlock()
+	if !check {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// another goroutine may have checked the file already
+	if now.Sub(s.lastCheck) < s.reloadInterval {
+		return
+	}
+	s.lastCheck = now
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		// keep the keys loaded if the file is temporarily unavailable
+		s.reloadFailures.Incr()
+		return
+	}
+
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return
+	}
+
+	if err := s.loadLocked(info); err != nil {
+		s.reloadFailures.Incr()
+		return
+	}
+
+	s.reloads.Incr()
+}
+
+func (s *Store) load(info os.FileInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadLocked(info)
+}
+
+func (s *Store) loadLocked(info os.FileInfo) error {
+	p, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseKeys(p)
+	if err != nil {
+		return fmt.Errorf("failed to parse key store %s: %s", s.path, err.Error())
+	}
+
+	s.keys = keys
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return nil
+}
+
+func parseKeys(p []byte) (map[string]Key, error) {
+	var file KeyFile
+	if err := json.Unmarshal(p, &file); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key, len(file.Keys))
+	for i, key := range file.Keys {
+		hash := strings.ToLower(key.Hash)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("key %d does not have a valid SHA-256 hash", i)
+		}
+		if len(key.Owner) == 0 {
+			return nil, fmt.Errorf("key %d does not have an owner", i)
+		}
+		if _, ok := keys[hash]; ok {
+			return nil, fmt.Errorf("key %d is duplicated", i)
+		}
+
+		key.Hash = hash
+		keys[hash] = key
+	}
+
+	return keys, nil
+}
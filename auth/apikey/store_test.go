@@ -0,0 +1,100 @@
+package apikey
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func writeKeys(t *testing.T, path string, modTime time.Time, keys ...Key) {
+	p, err := json.Marshal(KeyFile{Keys: keys})
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, p, 0600))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func createStore(t *testing.T, keys ...Key) (*Store, *clock, string) {
+	dir, err := ioutil.TempDir("", "apikey")
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "keys.json")
+	c := &clock{now: time.Now()}
+	writeKeys(t, path, c.now, keys...)
+
+	store, err := NewStore(StoreProps{
+		Path:           path,
+		ReloadInterval: time.Second,
+		Now:            c.Now,
+	})
+	assert.Nil(t, err)
+	return store, c, path
+}
+
+func TestStoreLookup(t *testing.T) {
+	store, _, path := createStore(t, Key{Hash: HashKey("key1"), Owner: "owner1"})
+	defer os.RemoveAll(filepath.Dir(path))
+
+	key, ok := store.Lookup("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "owner1", key.Owner)
+
+	_, ok = store.Lookup("key2")
+	assert.False(t, ok)
+}
+
+func TestStoreReload(t *testing.T) {
+	store, c, path := createStore(t, Key{Hash: HashKey("key1"), Owner: "owner1"})
+	defer os.RemoveAll(filepath.Dir(path))
+
+	// key1 is revoked and key2 added
+	writeKeys(t, path, c.now.Add(time.Second), Key{Hash: HashKey("key2"), Owner: "owner2"})
+
+	// the file is not checked until the reload interval passes
+	_, ok := store.Lookup("key2")
+	assert.False(t, ok)
+
+	c.now = c.now.Add(time.Second)
+	_, ok = store.Lookup("key2")
+	assert.True(t, ok)
+	_, ok = store.Lookup("key1")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), store.Stats()["apiKeyReloads"])
+}
+
+func TestStoreReloadInvalid(t *testing.T) {
+	store, c, path := createStore(t, Key{Hash: HashKey("key1"), Owner: "owner1"})
+	defer os.RemoveAll(filepath.Dir(path))
+
+	// an invalid file keeps the keys previously loaded
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	c.now = c.now.Add(time.Second)
+
+	_, ok := store.Lookup("key1")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), store.Stats()["apiKeyReloadFailures"])
+}
+
+func TestParseKeysInvalid(t *testing.T) {
+	_, err := parseKeys([]byte(`{"keys":[{"hash":"abc","owner":"owner"}]}`))
+	assert.Equal(t, "key 0 does not have a valid SHA-256 hash", err.Error())
+
+	_, err = parseKeys([]byte(`{"keys":[{"hash":"` + HashKey("key") + `"}]}`))
+	assert.Equal(t, "key 0 does not have an owner", err.Error())
+
+	_, err = parseKeys([]byte(`{"keys":[{"hash":"` + HashKey("key") + `","owner":"a"},` +
+		`{"hash":"` + HashKey("key") + `","owner":"b"}]}`))
+	assert.Equal(t, "key 1 is duplicated", err.Error())
+}
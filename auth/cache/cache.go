@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// Props are the properties used to create a Cache
+type Props struct {
+	// MaxSize is the maximum number of entries kept by the
+	// cache. When the cache is full, the least recently used
+	// entry is evicted
+	MaxSize int
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// Cache is a bounded least recently used cache in which each entry
+// expires at its own time. It is safe for concurrent use
+type Cache struct {
+	maxSize int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+
+	hits      stats.Counter
+	misses    stats.Counter
+	evictions stats.Counter
+}
+
+// New creates a new Cache
+func New(props Props) *Cache {
+	if props.MaxSize <= 0 {
+		panic("MaxSize must be positive")
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Cache{
+		maxSize: props.MaxSize,
+		now:     now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value for the key if it is in the cache and
+// has not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses.Incr()
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.removeElement(el)
+		c.misses.Incr()
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.hits.Incr()
+	return e.value, true
+}
+
+// Set adds the value to the cache until the expiration time. Values
+// that have already expired are not added
+func (c *Cache) Set(key string, value interface{}, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.now().Before(expires) {
+		return
+	}
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.maxSize {
+		c.removeElement(c.order.Back())
+		c.evictions.Incr()
+	}
+}
+
+// Len returns the number of entries in the cache, including
+// those that have expired but have not been removed yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the metrics collected by the cache
+func (c *Cache) Stats() stats.Metrics {
+	hits := c.hits.Value()
+	misses := c.misses.Value()
+
+	var hitRate float64
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+
+	return stats.Metrics{
+		"hits":      hits,
+		"misses":    misses,
+		"evictions": c.evictions.Value(),
+		"hitRate":   hitRate,
+		"size":      c.Len(),
+	}
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
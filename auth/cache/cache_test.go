@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestCacheGetSet(t *testing.T) {
+	c := &clock{now: time.Now()}
+	cache := New(Props{MaxSize: 2, Now: c.Now})
+
+	cache.Set("a", 1, c.now.Add(time.Minute))
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(1), cache.Stats()["hits"])
+	assert.Equal(t, uint64(1), cache.Stats()["misses"])
+	assert.Equal(t, 0.5, cache.Stats()["hitRate"])
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := &clock{now: time.Now()}
+	cache := New(Props{MaxSize: 2, Now: c.Now})
+
+	cache.Set("a", 1, c.now.Add(time.Minute))
+	cache.Set("b", 2, c.now)
+	assert.Equal(t, 1, cache.Len())
+
+	c.now = c.now.Add(time.Minute)
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := &clock{now: time.Now()}
+	cache := New(Props{MaxSize: 2, Now: c.Now})
+	expires := c.now.Add(time.Minute)
+
+	cache.Set("a", 1, expires)
+	cache.Set("b", 2, expires)
+
+	// a is used so b becomes the least recently used entry
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Set("c", 3, expires)
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	_, ok = cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), cache.Stats()["evictions"])
+}
@@ -1,10 +1,18 @@
 package auth
 
 import (
+	"fmt"
+	"os"
 	"plugin"
 	"strings"
+	"time"
 
 	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/auth/hmac"
+	"github.com/oasislabs/oasis-gateway/auth/jwt"
+	"github.com/oasislabs/oasis-gateway/auth/mtls"
+	"github.com/oasislabs/oasis-gateway/auth/signature"
+	"github.com/oasislabs/oasis-gateway/auth/webhook"
 	"github.com/oasislabs/oasis-gateway/config"
 	"github.com/oasislabs/oasis-gateway/log"
 	"github.com/spf13/cobra"
@@ -16,22 +24,77 @@ type AuthProvider string
 const (
 	AuthInsecure = "insecure"
 	AuthOauth    = "oauth"
+	AuthJwt      = "jwt"
+	AuthApiKey   = "apikey"
+	AuthHmac     = "hmac"
+	AuthMtls     = "mtls"
+	AuthWebhook  = "webhook"
 )
 
+// jwtMinRefreshInterval is the minimum time between two attempts
+// to retrieve the JWT keys when tokens are signed by unknown keys
+const jwtMinRefreshInterval = time.Minute
+
 // Config sets the configuration for the authentication
 // mechanism to use
 type Config struct {
+	// Provider are the built-in providers to use, which are
+	// created by the Factory
+	Provider []AuthProvider
+
+	// Providers are the providers loaded from plugins
 	Providers []core.Auth
+
+	// Mode is how the providers are combined when
+	// more than one is configured
+	Mode core.MultiAuthMode
+
+	// Roles are the roles of the providers in chain mode by the
+	// name of the provider. Providers without a role both
+	// authenticate and verify requests
+	Roles map[string]core.Role
+
+	TenantPrefix    bool
+	OauthConfig     OauthConfig
+	JwtConfig       JwtConfig
+	ApiKeyConfig    ApiKeyConfig
+	HmacConfig      HmacConfig
+	MtlsConfig      MtlsConfig
+	PolicyConfig    PolicyConfig
+	WebhookConfig   WebhookConfig
+	SignatureConfig SignatureConfig
 }
 
 func (c *Config) Log(fields log.Fields) {
 	var names []string
 
+	for _, provider := range c.Provider {
+		names = append(names, string(provider))
+	}
 	for _, provider := range c.Providers {
 		names = append(names, provider.Name())
 	}
 
 	fields.Add("auth.provider", strings.Join(names, ", "))
+	fields.Add("auth.mode", c.Mode)
+	if len(c.Roles) > 0 {
+		var roles []string
+		for _, name := range names {
+			if role, ok := c.Roles[name]; ok {
+				roles = append(roles, name+"="+string(role))
+			}
+		}
+		fields.Add("auth.roles", strings.Join(roles, ", "))
+	}
+	fields.Add("auth.tenant_prefix", c.TenantPrefix)
+	c.OauthConfig.Log(fields)
+	c.JwtConfig.Log(fields)
+	c.ApiKeyConfig.Log(fields)
+	c.HmacConfig.Log(fields)
+	c.MtlsConfig.Log(fields)
+	c.PolicyConfig.Log(fields)
+	c.WebhookConfig.Log(fields)
+	c.SignatureConfig.Log(fields)
 }
 
 func (c *Config) Configure(v *viper.Viper) error {
@@ -39,13 +102,22 @@ func (c *Config) Configure(v *viper.Viper) error {
 		c.Providers = make([]core.Auth, 0)
 	}
 
+	c.TenantPrefix = v.GetBool("auth.tenant_prefix")
+
+	if err := c.PolicyConfig.Configure(v); err != nil {
+		return err
+	}
+	if err := c.SignatureConfig.Configure(v); err != nil {
+		return err
+	}
+
+	c.Provider = nil
 	providers := v.GetStringSlice("auth.provider")
 	for _, provider := range providers {
-		auth := newAuthSingle(AuthProvider(provider))
-		if auth == nil {
-			return config.ErrKeyNotSet{Key: "auth.provider"}
+		if err := c.configureProvider(v, AuthProvider(provider)); err != nil {
+			return err
 		}
-		c.Providers = append(c.Providers, auth)
+		c.Provider = append(c.Provider, AuthProvider(provider))
 	}
 
 	providers = v.GetStringSlice("auth.plugin")
@@ -65,11 +137,672 @@ func (c *Config) Configure(v *viper.Viper) error {
 		c.Providers = append(c.Providers, auth)
 	}
 
-	return nil
+	return c.configureMode(v)
+}
+
+// configureMode reads how the providers are combined and the roles of
+// the providers, which must refer to the providers configured
+func (c *Config) configureMode(v *viper.Viper) error {
+	c.Mode = core.MultiAuthMode(v.GetString("auth.mode"))
+	switch c.Mode {
+	case core.MultiAuthAnyOf, core.MultiAuthAllOf, core.MultiAuthChain:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "auth.mode",
+			InvalidValue: string(c.Mode),
+			Values:       []string{string(core.MultiAuthAnyOf), string(core.MultiAuthAllOf), string(core.MultiAuthChain)},
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, provider := range c.Provider {
+		names[string(provider)] = true
+	}
+	for _, provider := range c.Providers {
+		names[provider.Name()] = true
+	}
+
+	c.Roles = make(map[string]core.Role)
+	for _, value := range v.GetStringSlice("auth.roles") {
+		if c.Mode != core.MultiAuthChain {
+			return config.ErrInvalidValue{
+				Key:          "auth.roles",
+				InvalidValue: value,
+				Values:       []string{"roles can only be set in " + string(core.MultiAuthChain) + " mode"},
+			}
+		}
+
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || !names[parts[0]] {
+			return config.ErrInvalidValue{
+				Key:          "auth.roles",
+				InvalidValue: value,
+				Values:       []string{"<provider>=<role> for one of the providers configured"},
+			}
+		}
+
+		role := core.Role(parts[1])
+		switch role {
+		case core.RoleAuthenticate, core.RoleVerify, core.RoleBoth:
+		default:
+			return config.ErrInvalidValue{
+				Key:          "auth.roles",
+				InvalidValue: value,
+				Values:       []string{string(core.RoleAuthenticate), string(core.RoleVerify), string(core.RoleBoth)},
+			}
+		}
+		c.Roles[parts[0]] = role
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	authenticates, verifies := false, false
+	for name := range names {
+		role, ok := c.Roles[name]
+		if !ok {
+			role = core.RoleBoth
+		}
+		authenticates = authenticates || role != core.RoleVerify
+		verifies = verifies || role != core.RoleAuthenticate
+	}
+
+	switch {
+	case !authenticates:
+		return config.ErrInvalidValue{
+			Key:          "auth.roles",
+			InvalidValue: strings.Join(v.GetStringSlice("auth.roles"), ","),
+			Values:       []string{"at least one provider must authenticate requests"},
+		}
+	case !verifies:
+		return config.ErrInvalidValue{
+			Key:          "auth.roles",
+			InvalidValue: strings.Join(v.GetStringSlice("auth.roles"), ","),
+			Values:       []string{"at least one provider must verify requests"},
+		}
+	default:
+		return nil
+	}
+}
+
+// configureProvider reads the configuration specific to a provider
+func (c *Config) configureProvider(v *viper.Viper, provider AuthProvider) error {
+	switch provider {
+	case AuthOauth:
+		return c.OauthConfig.Configure(v)
+	case AuthJwt:
+		return c.JwtConfig.Configure(v)
+	case AuthApiKey:
+		return c.ApiKeyConfig.Configure(v)
+	case AuthHmac:
+		return c.HmacConfig.Configure(v)
+	case AuthMtls:
+		return c.MtlsConfig.Configure(v)
+	case AuthWebhook:
+		return c.WebhookConfig.Configure(v)
+	case AuthInsecure:
+		return nil
+	default:
+		return config.ErrInvalidValue{
+			Key:          "auth.provider",
+			InvalidValue: string(provider),
+			Values:       []string{AuthInsecure, AuthOauth, AuthJwt, AuthApiKey, AuthHmac, AuthMtls, AuthWebhook},
+		}
+	}
 }
 
 func (c *Config) Bind(v *viper.Viper, cmd *cobra.Command) error {
-	cmd.PersistentFlags().StringSlice("auth.provider", []string{"insecure"}, "providers for request authentication")
+	cmd.PersistentFlags().StringSlice("auth.provider", []string{"insecure"},
+		"providers for request authentication. Options are "+
+			AuthInsecure+", "+AuthOauth+", "+AuthJwt+", "+AuthApiKey+", "+
+			AuthHmac+", "+AuthMtls+", "+AuthWebhook+".")
 	cmd.PersistentFlags().StringSlice("auth.plugin", []string{}, "plugins for request authentication")
+	cmd.PersistentFlags().String("auth.mode", string(core.MultiAuthAnyOf),
+		"how multiple providers are combined. Options are "+string(core.MultiAuthAnyOf)+", "+
+			string(core.MultiAuthAllOf)+", "+string(core.MultiAuthChain)+".")
+	cmd.PersistentFlags().StringSlice("auth.roles", []string{},
+		"roles of the providers in "+string(core.MultiAuthChain)+" mode as <provider>=<role>. Roles are "+
+			string(core.RoleAuthenticate)+", "+string(core.RoleVerify)+", "+string(core.RoleBoth)+
+			". Providers without a role are "+string(core.RoleBoth))
+	cmd.PersistentFlags().Bool("auth.tenant_prefix", false,
+		"if set the sessions of the requests are prefixed with the tenant provided by the authentication provider")
+
+	if err := (&OauthConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&JwtConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&ApiKeyConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&HmacConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&MtlsConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&PolicyConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&WebhookConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&SignatureConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OauthConfig is the configuration for the oauth provider, which
+// authenticates requests with a Google ID token
+type OauthConfig struct {
+	Enabled   bool
+	CacheSize int
+}
+
+func (c *OauthConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.oauth.cache_size", c.CacheSize)
+}
+
+func (c *OauthConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	c.CacheSize = v.GetInt("auth.oauth.cache_size")
+	if c.CacheSize < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.oauth.cache_size",
+			InvalidValue: fmt.Sprintf("%d", c.CacheSize),
+			Values:       []string{},
+		}
+	}
+
+	return nil
+}
+
+func (c *OauthConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Int("auth.oauth.cache_size", 1024,
+		"maximum number of verified ID tokens cached until they expire. If 0 tokens are not cached")
+	return nil
+}
+
+// JwtConfig is the configuration for the jwt provider, which
+// authenticates requests with a JWT signed by a trusted issuer
+type JwtConfig struct {
+	Enabled         bool
+	Issuer          string
+	Audience        string
+	JwksURL         string
+	KeyFiles        []string
+	SigningAlgs     []string
+	AADClaim        string
+	SessionKeyClaim string
+	AllowDeploy     bool
+	RefreshInterval time.Duration
+}
+
+func (c *JwtConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.jwt.issuer", c.Issuer)
+	fields.Add("auth.jwt.audience", c.Audience)
+	fields.Add("auth.jwt.jwks_url", c.JwksURL)
+	fields.Add("auth.jwt.key_files", strings.Join(c.KeyFiles, ", "))
+	fields.Add("auth.jwt.signing_algs", strings.Join(c.SigningAlgs, ", "))
+	fields.Add("auth.jwt.aad_claim", c.AADClaim)
+	fields.Add("auth.jwt.session_key_claim", c.SessionKeyClaim)
+	fields.Add("auth.jwt.allow_deploy", c.AllowDeploy)
+	fields.Add("auth.jwt.refresh_interval_ms", c.RefreshInterval.Milliseconds())
+}
+
+func (c *JwtConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	c.Issuer = v.GetString("auth.jwt.issuer")
+	if len(c.Issuer) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.jwt.issuer"}
+	}
+
+	c.Audience = v.GetString("auth.jwt.audience")
+	c.JwksURL = v.GetString("auth.jwt.jwks_url")
+	c.KeyFiles = v.GetStringSlice("auth.jwt.key_files")
+	if len(c.JwksURL) == 0 && len(c.KeyFiles) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.jwt.jwks_url"}
+	}
+	if len(c.JwksURL) > 0 && len(c.KeyFiles) > 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.jwt.key_files",
+			InvalidValue: strings.Join(c.KeyFiles, ","),
+			Values:       []string{"only one of auth.jwt.jwks_url and auth.jwt.key_files can be set"},
+		}
+	}
+
+	c.SigningAlgs = v.GetStringSlice("auth.jwt.signing_algs")
+	c.AADClaim = v.GetString("auth.jwt.aad_claim")
+	if len(c.AADClaim) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.jwt.aad_claim"}
+	}
+
+	c.SessionKeyClaim = v.GetString("auth.jwt.session_key_claim")
+	c.AllowDeploy = v.GetBool("auth.jwt.allow_deploy")
+
+	refreshInterval := v.GetInt64("auth.jwt.refresh_interval_ms")
+	if refreshInterval < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.jwt.refresh_interval_ms",
+			InvalidValue: fmt.Sprintf("%d", refreshInterval),
+			Values:       []string{},
+		}
+	}
+	c.RefreshInterval = time.Duration(refreshInterval) * time.Millisecond
+
+	return nil
+}
+
+func (c *JwtConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.jwt.issuer", "", "expected issuer of the tokens")
+	cmd.PersistentFlags().String("auth.jwt.audience", "",
+		"expected audience of the tokens. If not set the audience is not checked")
+	cmd.PersistentFlags().String("auth.jwt.jwks_url", "", "url of the JWKS with the keys of the issuer")
+	cmd.PersistentFlags().StringSlice("auth.jwt.key_files", []string{},
+		"local files with the keys of the issuer as JWKS or PEM. Used instead of auth.jwt.jwks_url")
+	cmd.PersistentFlags().StringSlice("auth.jwt.signing_algs", []string{jwt.RS256},
+		"algorithms accepted for the signature of the tokens")
+	cmd.PersistentFlags().String("auth.jwt.aad_claim", jwt.DefaultAADClaim, "token claim used as the AAD")
+	cmd.PersistentFlags().String("auth.jwt.session_key_claim", "",
+		"token claim used as the session key. If not set the session key header is used")
+	cmd.PersistentFlags().Bool("auth.jwt.allow_deploy", false,
+		"if set authenticated users are allowed to deploy services")
+	cmd.PersistentFlags().Int64("auth.jwt.refresh_interval_ms", 3600000,
+		"interval after which the keys are retrieved again. If 0 keys are only "+
+			"retrieved again when a token is signed by an unknown key")
+	return nil
+}
+
+// ApiKeyConfig is the configuration for the apikey provider, which
+// authenticates requests with API keys from a key store file
+type ApiKeyConfig struct {
+	Enabled        bool
+	File           string
+	ReloadInterval time.Duration
+}
+
+func (c *ApiKeyConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.apikey.file", c.File)
+	fields.Add("auth.apikey.reload_interval_ms", c.ReloadInterval.Milliseconds())
+}
+
+func (c *ApiKeyConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	c.File = v.GetString("auth.apikey.file")
+	if len(c.File) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.apikey.file"}
+	}
+
+	reloadInterval := v.GetInt64("auth.apikey.reload_interval_ms")
+	if reloadInterval < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.apikey.reload_interval_ms",
+			InvalidValue: fmt.Sprintf("%d", reloadInterval),
+			Values:       []string{},
+		}
+	}
+	c.ReloadInterval = time.Duration(reloadInterval) * time.Millisecond
+
+	return nil
+}
+
+func (c *ApiKeyConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.apikey.file", "", "path to the key store file with the hashed api keys")
+	cmd.PersistentFlags().Int64("auth.apikey.reload_interval_ms", 5000,
+		"interval at which the key store file is checked for changes")
+	return nil
+}
+
+// HmacConfig is the configuration for the hmac provider, which
+// authenticates requests signed with a shared secret
+type HmacConfig struct {
+	Enabled      bool
+	Keys         map[string]hmac.Key
+	MaxSkew      time.Duration
+	MaxBodyBytes int64
+}
+
+func (c *HmacConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	// do not log the secrets themselves
+	fields.Add("auth.hmac.keys", len(c.Keys))
+	fields.Add("auth.hmac.max_skew_ms", c.MaxSkew.Milliseconds())
+	fields.Add("auth.hmac.max_body_bytes", c.MaxBodyBytes)
+}
+
+func (c *HmacConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	path := v.GetString("auth.hmac.key_file")
+	if len(path) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.hmac.key_file"}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	c.Keys, err = hmac.ReadKeys(f)
+	if err != nil {
+		return config.ErrInvalidValue{Key: "auth.hmac.key_file", InvalidValue: path, Values: []string{err.Error()}}
+	}
+
+	maxSkew := v.GetInt64("auth.hmac.max_skew_ms")
+	if maxSkew <= 0 || time.Duration(maxSkew)*time.Millisecond > hmac.MaxSkew {
+		return config.ErrInvalidValue{
+			Key:          "auth.hmac.max_skew_ms",
+			InvalidValue: fmt.Sprintf("%d", maxSkew),
+			Values:       []string{fmt.Sprintf("(0, %d]", hmac.MaxSkew.Milliseconds())},
+		}
+	}
+	c.MaxSkew = time.Duration(maxSkew) * time.Millisecond
+
+	c.MaxBodyBytes = v.GetInt64("auth.hmac.max_body_bytes")
+	if c.MaxBodyBytes <= 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.hmac.max_body_bytes",
+			InvalidValue: fmt.Sprintf("%d", c.MaxBodyBytes),
+			Values:       []string{},
+		}
+	}
+
+	return nil
+}
+
+func (c *HmacConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.hmac.key_file", "", "path to the file with the shared secrets")
+	cmd.PersistentFlags().Int64("auth.hmac.max_skew_ms", hmac.MaxSkew.Milliseconds(),
+		"maximum difference between the timestamp of a request and the gateway time")
+	cmd.PersistentFlags().Int64("auth.hmac.max_body_bytes", 1<<16,
+		"maximum size of a request body that is read to verify its signature")
+	return nil
+}
+
+// MtlsConfig is the configuration for the mtls provider, which
+// authenticates requests by their client certificate
+type MtlsConfig struct {
+	Enabled     bool
+	Identity    mtls.Identity
+	SessionKey  mtls.SessionKey
+	AllowDeploy bool
+}
+
+func (c *MtlsConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.mtls.identity", c.Identity)
+	fields.Add("auth.mtls.session_key", c.SessionKey)
+	fields.Add("auth.mtls.allow_deploy", c.AllowDeploy)
+}
+
+func (c *MtlsConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	c.Identity = mtls.Identity(v.GetString("auth.mtls.identity"))
+	switch c.Identity {
+	case mtls.IdentitySubject, mtls.IdentityCommonName, mtls.IdentityDNS, mtls.IdentityEmail, mtls.IdentityURI:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "auth.mtls.identity",
+			InvalidValue: string(c.Identity),
+			Values: []string{
+				string(mtls.IdentitySubject),
+				string(mtls.IdentityCommonName),
+				string(mtls.IdentityDNS),
+				string(mtls.IdentityEmail),
+				string(mtls.IdentityURI),
+			},
+		}
+	}
+
+	c.SessionKey = mtls.SessionKey(v.GetString("auth.mtls.session_key"))
+	switch c.SessionKey {
+	case mtls.SessionKeyHeader, mtls.SessionKeyIdentity, mtls.SessionKeyFingerprint:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "auth.mtls.session_key",
+			InvalidValue: string(c.SessionKey),
+			Values: []string{
+				string(mtls.SessionKeyHeader),
+				string(mtls.SessionKeyIdentity),
+				string(mtls.SessionKeyFingerprint),
+			},
+		}
+	}
+
+	c.AllowDeploy = v.GetBool("auth.mtls.allow_deploy")
+	return nil
+}
+
+func (c *MtlsConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.mtls.identity", string(mtls.IdentitySubject),
+		"part of the client certificate used as the AAD. Options are "+
+			string(mtls.IdentitySubject)+", "+string(mtls.IdentityCommonName)+", "+
+			string(mtls.IdentityDNS)+", "+string(mtls.IdentityEmail)+", "+string(mtls.IdentityURI)+".")
+	cmd.PersistentFlags().String("auth.mtls.session_key", string(mtls.SessionKeyHeader),
+		"source of the session key. Options are "+string(mtls.SessionKeyHeader)+", "+
+			string(mtls.SessionKeyIdentity)+", "+string(mtls.SessionKeyFingerprint)+".")
+	cmd.PersistentFlags().Bool("auth.mtls.allow_deploy", false,
+		"if set authenticated clients are allowed to deploy services")
+	return nil
+}
+
+// PolicyConfig is the configuration for the authorization policy
+// applied to the requests verified by the providers
+type PolicyConfig struct {
+	Enabled        bool
+	File           string
+	ReloadInterval time.Duration
+}
+
+func (c *PolicyConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.policy.file", c.File)
+	fields.Add("auth.policy.reload_interval_ms", c.ReloadInterval.Milliseconds())
+}
+
+func (c *PolicyConfig) Configure(v *viper.Viper) error {
+	c.File = v.GetString("auth.policy.file")
+	c.Enabled = len(c.File) > 0
+	if !c.Enabled {
+		return nil
+	}
+
+	reloadInterval := v.GetInt64("auth.policy.reload_interval_ms")
+	if reloadInterval < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.policy.reload_interval_ms",
+			InvalidValue: fmt.Sprintf("%d", reloadInterval),
+			Values:       []string{},
+		}
+	}
+	c.ReloadInterval = time.Duration(reloadInterval) * time.Millisecond
+
+	return nil
+}
+
+func (c *PolicyConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.policy.file", "",
+		"path to the rules file of the authorization policy. If not set no policy is applied")
+	cmd.PersistentFlags().Int64("auth.policy.reload_interval_ms", 5000,
+		"interval at which the rules file is checked for changes")
+	return nil
+}
+
+// WebhookConfig is the configuration for the webhook provider, which
+// delegates the authentication and authorization of requests to an
+// external http service
+type WebhookConfig struct {
+	Enabled     bool
+	URL         string
+	Timeout     time.Duration
+	Headers     []string
+	FailureMode webhook.FailureMode
+	CacheSize   int
+	CacheTTL    time.Duration
+}
+
+func (c *WebhookConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.webhook.url", c.URL)
+	fields.Add("auth.webhook.timeout_ms", c.Timeout.Milliseconds())
+	fields.Add("auth.webhook.headers", strings.Join(c.Headers, ", "))
+	fields.Add("auth.webhook.failure_mode", c.FailureMode)
+	fields.Add("auth.webhook.cache_size", c.CacheSize)
+	fields.Add("auth.webhook.cache_ttl_ms", c.CacheTTL.Milliseconds())
+}
+
+func (c *WebhookConfig) Configure(v *viper.Viper) error {
+	c.Enabled = true
+
+	c.URL = v.GetString("auth.webhook.url")
+	if len(c.URL) == 0 {
+		return config.ErrKeyNotSet{Key: "auth.webhook.url"}
+	}
+
+	timeout := v.GetInt64("auth.webhook.timeout_ms")
+	if timeout <= 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.webhook.timeout_ms",
+			InvalidValue: fmt.Sprintf("%d", timeout),
+			Values:       []string{},
+		}
+	}
+	c.Timeout = time.Duration(timeout) * time.Millisecond
+
+	c.Headers = v.GetStringSlice("auth.webhook.headers")
+
+	c.FailureMode = webhook.FailureMode(v.GetString("auth.webhook.failure_mode"))
+	if c.FailureMode != webhook.FailClosed && c.FailureMode != webhook.FailOpen {
+		return config.ErrInvalidValue{
+			Key:          "auth.webhook.failure_mode",
+			InvalidValue: string(c.FailureMode),
+			Values:       []string{string(webhook.FailClosed), string(webhook.FailOpen)},
+		}
+	}
+
+	c.CacheSize = v.GetInt("auth.webhook.cache_size")
+	if c.CacheSize < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.webhook.cache_size",
+			InvalidValue: fmt.Sprintf("%d", c.CacheSize),
+			Values:       []string{},
+		}
+	}
+
+	cacheTTL := v.GetInt64("auth.webhook.cache_ttl_ms")
+	if cacheTTL < 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.webhook.cache_ttl_ms",
+			InvalidValue: fmt.Sprintf("%d", cacheTTL),
+			Values:       []string{},
+		}
+	}
+	c.CacheTTL = time.Duration(cacheTTL) * time.Millisecond
+
+	return nil
+}
+
+func (c *WebhookConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("auth.webhook.url", "", "url of the authorization webhook")
+	cmd.PersistentFlags().Int64("auth.webhook.timeout_ms", 1000, "timeout for the requests to the webhook")
+	cmd.PersistentFlags().StringSlice("auth.webhook.headers", []string{"Authorization"},
+		"headers of the requests forwarded to the webhook for authentication")
+	cmd.PersistentFlags().String("auth.webhook.failure_mode", string(webhook.FailClosed),
+		"decision taken when the webhook fails. Options are "+string(webhook.FailClosed)+
+			", "+string(webhook.FailOpen)+". Requests can only be authenticated by the webhook, "+
+			"so the failure mode only applies to their verification")
+	cmd.PersistentFlags().Int("auth.webhook.cache_size", 1024,
+		"maximum number of webhook decisions cached. If 0 decisions are not cached")
+	cmd.PersistentFlags().Int64("auth.webhook.cache_ttl_ms", 60000, "time webhook decisions are cached for")
+	return nil
+}
+
+// SignatureConfig is the configuration for the signature verification
+// of requests, which uses the address that signed a request as its AAD
+type SignatureConfig struct {
+	Enabled      bool
+	MaxSkew      time.Duration
+	MaxBodyBytes int64
+}
+
+func (c *SignatureConfig) Log(fields log.Fields) {
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("auth.signature.enabled", c.Enabled)
+	fields.Add("auth.signature.max_skew_ms", c.MaxSkew.Milliseconds())
+	fields.Add("auth.signature.max_body_bytes", c.MaxBodyBytes)
+}
+
+func (c *SignatureConfig) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("auth.signature.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	maxSkew := v.GetInt64("auth.signature.max_skew_ms")
+	if maxSkew <= 0 || time.Duration(maxSkew)*time.Millisecond > signature.MaxSkew {
+		return config.ErrInvalidValue{
+			Key:          "auth.signature.max_skew_ms",
+			InvalidValue: fmt.Sprintf("%d", maxSkew),
+			Values:       []string{fmt.Sprintf("(0, %d]", signature.MaxSkew.Milliseconds())},
+		}
+	}
+	c.MaxSkew = time.Duration(maxSkew) * time.Millisecond
+
+	c.MaxBodyBytes = v.GetInt64("auth.signature.max_body_bytes")
+	if c.MaxBodyBytes <= 0 {
+		return config.ErrInvalidValue{
+			Key:          "auth.signature.max_body_bytes",
+			InvalidValue: fmt.Sprintf("%d", c.MaxBodyBytes),
+			Values:       []string{},
+		}
+	}
+
+	return nil
+}
+
+func (c *SignatureConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("auth.signature.enabled", false,
+		"if set requests must be signed with a secp256k1 key and the address that "+
+			"signed the request is used as the AAD")
+	cmd.PersistentFlags().Int64("auth.signature.max_skew_ms", signature.MaxSkew.Milliseconds(),
+		"maximum difference between the timestamp of a signed request and the gateway time")
+	cmd.PersistentFlags().Int64("auth.signature.max_body_bytes", 1<<16,
+		"maximum size of a request body that is read to verify its signature")
 	return nil
 }
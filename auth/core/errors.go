@@ -1,12 +1,35 @@
 package core
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
+// ProviderError is the error returned by a provider that
+// is part of a MultiAuth
+type ProviderError struct {
+	// Provider is the name of the provider that failed
+	Provider string
+
+	// Err is the error returned by the provider
+	Err error
+}
+
+func (e ProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Err.Error())
+}
+
+// MultiError holds the errors of all the providers that failed
+// to authenticate a request
 type MultiError struct {
 	Errors []error
 }
 
 func (e MultiError) Error() string {
+	if len(e.Errors) == 0 {
+		return "no auth provider succeeded"
+	}
+
 	var s []string
 	for _, err := range e.Errors {
 		s = append(s, err.Error())
@@ -14,3 +37,28 @@ func (e MultiError) Error() string {
 
 	return strings.Join(s, "; ")
 }
+
+// Providers returns the names of the providers that failed, in
+// the order in which they were tried
+func (e MultiError) Providers() []string {
+	var providers []string
+	for _, err := range e.Errors {
+		if perr, ok := err.(ProviderError); ok {
+			providers = append(providers, perr.Provider)
+		}
+	}
+
+	return providers
+}
+
+// ProviderError returns the error returned by the provider with the
+// given name, or nil if that provider did not fail
+func (e MultiError) ProviderError(provider string) error {
+	for _, err := range e.Errors {
+		if perr, ok := err.(ProviderError); ok && perr.Provider == provider {
+			return perr.Err
+		}
+	}
+
+	return nil
+}
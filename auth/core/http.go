@@ -15,18 +15,61 @@ import (
 type AAD struct{}
 type Session struct{}
 
+// Tenant is the context key that an Auth implementation may set
+// on Authenticate to identify the tenant of the request issuer. If
+// it is set and the HttpMiddlewareAuth is configured to do so, the
+// session is prefixed with the tenant so that the keys of
+// different tenants can never collide
+type Tenant struct{}
+
+// IdentityClaims is the context key that an Auth implementation may set
+// on Authenticate with a map[string]interface{} of the claims about
+// the identity of the request issuer, so that they can be used by
+// authorization policies
+type IdentityClaims struct{}
+
+// SessionKey is the context key that an Auth implementation may
+// set on Authenticate to provide the session key of the request. If
+// it is set, the session key header of the request is not used
+type SessionKey struct{}
+
+// SessionIdentity is the context key that an Auth implementation may
+// set on Authenticate to identify the session of the request issuer
+// with a value other than the AAD. If it is not set the session is
+// identified by the AAD
+type SessionIdentity struct{}
+
 const (
 	sessionKeyFormat               = "%s:%s"
+	tenantSessionKeyFormat         = "%s:%s:%s"
 	RequestHeaderSessionKey string = "X-OASIS-SESSION-KEY"
 )
 
 type HttpMiddlewareAuth struct {
-	auth   Auth
-	logger log.Logger
-	next   rpc.HttpMiddleware
+	auth         Auth
+	logger       log.Logger
+	next         rpc.HttpMiddleware
+	tenantPrefix bool
+}
+
+// HttpMiddlewareAuthProps are the properties used to define
+// the behaviour of an HttpMiddlewareAuth
+type HttpMiddlewareAuthProps struct {
+	// TenantPrefix if set the session of the requests that
+	// belong to a tenant is prefixed with the tenant
+	TenantPrefix bool
 }
 
 func NewHttpMiddlewareAuth(auth Auth, logger log.Logger, next rpc.HttpMiddleware) *HttpMiddlewareAuth {
+	return NewHttpMiddlewareAuthWithProps(auth, logger, next, HttpMiddlewareAuthProps{})
+}
+
+func NewHttpMiddlewareAuthWithProps(
+	auth Auth,
+	logger log.Logger,
+	next rpc.HttpMiddleware,
+	props HttpMiddlewareAuthProps,
+) *HttpMiddlewareAuth {
 	if auth == nil {
 		panic("auth must be set")
 	}
@@ -40,9 +83,10 @@ func NewHttpMiddlewareAuth(auth Auth, logger log.Logger, next rpc.HttpMiddleware
 	}
 
 	return &HttpMiddlewareAuth{
-		auth:   auth,
-		logger: logger.ForClass("auth", "HttpMiddlewareAuth"),
-		next:   next,
+		auth:         auth,
+		logger:       logger.ForClass("auth", "HttpMiddlewareAuth"),
+		next:         next,
+		tenantPrefix: props.TenantPrefix,
 	}
 }
 
@@ -55,6 +99,42 @@ func MustGetAAD(ctx context.Context) string {
 	return value.(string)
 }
 
+// MakeSession returns the session that identifies the requests
+// of an issuer with the provided AAD and session key. If tenant
+// is not empty the session is prefixed with the tenant
+func MakeSession(tenant, aad, sessionKey string) string {
+	hash := sha256.Sum256([]byte(aad))
+	aadHash := hex.EncodeToString(hash[:])
+
+	if len(tenant) > 0 {
+		return fmt.Sprintf(tenantSessionKeyFormat, tenant, aadHash, sessionKey)
+	}
+
+	return fmt.Sprintf(sessionKeyFormat, aadHash, sessionKey)
+}
+
+// GetTenant returns the tenant set by the Authenticate method
+// or an empty string if the request does not belong to a tenant
+func GetTenant(ctx context.Context) string {
+	value, ok := ctx.Value(Tenant{}).(string)
+	if !ok {
+		return ""
+	}
+
+	return value
+}
+
+// GetIdentityClaims returns the identity claims set by the Authenticate
+// method or nil if none were set
+func GetIdentityClaims(ctx context.Context) map[string]interface{} {
+	value, ok := ctx.Value(IdentityClaims{}).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	return value
+}
+
 func (m *HttpMiddlewareAuth) ServeHTTP(req *http.Request) (interface{}, error) {
 	req, err := m.auth.Authenticate(req)
 	if err != nil {
@@ -65,7 +145,10 @@ func (m *HttpMiddlewareAuth) ServeHTTP(req *http.Request) (interface{}, error) {
 		}
 	}
 
-	sessionKey := req.Header.Get(RequestHeaderSessionKey)
+	sessionKey, _ := req.Context().Value(SessionKey{}).(string)
+	if len(sessionKey) == 0 {
+		sessionKey = req.Header.Get(RequestHeaderSessionKey)
+	}
 	if len(sessionKey) == 0 {
 		newErr := errors.New(errors.ErrAuthenticateRequest, fmt.Errorf("no %s header provided", RequestHeaderSessionKey))
 		return nil, &rpc.HttpError{
@@ -74,14 +157,17 @@ func (m *HttpMiddlewareAuth) ServeHTTP(req *http.Request) (interface{}, error) {
 		}
 	}
 
-	expectedAAD := MustGetAAD(req.Context())
-	hasher := sha256.New()
-	if _, err = hasher.Write([]byte(expectedAAD)); err != nil {
-		return nil, rpc.HttpForbidden(context.TODO(), errors.New(errors.ErrInvalidAAD, err))
+	var tenant string
+	if m.tenantPrefix {
+		tenant = GetTenant(req.Context())
 	}
 
-	aadHash := hex.EncodeToString(hasher.Sum(nil))
+	identity, _ := req.Context().Value(SessionIdentity{}).(string)
+	if len(identity) == 0 {
+		identity = MustGetAAD(req.Context())
+	}
 
-	req = req.WithContext(context.WithValue(req.Context(), Session{}, fmt.Sprintf(sessionKeyFormat, aadHash, sessionKey)))
+	session := MakeSession(tenant, identity, sessionKey)
+	req = req.WithContext(context.WithValue(req.Context(), Session{}, session))
 	return m.next.ServeHTTP(req)
 }
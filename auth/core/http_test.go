@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"testing"
@@ -31,3 +32,111 @@ func TestServeHTTP(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 0, res)
 }
+
+type tenantAuth struct {
+	NilAuth
+}
+
+func (a *tenantAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.NilAuth.Authenticate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(req.Context(), Tenant{}, "tenant")
+	return req.WithContext(ctx), nil
+}
+
+func TestServeHTTPSession(t *testing.T) {
+	handler := NewHttpMiddlewareAuth(&NilAuth{}, Logger, rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		return req.Context().Value(Session{}), nil
+	}))
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, err)
+	req.Header.Add(RequestHeaderSessionKey, "session")
+
+	res, err := handler.ServeHTTP(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "5da3a4c7f117944275b4c8629c4916403625d5a4a6573a01ecb03f0e9d2edbe6:session", res)
+}
+
+func TestServeHTTPTenantSession(t *testing.T) {
+	next := rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		return req.Context().Value(Session{}), nil
+	})
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, err)
+	req.Header.Add(RequestHeaderSessionKey, "session")
+
+	// the tenant is ignored unless the middleware is configured to use it
+	res, err := NewHttpMiddlewareAuth(&tenantAuth{}, Logger, next).ServeHTTP(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "5da3a4c7f117944275b4c8629c4916403625d5a4a6573a01ecb03f0e9d2edbe6:session", res)
+
+	res, err = NewHttpMiddlewareAuthWithProps(&tenantAuth{}, Logger, next, HttpMiddlewareAuthProps{
+		TenantPrefix: true,
+	}).ServeHTTP(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant:5da3a4c7f117944275b4c8629c4916403625d5a4a6573a01ecb03f0e9d2edbe6:session", res)
+}
+
+type sessionKeyAuth struct {
+	NilAuth
+}
+
+func (a *sessionKeyAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.NilAuth.Authenticate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(req.Context(), SessionKey{}, "session")
+	return req.WithContext(ctx), nil
+}
+
+func TestServeHTTPAuthSessionKey(t *testing.T) {
+	handler := NewHttpMiddlewareAuth(&sessionKeyAuth{}, Logger, rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		return req.Context().Value(Session{}), nil
+	}))
+
+	// the session key set by the provider takes precedence over the header
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, err)
+	req.Header.Add(RequestHeaderSessionKey, "other")
+
+	res, err := handler.ServeHTTP(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "5da3a4c7f117944275b4c8629c4916403625d5a4a6573a01ecb03f0e9d2edbe6:session", res)
+}
+
+type sessionIdentityAuth struct {
+	NilAuth
+}
+
+func (a *sessionIdentityAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.NilAuth.Authenticate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(req.Context(), SessionIdentity{}, "identity")
+	return req.WithContext(ctx), nil
+}
+
+func TestServeHTTPSessionIdentity(t *testing.T) {
+	handler := NewHttpMiddlewareAuth(&sessionIdentityAuth{}, Logger, rpc.HttpMiddlewareFunc(func(req *http.Request) (interface{}, error) {
+		assert.Equal(t, "nil", req.Context().Value(AAD{}))
+		return req.Context().Value(Session{}), nil
+	}))
+
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, err)
+	req.Header.Add(RequestHeaderSessionKey, "session")
+
+	// the session is identified by the session identity instead of the AAD
+	res, err := handler.ServeHTTP(req)
+	assert.Nil(t, err)
+	assert.Equal(t, MakeSession("", "identity", "session"), res)
+}
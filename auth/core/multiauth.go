@@ -9,12 +9,82 @@ import (
 	"github.com/oasislabs/oasis-gateway/stats"
 )
 
+// MultiAuthMode defines how the providers of a MultiAuth are
+// combined to authenticate and verify a request
+type MultiAuthMode string
+
+const (
+	// MultiAuthAnyOf tries the providers in order until one of them
+	// authenticates the request. That provider is the only one used
+	// to verify the request
+	MultiAuthAnyOf MultiAuthMode = "any_of"
+
+	// MultiAuthAllOf requires all the providers to authenticate and
+	// verify the request
+	MultiAuthAllOf MultiAuthMode = "all_of"
+
+	// MultiAuthChain passes the request through the providers in order,
+	// each provider taking part only in the steps allowed by its Role
+	MultiAuthChain MultiAuthMode = "chain"
+)
+
+// Role defines the steps a provider takes part in when it is
+// part of a MultiAuth in chain mode
+type Role string
+
+const (
+	// RoleAuthenticate providers authenticate the request but
+	// do not verify it
+	RoleAuthenticate Role = "authenticate"
+
+	// RoleVerify providers verify requests authenticated by
+	// other providers
+	RoleVerify Role = "verify"
+
+	// RoleBoth providers authenticate and verify the request
+	RoleBoth Role = "both"
+)
+
+func (r Role) authenticates() bool {
+	return r == RoleAuthenticate || r == RoleBoth
+}
+
+func (r Role) verifies() bool {
+	return r == RoleVerify || r == RoleBoth
+}
+
+// MultiAuthProps are the properties used to define the
+// behaviour of a MultiAuth
+type MultiAuthProps struct {
+	// Mode is how the providers are combined. If not
+	// set it defaults to MultiAuthAnyOf
+	Mode MultiAuthMode
+}
+
+type multiAuthEntry struct {
+	auth Auth
+	role Role
+}
+
 type MultiAuth struct {
-	auths []Auth
+	mode  MultiAuthMode
+	auths []multiAuthEntry
 }
 
+// NewMultiAuth creates a MultiAuth without providers
+func NewMultiAuth(props MultiAuthProps) *MultiAuth {
+	return &MultiAuth{mode: props.Mode}
+}
+
+// Add a provider that both authenticates and verifies requests
 func (m *MultiAuth) Add(a Auth) {
-	m.auths = append(m.auths, a)
+	m.AddWithRole(a, RoleBoth)
+}
+
+// AddWithRole adds a provider with the provided role. The role is
+// only taken into account in chain mode
+func (m *MultiAuth) AddWithRole(a Auth, role Role) {
+	m.auths = append(m.auths, multiAuthEntry{auth: a, role: role})
 }
 
 func (*MultiAuth) Name() string {
@@ -22,8 +92,8 @@ func (*MultiAuth) Name() string {
 }
 func (m *MultiAuth) Stats() stats.Metrics {
 	metrics := make(stats.Metrics)
-	for _, auth := range m.auths {
-		for k, val := range auth.Stats() {
+	for _, entry := range m.auths {
+		for k, val := range entry.auth.Stats() {
 			metrics[k] = val
 		}
 	}
@@ -31,16 +101,25 @@ func (m *MultiAuth) Stats() stats.Metrics {
 }
 
 func (m *MultiAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	switch m.mode {
+	case MultiAuthAllOf, MultiAuthChain:
+		return m.authenticateChain(req)
+	default:
+		return m.authenticateAnyOf(req)
+	}
+}
+
+func (m *MultiAuth) authenticateAnyOf(req *http.Request) (*http.Request, error) {
 	var errs []error
 
-	for _, auth := range m.auths {
-		req, err := auth.Authenticate(req)
+	for _, entry := range m.auths {
+		req, err := entry.auth.Authenticate(req)
 		if err != nil {
-			errs = append(errs, err)
+			errs = append(errs, ProviderError{Provider: entry.auth.Name(), Err: err})
 			continue
 		}
 
-		ctx := context.WithValue(req.Context(), m, auth)
+		ctx := context.WithValue(req.Context(), m, entry.auth)
 		req = req.WithContext(ctx)
 		return req, nil
 	}
@@ -48,17 +127,126 @@ func (m *MultiAuth) Authenticate(req *http.Request) (*http.Request, error) {
 	return req, MultiError{Errors: errs}
 }
 
+// providerContext is the context used to verify a request with one
+// of the providers of a MultiAuth. The values set by the provider when
+// it authenticated the request take precedence over the values of the
+// verification context, so that the AAD set by one provider is never
+// seen by another one
+type providerContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c providerContext) Value(key interface{}) interface{} {
+	if value := c.values.Value(key); value != nil {
+		return value
+	}
+
+	return c.Context.Value(key)
+}
+
+// authenticateChain passes the request through all the providers
+// that authenticate so that each one of them can add its values to
+// the request context. If a later provider sets the AAD it replaces
+// the one set by the previous providers in the returned request, but
+// the context of each provider is kept so that the provider verifies
+// the request with the values it set itself
+func (m *MultiAuth) authenticateChain(req *http.Request) (*http.Request, error) {
+	var errs []error
+	contexts := make([]context.Context, len(m.auths))
+	authenticated := false
+
+	for i, entry := range m.auths {
+		if !m.roleOf(entry).authenticates() {
+			continue
+		}
+
+		next, err := entry.auth.Authenticate(req)
+		if err != nil {
+			errs = append(errs, ProviderError{Provider: entry.auth.Name(), Err: err})
+			continue
+		}
+
+		req = next
+		contexts[i] = next.Context()
+		authenticated = true
+	}
+
+	if len(errs) > 0 {
+		return req, MultiError{Errors: errs}
+	}
+
+	if !authenticated {
+		return req, MultiError{Errors: []error{stderr.New("no auth provider can authenticate the request")}}
+	}
+
+	ctx := context.WithValue(req.Context(), m, contexts)
+	return req.WithContext(ctx), nil
+}
+
 func (m *MultiAuth) Verify(ctx context.Context, data AuthRequest) error {
 	auth := ctx.Value(m)
 	if auth == nil {
 		return stderr.New("request without auth cannot be verified")
 	}
 
-	return auth.(Auth).Verify(ctx, data)
+	switch m.mode {
+	case MultiAuthAllOf, MultiAuthChain:
+		return m.verifyChain(ctx, auth.([]context.Context), data)
+	default:
+		return auth.(Auth).Verify(ctx, data)
+	}
+}
+
+// verifyChain verifies the request with all the providers that verify.
+// A provider that authenticated the request verifies it with the values
+// it set on authentication. All the providers are tried so that the
+// error returned holds all the failures
+func (m *MultiAuth) verifyChain(ctx context.Context, contexts []context.Context, data AuthRequest) error {
+	var errs []error
+	verified := false
+
+	for i, entry := range m.auths {
+		if !m.roleOf(entry).verifies() {
+			continue
+		}
+
+		verifyCtx := ctx
+		if contexts[i] != nil {
+			verifyCtx = providerContext{Context: ctx, values: contexts[i]}
+		}
+
+		if err := entry.auth.Verify(verifyCtx, data); err != nil {
+			errs = append(errs, ProviderError{Provider: entry.auth.Name(), Err: err})
+			continue
+		}
+
+		verified = true
+	}
+
+	if len(errs) > 0 {
+		return MultiError{Errors: errs}
+	}
+
+	if !verified {
+		return MultiError{Errors: []error{stderr.New("no auth provider can verify the request")}}
+	}
+
+	return nil
+}
+
+// roleOf returns the role of the provider for the mode of the
+// MultiAuth. Roles only restrict providers in chain mode
+func (m *MultiAuth) roleOf(entry multiAuthEntry) Role {
+	if m.mode != MultiAuthChain {
+		return RoleBoth
+	}
+
+	return entry.role
 }
 
 func (m *MultiAuth) SetLogger(l log.Logger) {
-	for _, auth := range m.auths {
-		auth.SetLogger(l)
+	for _, entry := range m.auths {
+		entry.auth.SetLogger(l)
 	}
 }
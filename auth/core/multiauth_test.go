@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"testing"
 
@@ -21,3 +23,192 @@ func TestAuthenticateOK(t *testing.T) {
 	v := res.Context().Value(multi)
 	assert.Equal(t, auth, v)
 }
+
+type aadAuth struct {
+	NilAuth
+	name     string
+	aad      string
+	err      error
+	verified bool
+	seenAAD  interface{}
+}
+
+func (a *aadAuth) Name() string {
+	return a.name
+}
+
+func (a *aadAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	ctx := context.WithValue(req.Context(), AAD{}, a.aad)
+	return req.WithContext(ctx), nil
+}
+
+func (a *aadAuth) Verify(ctx context.Context, req AuthRequest) error {
+	a.verified = true
+	a.seenAAD = ctx.Value(AAD{})
+	if a.err != nil {
+		return a.err
+	}
+	return nil
+}
+
+func TestAuthenticateAnyOfMultiError(t *testing.T) {
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthAnyOf})
+	multi.Add(&aadAuth{name: "first", err: errors.New("bad token")})
+	multi.Add(&aadAuth{name: "second", err: errors.New("no key")})
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	_, err = multi.Authenticate(req)
+	assert.Equal(t, "first: bad token; second: no key", err.Error())
+
+	merr := err.(MultiError)
+	assert.Equal(t, []string{"first", "second"}, merr.Providers())
+	assert.Equal(t, errors.New("no key"), merr.ProviderError("second"))
+	assert.Nil(t, merr.ProviderError("third"))
+}
+
+func TestAuthenticateAllOf(t *testing.T) {
+	first := &aadAuth{name: "first", aad: "aad"}
+	second := &aadAuth{name: "second", aad: "aad"}
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthAllOf})
+	multi.Add(first)
+	multi.AddWithRole(second, RoleAuthenticate)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	res, err := multi.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "aad", MustGetAAD(res.Context()))
+
+	// roles are ignored unless in chain mode
+	err = multi.Verify(res.Context(), AuthRequest{})
+	assert.Nil(t, err)
+	assert.True(t, first.verified)
+	assert.True(t, second.verified)
+}
+
+func TestAuthenticateAllOfFailure(t *testing.T) {
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthAllOf})
+	multi.Add(&aadAuth{name: "first", err: errors.New("expired")})
+	multi.Add(&aadAuth{name: "second", aad: "aad"})
+	multi.Add(&aadAuth{name: "third", err: errors.New("denied")})
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	_, err = multi.Authenticate(req)
+	assert.Equal(t, MultiError{Errors: []error{
+		ProviderError{Provider: "first", Err: errors.New("expired")},
+		ProviderError{Provider: "third", Err: errors.New("denied")},
+	}}, err)
+}
+
+func TestAuthenticateAllOfSeparateAAD(t *testing.T) {
+	first := &aadAuth{name: "first", aad: "first-aad"}
+	second := &aadAuth{name: "second", aad: "second-aad"}
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthAllOf})
+	multi.Add(first)
+	multi.Add(second)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	res, err := multi.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "second-aad", MustGetAAD(res.Context()))
+
+	err = multi.Verify(res.Context(), AuthRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "first-aad", first.seenAAD)
+	assert.Equal(t, "second-aad", second.seenAAD)
+}
+
+func TestVerifyAllOfMultiError(t *testing.T) {
+	first := &aadAuth{name: "first", aad: "aad"}
+	second := &aadAuth{name: "second", aad: "aad"}
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthAllOf})
+	multi.Add(first)
+	multi.Add(second)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	res, err := multi.Authenticate(req)
+	assert.Nil(t, err)
+
+	first.err = errors.New("expired")
+	second.err = errors.New("denied")
+	err = multi.Verify(res.Context(), AuthRequest{})
+	assert.Equal(t, MultiError{Errors: []error{
+		ProviderError{Provider: "first", Err: errors.New("expired")},
+		ProviderError{Provider: "second", Err: errors.New("denied")},
+	}}, err)
+}
+
+func TestAuthenticateChainRoles(t *testing.T) {
+	identity := &aadAuth{name: "identity", aad: "user"}
+	allowlist := &aadAuth{name: "allowlist", err: errors.New("not allowed")}
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthChain})
+	multi.AddWithRole(identity, RoleAuthenticate)
+	multi.AddWithRole(allowlist, RoleVerify)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	// the allowlist does not authenticate the request
+	res, err := multi.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "user", MustGetAAD(res.Context()))
+
+	// and the identity provider does not verify it
+	err = multi.Verify(res.Context(), AuthRequest{})
+	assert.Equal(t, MultiError{Errors: []error{
+		ProviderError{Provider: "allowlist", Err: errors.New("not allowed")},
+	}}, err)
+	assert.False(t, identity.verified)
+	assert.True(t, allowlist.verified)
+
+	// the verify only provider sees the AAD of the request
+	assert.Equal(t, "user", allowlist.seenAAD)
+}
+
+func TestVerifyChainNoVerifier(t *testing.T) {
+	identity := &aadAuth{name: "identity", aad: "user"}
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthChain})
+	multi.AddWithRole(identity, RoleAuthenticate)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	res, err := multi.Authenticate(req)
+	assert.Nil(t, err)
+
+	err = multi.Verify(res.Context(), AuthRequest{})
+	assert.Error(t, err)
+	assert.False(t, identity.verified)
+}
+
+func TestAuthenticateChainNoAuthenticator(t *testing.T) {
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthChain})
+	multi.AddWithRole(&aadAuth{name: "allowlist"}, RoleVerify)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, err)
+
+	_, err = multi.Authenticate(req)
+	assert.Error(t, err)
+}
+
+func TestVerifyNotAuthenticated(t *testing.T) {
+	multi := NewMultiAuth(MultiAuthProps{Mode: MultiAuthChain})
+	multi.Add(&aadAuth{name: "identity", aad: "user"})
+
+	err := multi.Verify(context.Background(), AuthRequest{})
+	assert.Error(t, err)
+}
@@ -1,41 +1,177 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/oasislabs/oasis-gateway/auth/apikey"
 	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/auth/hmac"
 	"github.com/oasislabs/oasis-gateway/auth/insecure"
+	"github.com/oasislabs/oasis-gateway/auth/jwt"
+	"github.com/oasislabs/oasis-gateway/auth/mtls"
 	"github.com/oasislabs/oasis-gateway/auth/oauth"
+	"github.com/oasislabs/oasis-gateway/auth/policy"
+	"github.com/oasislabs/oasis-gateway/auth/signature"
+	"github.com/oasislabs/oasis-gateway/auth/webhook"
+	"github.com/oasislabs/oasis-gateway/log"
+	mqueue "github.com/oasislabs/oasis-gateway/mqueue/core"
 )
 
+// Services are the services that the providers may depend on
+type Services struct {
+	Logger log.Logger
+
+	// MQueue is used by providers that need state shared
+	// amongst gateway instances
+	MQueue mqueue.MQueue
+}
+
 type Factory interface {
-	New(*Config) (core.Auth, error)
+	New(ctx context.Context, services Services, config *Config) (core.Auth, error)
 }
 
-type FactoryFunc func(*Config) (core.Auth, error)
+type FactoryFunc func(ctx context.Context, services Services, config *Config) (core.Auth, error)
 
-func (f FactoryFunc) New(config *Config) (core.Auth, error) {
-	return f(config)
+func (f FactoryFunc) New(ctx context.Context, services Services, config *Config) (core.Auth, error) {
+	return f(ctx, services, config)
 }
 
-var NewAuth = FactoryFunc(func(config *Config) (core.Auth, error) {
-	if len(config.Providers) == 0 {
-		return &core.NilAuth{}, nil
-	} else if len(config.Providers) == 1 {
-		return config.Providers[0], nil
+var NewAuth = FactoryFunc(func(ctx context.Context, services Services, config *Config) (core.Auth, error) {
+	var providers []core.Auth
+	var names []string
+	for _, provider := range config.Provider {
+		auth, err := newAuthSingle(ctx, services, config, provider)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, auth)
+		names = append(names, string(provider))
+	}
+	for _, provider := range config.Providers {
+		providers = append(providers, provider)
+		names = append(names, provider.Name())
+	}
+
+	var auth core.Auth
+	if len(providers) == 0 {
+		auth = &core.NilAuth{}
+	} else if len(providers) == 1 {
+		auth = providers[0]
+	} else {
+		multiAuth := core.NewMultiAuth(core.MultiAuthProps{Mode: config.Mode})
+		for i, p := range providers {
+			role, ok := config.Roles[names[i]]
+			if !ok {
+				role = core.RoleBoth
+			}
+			multiAuth.AddWithRole(p, role)
+		}
+		auth = multiAuth
+	}
+
+	if config.SignatureConfig.Enabled {
+		if services.MQueue == nil {
+			return nil, errors.New("signature verification requires an mqueue to keep track of nonces")
+		}
+
+		auth = signature.NewSignatureAuth(auth, signature.Props{
+			Nonces:       signature.NewMQueueNonceCache(services.MQueue),
+			MaxSkew:      config.SignatureConfig.MaxSkew,
+			MaxBodyBytes: config.SignatureConfig.MaxBodyBytes,
+		})
 	}
-	multiAuth := new(core.MultiAuth)
-	for _, p := range config.Providers {
-		multiAuth.Add(p)
+
+	if config.PolicyConfig.Enabled {
+		return policy.NewPolicyAuth(auth, policy.Props{
+			Path:           config.PolicyConfig.File,
+			ReloadInterval: config.PolicyConfig.ReloadInterval,
+		})
 	}
-	return multiAuth, nil
+
+	return auth, nil
 })
 
-func newAuthSingle(provider AuthProvider) core.Auth {
+func newAuthSingle(ctx context.Context, services Services, config *Config, provider AuthProvider) (core.Auth, error) {
 	switch provider {
 	case AuthOauth:
-		return oauth.NewGoogleOauth(oauth.NewGoogleIDTokenVerifier())
+		return oauth.NewGoogleOauthWithProps(oauth.GoogleOauthProps{
+			Verifier:  oauth.NewGoogleIDTokenVerifier(),
+			CacheSize: config.OauthConfig.CacheSize,
+		}), nil
 	case AuthInsecure:
-		return insecure.InsecureAuth{}
+		return insecure.InsecureAuth{}, nil
+	case AuthJwt:
+		return newJwtAuth(&config.JwtConfig), nil
+	case AuthApiKey:
+		return newApiKeyAuth(&config.ApiKeyConfig)
+	case AuthHmac:
+		return newHmacAuth(services, &config.HmacConfig)
+	case AuthMtls:
+		return mtls.NewMtlsAuth(mtls.Props{
+			Identity:    config.MtlsConfig.Identity,
+			SessionKey:  config.MtlsConfig.SessionKey,
+			AllowDeploy: config.MtlsConfig.AllowDeploy,
+		}), nil
+	case AuthWebhook:
+		return webhook.NewWebhookAuth(webhook.Props{
+			URL:         config.WebhookConfig.URL,
+			Timeout:     config.WebhookConfig.Timeout,
+			Headers:     config.WebhookConfig.Headers,
+			FailureMode: config.WebhookConfig.FailureMode,
+			CacheSize:   config.WebhookConfig.CacheSize,
+			CacheTTL:    config.WebhookConfig.CacheTTL,
+		}), nil
 	default:
-		return nil
+		return nil, fmt.Errorf("unknown auth provider %s", provider)
 	}
 }
+
+func newJwtAuth(config *JwtConfig) core.Auth {
+	var source jwt.KeySource
+	if len(config.JwksURL) > 0 {
+		source = jwt.URLKeySource{URL: config.JwksURL}
+	} else {
+		source = jwt.FileKeySource{Paths: config.KeyFiles}
+	}
+
+	return jwt.NewJwtAuth(jwt.Props{
+		Issuer:   config.Issuer,
+		Audience: config.Audience,
+		KeySet: jwt.NewKeySet(jwt.KeySetProps{
+			Source:             source,
+			RefreshInterval:    config.RefreshInterval,
+			MinRefreshInterval: jwtMinRefreshInterval,
+		}),
+		SigningAlgs:     config.SigningAlgs,
+		AADClaim:        config.AADClaim,
+		SessionKeyClaim: config.SessionKeyClaim,
+		AllowDeploy:     config.AllowDeploy,
+	})
+}
+
+func newApiKeyAuth(config *ApiKeyConfig) (core.Auth, error) {
+	store, err := apikey.NewStore(apikey.StoreProps{
+		Path:           config.File,
+		ReloadInterval: config.ReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apikey.NewApiKeyAuth(store), nil
+}
+
+func newHmacAuth(services Services, config *HmacConfig) (core.Auth, error) {
+	if services.MQueue == nil {
+		return nil, errors.New("hmac auth provider requires an mqueue to keep track of nonces")
+	}
+
+	return hmac.NewHmacAuth(hmac.Props{
+		Keys:         config.Keys,
+		Nonces:       hmac.NewMQueueNonceCache(services.MQueue),
+		MaxSkew:      config.MaxSkew,
+		MaxBodyBytes: config.MaxBodyBytes,
+	}), nil
+}
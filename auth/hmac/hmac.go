@@ -0,0 +1,298 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	stderr "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+const (
+	// KeyIDHeader is the header with the id of the key used to
+	// sign the request
+	KeyIDHeader = "X-OASIS-HMAC-KEY-ID"
+
+	// TimestampHeader is the header with the unix time in seconds
+	// at which the request was signed
+	TimestampHeader = "X-OASIS-HMAC-TIMESTAMP"
+
+	// NonceHeader is the header with a unique value for the
+	// request so that the request cannot be replayed
+	NonceHeader = "X-OASIS-HMAC-NONCE"
+
+	// SignatureHeader is the header with the hex encoded
+	// HMAC-SHA256 signature of the request
+	SignatureHeader = "X-OASIS-HMAC-SIGNATURE"
+
+	// MaxSkew is the maximum allowed difference between the
+	// timestamp of a request and the time of the gateway. Nonces are
+	// kept by the mqueue for as long as its queues live, and
+	// a request must not be accepted after its nonce has expired
+	MaxSkew = 5 * time.Minute
+
+	// maxNonceLength is the maximum length of a nonce
+	maxNonceLength = 128
+)
+
+var (
+	// ErrStaleTimestamp is returned when the timestamp of the
+	// request is too far from the gateway time
+	ErrStaleTimestamp = stderr.New("request timestamp is outside of the accepted window")
+
+	// ErrReplayedNonce is returned when the nonce of the request
+	// has already been used
+	ErrReplayedNonce = stderr.New("request nonce has already been used")
+
+	// ErrInvalidSignature is returned when the signature does not
+	// match the request
+	ErrInvalidSignature = stderr.New("request signature is not valid")
+
+	// ErrBodyTooLarge is returned when the body of the request
+	// exceeds the maximum size that can be signed
+	ErrBodyTooLarge = stderr.New("request body is too large")
+)
+
+// keyContext is the context key used to keep the key used to
+// sign an authenticated request
+type keyContext struct{}
+
+// Props are the properties used to create an HmacAuth
+type Props struct {
+	// Keys are the shared secrets indexed by key id
+	Keys map[string]Key
+
+	// Nonces keeps track of the nonces already used
+	Nonces NonceCache
+
+	// MaxSkew is the maximum allowed difference between the
+	// timestamp of a request and the time of the gateway. It cannot
+	// be larger than MaxSkew. Defaults to MaxSkew
+	MaxSkew time.Duration
+
+	// MaxBodyBytes is the maximum size of a body that is read
+	// to verify the signature
+	MaxBodyBytes int64
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// HmacAuth authenticates requests signed by the client with
+// a secret shared with the gateway
+type HmacAuth struct {
+	logger       log.Logger
+	keys         map[string]Key
+	nonces       NonceCache
+	maxSkew      time.Duration
+	maxBodyBytes int64
+	now          func() time.Time
+
+	successes stats.Counter
+	failures  stats.Counter
+	replays   stats.Counter
+}
+
+// NewHmacAuth creates a new HmacAuth
+func NewHmacAuth(props Props) *HmacAuth {
+	if props.Nonces == nil {
+		panic("Nonces must be set")
+	}
+	if props.MaxBodyBytes <= 0 {
+		panic("MaxBodyBytes must be positive")
+	}
+
+	maxSkew := props.MaxSkew
+	if maxSkew <= 0 || maxSkew > MaxSkew {
+		maxSkew = MaxSkew
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &HmacAuth{
+		keys:         props.Keys,
+		nonces:       props.Nonces,
+		maxSkew:      maxSkew,
+		maxBodyBytes: props.MaxBodyBytes,
+		now:          now,
+	}
+}
+
+func (a *HmacAuth) Name() string {
+	return "auth.hmac.HmacAuth"
+}
+
+func (a *HmacAuth) Stats() stats.Metrics {
+	return stats.Metrics{
+		"hmacSuccesses": a.successes.Value(),
+		"hmacFailures":  a.failures.Value(),
+		"hmacReplays":   a.replays.Value(),
+	}
+}
+
+// SigningString returns the string that is signed for a request with
+// the provided method, path, timestamp, nonce and body
+func SigningString(method, path, timestamp, nonce string, body []byte) string {
+	hash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		timestamp,
+		nonce,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+// Sign returns the hex encoded signature of the signing string
+func Sign(secret []byte, signingString string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(signingString))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Authenticate verifies the signature of the request and sets
+// the owner of the key as the AAD
+func (a *HmacAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.authenticate(req)
+	if err != nil {
+		a.failures.Incr()
+		if err == ErrReplayedNonce {
+			a.replays.Incr()
+		}
+		if a.logger != nil {
+			a.logger.Debug(req.Context(), "failed to authenticate request", log.MapFields{
+				"call_type": "AuthenticateFailure",
+				"key_id":    req.Header.Get(KeyIDHeader),
+				"err":       err.Error(),
+			})
+		}
+		return req, err
+	}
+
+	a.successes.Incr()
+	return req, nil
+}
+
+func (a *HmacAuth) authenticate(req *http.Request) (*http.Request, error) {
+	id := req.Header.Get(KeyIDHeader)
+	timestamp := req.Header.Get(TimestampHeader)
+	nonce := req.Header.Get(NonceHeader)
+	signature := req.Header.Get(SignatureHeader)
+
+	for header, value := range map[string]string{
+		KeyIDHeader:     id,
+		TimestampHeader: timestamp,
+		NonceHeader:     nonce,
+		SignatureHeader: signature,
+	} {
+		if len(value) == 0 {
+			return req, fmt.Errorf("%s header not set", header)
+		}
+	}
+
+	if len(nonce) > maxNonceLength {
+		return req, fmt.Errorf("%s header exceeds %d characters", NonceHeader, maxNonceLength)
+	}
+
+	key, ok := a.keys[id]
+	if !ok {
+		return req, fmt.Errorf("unknown key id %s", id)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return req, fmt.Errorf("%s header is not a unix timestamp", TimestampHeader)
+	}
+	skew := a.now().Sub(time.Unix(seconds, 0))
+	if skew > a.maxSkew || skew < -a.maxSkew {
+		return req, ErrStaleTimestamp
+	}
+
+	body, err := a.readBody(req)
+	if err != nil {
+		return req, err
+	}
+
+	expected := Sign(key.secret, SigningString(req.Method, req.URL.Path, timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return req, ErrInvalidSignature
+	}
+
+	// the nonce is only recorded once the signature is verified so
+	// that unauthenticated requests cannot consume nonces
+	used, err := a.nonces.Use(req.Context(), id, nonce)
+	if err != nil {
+		return req, err
+	}
+	if used {
+		return req, ErrReplayedNonce
+	}
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, key.Owner)
+	ctx = context.WithValue(ctx, keyContext{}, key)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, map[string]interface{}{"key_id": key.ID, "owner": key.Owner})
+	return req.WithContext(ctx), nil
+}
+
+// readBody reads the body of the request and replaces it so that
+// it can be read again by the handlers
+func (a *HmacAuth) readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, a.maxBodyBytes+1))
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > a.maxBodyBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Verify that the key allows the requested API and that the AAD
+// in the request data matches the owner of the key
+func (a *HmacAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	key, ok := ctx.Value(keyContext{}).(Key)
+	if !ok {
+		return stderr.New("request was not authenticated with an hmac signature")
+	}
+
+	if !key.Allows(req.API) {
+		return fmt.Errorf("hmac key is not allowed to use %s", req.API)
+	}
+
+	// deploy requests do not carry an AAD
+	if req.API == "Deploy" {
+		return nil
+	}
+
+	if string(req.AAD) != key.Owner {
+		return stderr.New("AAD does not match")
+	}
+
+	return nil
+}
+
+func (a *HmacAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/hmac", "HmacAuth")
+}
@@ -0,0 +1,181 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+var Logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Output: ioutil.Discard,
+})
+
+var secret = bytes.Repeat([]byte{1}, minSecretSize)
+
+func createAuth(t *testing.T, ctx context.Context, now time.Time) *HmacAuth {
+	keys, err := ReadKeys(strings.NewReader(`{"keys":[` +
+		`{"id":"client1","secret":"` + base64.StdEncoding.EncodeToString(secret) + `","apis":["Execute"]},` +
+		`{"id":"client2","secret":"` + base64.StdEncoding.EncodeToString(secret) + `","owner":"owner2"}` +
+		`]}`))
+	assert.Nil(t, err)
+
+	return NewHmacAuth(Props{
+		Keys:         keys,
+		Nonces:       NewMQueueNonceCache(mem.NewServer(ctx, mem.Services{Logger: Logger}, mem.Props{})),
+		MaxSkew:      time.Minute,
+		MaxBodyBytes: 1024,
+		Now:          func() time.Time { return now },
+	})
+}
+
+func newRequest(t *testing.T, id string, timestamp time.Time, nonce, body string) *http.Request {
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+	assert.Nil(t, err)
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	req.Header.Add(KeyIDHeader, id)
+	req.Header.Add(TimestampHeader, ts)
+	req.Header.Add(NonceHeader, nonce)
+	req.Header.Add(SignatureHeader, Sign(secret, SigningString("POST", "/v0/api/service/execute", ts, nonce, []byte(body))))
+	return req
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	req, err := auth.Authenticate(newRequest(t, "client1", now, "nonce1", `{"data":"0x00"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "client1", req.Context().Value(core.AAD{}))
+
+	// the body can still be read by the handler
+	body, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"data":"0x00"}`, string(body))
+
+	req, err = auth.Authenticate(newRequest(t, "client2", now, "nonce1", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner2", req.Context().Value(core.AAD{}))
+	assert.Equal(t, uint64(2), auth.Stats()["hmacSuccesses"])
+}
+
+func TestAuthenticateReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	_, err := auth.Authenticate(newRequest(t, "client1", now, "nonce1", "body"))
+	assert.Nil(t, err)
+
+	_, err = auth.Authenticate(newRequest(t, "client1", now, "nonce1", "body"))
+	assert.Equal(t, ErrReplayedNonce, err)
+	assert.Equal(t, uint64(1), auth.Stats()["hmacReplays"])
+}
+
+func TestAuthenticateStaleTimestamp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	_, err := auth.Authenticate(newRequest(t, "client1", now.Add(-2*time.Minute), "nonce1", "body"))
+	assert.Equal(t, ErrStaleTimestamp, err)
+
+	_, err = auth.Authenticate(newRequest(t, "client1", now.Add(2*time.Minute), "nonce2", "body"))
+	assert.Equal(t, ErrStaleTimestamp, err)
+}
+
+func TestAuthenticateInvalidSignature(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	// the body is modified after it has been signed
+	req := newRequest(t, "client1", now, "nonce1", "body")
+	req.Body = ioutil.NopCloser(strings.NewReader("other"))
+	_, err := auth.Authenticate(req)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	// a failed request does not consume the nonce
+	_, err = auth.Authenticate(newRequest(t, "client1", now, "nonce1", "body"))
+	assert.Nil(t, err)
+}
+
+func TestAuthenticateMissingHeader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	req := newRequest(t, "client1", now, "nonce1", "body")
+	req.Header.Del(NonceHeader)
+	_, err := auth.Authenticate(req)
+	assert.Equal(t, "X-OASIS-HMAC-NONCE header not set", err.Error())
+}
+
+func TestAuthenticateUnknownKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	_, err := auth.Authenticate(newRequest(t, "client3", now, "nonce1", "body"))
+	assert.Equal(t, "unknown key id client3", err.Error())
+}
+
+func TestAuthenticateBodyTooLarge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	_, err := auth.Authenticate(newRequest(t, "client1", now, "nonce1", strings.Repeat("a", 1025)))
+	assert.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestVerify(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	auth := createAuth(t, ctx, now)
+
+	req, err := auth.Authenticate(newRequest(t, "client1", now, "nonce1", "body"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte("client1")}))
+	assert.Equal(t, "AAD does not match",
+		auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte("client2")}).Error())
+	assert.Equal(t, "hmac key is not allowed to use Deploy",
+		auth.Verify(req.Context(), core.AuthRequest{API: "Deploy"}).Error())
+}
+
+func TestReadKeysInvalid(t *testing.T) {
+	_, err := ReadKeys(strings.NewReader(`{"keys":[{"id":"client1","secret":"c2hvcnQ="}]}`))
+	assert.Equal(t, "key client1 secret must be at least 32 bytes", err.Error())
+
+	_, err = ReadKeys(strings.NewReader(`{"keys":[{"secret":"c2hvcnQ="}]}`))
+	assert.Equal(t, "key 0 does not have an id", err.Error())
+}
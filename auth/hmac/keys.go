@@ -0,0 +1,89 @@
+package hmac
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// minSecretSize is the minimum size in bytes of a shared secret
+const minSecretSize = 32
+
+// Key is a shared secret along with the metadata of the client
+// that uses it
+type Key struct {
+	// ID identifies the key. Clients send it along with
+	// the signature
+	ID string `json:"id"`
+
+	// Secret is the base64 encoded shared secret
+	Secret string `json:"secret"`
+
+	// Owner identifies the owner of the key. It is used as the
+	// AAD of the requests signed with the key. Defaults to ID
+	Owner string `json:"owner"`
+
+	// APIs are the APIs that the owner of the key is allowed
+	// to use, such as Deploy or Execute
+	APIs []string `json:"apis"`
+
+	secret []byte
+}
+
+// Allows returns true if the key allows the use of the API
+func (k Key) Allows(api string) bool {
+	for _, allowed := range k.APIs {
+		if allowed == api {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyFile is the format of the file with the shared secrets
+type KeyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+// ReadKeys reads the shared secrets from a key file
+func ReadKeys(r io.Reader) (map[string]Key, error) {
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file KeyFile
+	if err := json.Unmarshal(p, &file); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key, len(file.Keys))
+	for i, key := range file.Keys {
+		if len(key.ID) == 0 {
+			return nil, fmt.Errorf("key %d does not have an id", i)
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, fmt.Errorf("key %s is duplicated", key.ID)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("key %s does not have a valid base64 secret", key.ID)
+		}
+		if len(secret) < minSecretSize {
+			return nil, fmt.Errorf("key %s secret must be at least %d bytes", key.ID, minSecretSize)
+		}
+
+		if len(key.Owner) == 0 {
+			key.Owner = key.ID
+		}
+
+		key.secret = secret
+		keys[key.ID] = key
+	}
+
+	return keys, nil
+}
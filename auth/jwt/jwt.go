@@ -0,0 +1,224 @@
+package jwt
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+const (
+	// AuthorizationHeader is the header in the *http.Request that
+	// contains the bearer token
+	AuthorizationHeader = "Authorization"
+
+	bearerPrefix = "Bearer "
+
+	// RS256 is the default algorithm accepted for token signatures
+	RS256 = oidc.RS256
+
+	// DefaultAADClaim is the claim used as the AAD if none is
+	// configured
+	DefaultAADClaim = "sub"
+)
+
+// Props are the properties used to create a JwtAuth
+type Props struct {
+	// Issuer is the expected issuer of the tokens
+	Issuer string
+
+	// Audience is the expected audience of the tokens. If it is
+	// empty the audience is not checked
+	Audience string
+
+	// KeySet is used to verify the signature of the tokens
+	KeySet oidc.KeySet
+
+	// SigningAlgs are the algorithms accepted for the token
+	// signatures. Defaults to RS256
+	SigningAlgs []string
+
+	// AADClaim is the claim used as the AAD of the request.
+	// Defaults to DefaultAADClaim
+	AADClaim string
+
+	// SessionKeyClaim if set is the claim used as the session key
+	// of the request instead of the session key header
+	SessionKeyClaim string
+
+	// AllowDeploy if set allows authenticated users to deploy
+	// services
+	AllowDeploy bool
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// JwtAuth authenticates requests with a JWT issued by an OpenID
+// Connect provider, or any issuer that publishes its keys as
+// a JWKS
+type JwtAuth struct {
+	logger          log.Logger
+	verifier        *oidc.IDTokenVerifier
+	keySet          oidc.KeySet
+	aadClaim        string
+	sessionKeyClaim string
+	allowDeploy     bool
+
+	successes stats.Counter
+	failures  stats.Counter
+}
+
+// NewJwtAuth creates a new JwtAuth
+func NewJwtAuth(props Props) *JwtAuth {
+	if len(props.Issuer) == 0 {
+		panic("Issuer must be set")
+	}
+	if props.KeySet == nil {
+		panic("KeySet must be set")
+	}
+
+	aadClaim := props.AADClaim
+	if len(aadClaim) == 0 {
+		aadClaim = DefaultAADClaim
+	}
+
+	return &JwtAuth{
+		verifier: oidc.NewVerifier(props.Issuer, props.KeySet, &oidc.Config{
+			ClientID:             props.Audience,
+			SkipClientIDCheck:    len(props.Audience) == 0,
+			SupportedSigningAlgs: props.SigningAlgs,
+			Now:                  props.Now,
+		}),
+		keySet:          props.KeySet,
+		aadClaim:        aadClaim,
+		sessionKeyClaim: props.SessionKeyClaim,
+		allowDeploy:     props.AllowDeploy,
+	}
+}
+
+func (a *JwtAuth) Name() string {
+	return "auth.jwt.JwtAuth"
+}
+
+func (a *JwtAuth) Stats() stats.Metrics {
+	metrics := stats.Metrics{
+		"jwtSuccesses": a.successes.Value(),
+		"jwtFailures":  a.failures.Value(),
+	}
+
+	if s, ok := a.keySet.(interface{ Stats() stats.Metrics }); ok {
+		for k, v := range s.Stats() {
+			metrics[k] = v
+		}
+	}
+
+	return metrics
+}
+
+// Authenticate verifies the bearer token of the request and sets
+// the AAD, and the session key if configured, from its claims
+func (a *JwtAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.authenticate(req)
+	if err != nil {
+		a.failures.Incr()
+		if a.logger != nil {
+			a.logger.Debug(req.Context(), "failed to authenticate request", log.MapFields{
+				"call_type": "AuthenticateFailure",
+				"err":       err.Error(),
+			})
+		}
+		return req, err
+	}
+
+	a.successes.Incr()
+	return req, nil
+}
+
+func (a *JwtAuth) authenticate(req *http.Request) (*http.Request, error) {
+	value := req.Header.Get(AuthorizationHeader)
+	if !strings.HasPrefix(value, bearerPrefix) {
+		return req, fmt.Errorf("%s header does not contain a bearer token", AuthorizationHeader)
+	}
+
+	rawToken := strings.TrimSpace(strings.TrimPrefix(value, bearerPrefix))
+	idToken, err := a.verifier.Verify(req.Context(), rawToken)
+	if err != nil {
+		return req, err
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return req, err
+	}
+
+	aad, err := claimString(claims, a.aadClaim)
+	if err != nil {
+		return req, err
+	}
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, aad)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, claims)
+
+	if len(a.sessionKeyClaim) > 0 {
+		sessionKey, err := claimString(claims, a.sessionKeyClaim)
+		if err != nil {
+			return req, err
+		}
+
+		ctx = context.WithValue(ctx, core.SessionKey{}, sessionKey)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// claimString returns the value of a claim as a string. Numeric
+// claims are formatted without loss of precision
+func claimString(claims map[string]interface{}, name string) (string, error) {
+	value, ok := claims[name]
+	if !ok {
+		return "", fmt.Errorf("token does not have claim %s", name)
+	}
+
+	switch value := value.(type) {
+	case string:
+		if len(value) == 0 {
+			return "", fmt.Errorf("token claim %s is empty", name)
+		}
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("token claim %s is not a string", name)
+	}
+}
+
+// Verify that the AAD in the request data matches the AAD
+// derived from the token claims
+func (a *JwtAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	if req.API == "Deploy" {
+		if !a.allowDeploy {
+			return stderr.New("JwtAuth is not configured to authorize a user to deploy a service")
+		}
+		return nil
+	}
+
+	expectedAAD := core.MustGetAAD(ctx)
+	if string(req.AAD) != expectedAAD {
+		return stderr.New("AAD does not match")
+	}
+
+	return nil
+}
+
+func (a *JwtAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/jwt", "JwtAuth")
+}
@@ -0,0 +1,200 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const issuer = "https://issuer.example.com/"
+
+type signer struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+func newSigner(t *testing.T, kid string) signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	return signer{key: key, kid: kid}
+}
+
+func (s signer) publicKey() jose.JSONWebKey {
+	return jose.JSONWebKey{Key: &s.key.PublicKey, KeyID: s.kid, Algorithm: RS256, Use: "sig"}
+}
+
+func (s signer) sign(t *testing.T, claims map[string]interface{}) string {
+	sig, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: s.key, KeyID: s.kid},
+	}, nil)
+	assert.Nil(t, err)
+
+	p, err := json.Marshal(claims)
+	assert.Nil(t, err)
+
+	jws, err := sig.Sign(p)
+	assert.Nil(t, err)
+
+	token, err := jws.CompactSerialize()
+	assert.Nil(t, err)
+	return token
+}
+
+func writeKeys(t *testing.T, path string, keys ...jose.JSONWebKey) {
+	p, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, p, 0600))
+}
+
+func claims(sub string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss": issuer,
+		"aud": "gateway",
+		"sub": sub,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newRequest(t *testing.T, token string) *http.Request {
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+	req.Header.Add(AuthorizationHeader, "Bearer "+token)
+	return req
+}
+
+func createAuth(t *testing.T, props Props, keys ...jose.JSONWebKey) (*JwtAuth, string) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "jwks.json")
+	writeKeys(t, path, keys...)
+
+	props.Issuer = issuer
+	props.KeySet = NewKeySet(KeySetProps{Source: FileKeySource{Paths: []string{path}}})
+	return NewJwtAuth(props), dir
+}
+
+func TestAuthenticateSuccess(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{Audience: "gateway"}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	req, err := auth.Authenticate(newRequest(t, s.sign(t, claims("user1"))))
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", req.Context().Value(core.AAD{}))
+	assert.Nil(t, req.Context().Value(core.SessionKey{}))
+	assert.Equal(t, uint64(1), auth.Stats()["jwtSuccesses"])
+}
+
+func TestAuthenticateClaims(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{AADClaim: "email", SessionKeyClaim: "sid"}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	c := claims("user1")
+	c["email"] = "user1@example.com"
+	c["sid"] = 1234
+
+	req, err := auth.Authenticate(newRequest(t, s.sign(t, c)))
+	assert.Nil(t, err)
+	assert.Equal(t, "user1@example.com", req.Context().Value(core.AAD{}))
+	assert.Equal(t, "1234", req.Context().Value(core.SessionKey{}))
+	assert.Equal(t, "user1", core.GetIdentityClaims(req.Context())["sub"])
+}
+
+func TestAuthenticateMissingClaim(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{AADClaim: "email"}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	_, err := auth.Authenticate(newRequest(t, s.sign(t, claims("user1"))))
+	assert.Equal(t, "token does not have claim email", err.Error())
+	assert.Equal(t, uint64(1), auth.Stats()["jwtFailures"])
+}
+
+func TestAuthenticateNoBearer(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+
+	_, err = auth.Authenticate(req)
+	assert.Error(t, err)
+}
+
+func TestAuthenticateWrongAudience(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{Audience: "other"}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	_, err := auth.Authenticate(newRequest(t, s.sign(t, claims("user1"))))
+	assert.Error(t, err)
+}
+
+func TestAuthenticateWrongIssuer(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	c := claims("user1")
+	c["iss"] = "https://other.example.com/"
+
+	_, err := auth.Authenticate(newRequest(t, s.sign(t, c)))
+	assert.Error(t, err)
+}
+
+func TestAuthenticateExpired(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	c := claims("user1")
+	c["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	_, err := auth.Authenticate(newRequest(t, s.sign(t, c)))
+	assert.Error(t, err)
+}
+
+func TestAuthenticateUnknownKey(t *testing.T) {
+	s := newSigner(t, "key1")
+	other := newSigner(t, "key2")
+	auth, dir := createAuth(t, Props{}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	_, err := auth.Authenticate(newRequest(t, other.sign(t, claims("user1"))))
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user1")
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("user1")}))
+	assert.Error(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("user2")}))
+	assert.Error(t, auth.Verify(ctx, core.AuthRequest{API: "Deploy"}))
+}
+
+func TestVerifyAllowDeploy(t *testing.T) {
+	s := newSigner(t, "key1")
+	auth, dir := createAuth(t, Props{AllowDeploy: true}, s.publicKey())
+	defer os.RemoveAll(dir)
+
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user1")
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Deploy"}))
+}
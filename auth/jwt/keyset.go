@@ -0,0 +1,298 @@
+package jwt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	stderr "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/stats"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+var (
+	// ErrNoKeys is returned when the key source does not provide
+	// any key that can be used to verify a token
+	ErrNoKeys = stderr.New("no keys available to verify token signature")
+
+	// ErrUnknownKey is returned when none of the keys in the key set
+	// verifies the token signature
+	ErrUnknownKey = stderr.New("token signature does not match any key")
+)
+
+// KeySource retrieves the keys used to verify the signature of
+// the tokens
+type KeySource interface {
+	Keys(ctx context.Context) ([]jose.JSONWebKey, error)
+}
+
+// URLKeySource retrieves the keys from a JWKS endpoint
+type URLKeySource struct {
+	URL    string
+	Client *http.Client
+}
+
+// Keys implementation of KeySource for URLKeySource
+func (s URLKeySource) Keys(ctx context.Context) ([]jose.JSONWebKey, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch keys from %s with status %d", s.URL, res.StatusCode)
+	}
+
+	p, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKeys(p)
+}
+
+// FileKeySource retrieves the keys from local files. Each file
+// may contain a JWKS, a single JWK or PEM encoded public keys
+// and certificates
+type FileKeySource struct {
+	Paths []string
+}
+
+// Keys implementation of KeySource for FileKeySource
+func (s FileKeySource) Keys(ctx context.Context) ([]jose.JSONWebKey, error) {
+	var keys []jose.JSONWebKey
+
+	for _, path := range s.Paths {
+		p, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fileKeys, err := parseKeys(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse keys from %s: %s", path, err.Error())
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	return keys, nil
+}
+
+func parseKeys(p []byte) ([]jose.JSONWebKey, error) {
+	if block, _ := pem.Decode(p); block != nil {
+		return parsePEMKeys(p)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(p, &set); err != nil {
+		return nil, err
+	}
+	if len(set.Keys) > 0 {
+		return set.Keys, nil
+	}
+
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(p, &key); err != nil {
+		return nil, err
+	}
+
+	return []jose.JSONWebKey{key}, nil
+}
+
+func parsePEMKeys(p []byte) ([]jose.JSONWebKey, error) {
+	var keys []jose.JSONWebKey
+
+	for {
+		block, rest := pem.Decode(p)
+		if block == nil {
+			return keys, nil
+		}
+		p = rest
+
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, jose.JSONWebKey{Key: key})
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, jose.JSONWebKey{Key: cert.PublicKey})
+		default:
+			return nil, fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+	}
+}
+
+// KeySetProps are the properties used to create a KeySet
+type KeySetProps struct {
+	// Source from which the keys are retrieved
+	Source KeySource
+
+	// RefreshInterval is the maximum time the keys are kept before
+	// they are retrieved again from the source. If it is zero the
+	// keys are only refreshed when a token is signed by an
+	// unknown key
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval is the minimum time between two attempts
+	// to retrieve the keys from the source, so that tokens signed
+	// by unknown keys cannot be used to flood the source
+	MinRefreshInterval time.Duration
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// KeySet caches the keys retrieved from a KeySource and uses them to
+// verify the signature of tokens. It implements oidc.KeySet
+type KeySet struct {
+	source             KeySource
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.Mutex
+	keys        []jose.JSONWebKey
+	updated     time.Time
+	lastAttempt time.Time
+
+	refreshes       stats.Counter
+	refreshFailures stats.Counter
+}
+
+// NewKeySet creates a new KeySet
+func NewKeySet(props KeySetProps) *KeySet {
+	if props.Source == nil {
+		panic("Source must be set")
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &KeySet{
+		source:             props.Source,
+		refreshInterval:    props.RefreshInterval,
+		minRefreshInterval: props.MinRefreshInterval,
+		now:                now,
+	}
+}
+
+// Stats returns the metrics collected by the key set
+func (k *KeySet) Stats() stats.Metrics {
+	return stats.Metrics{
+		"keySetRefreshes":       k.refreshes.Value(),
+		"keySetRefreshFailures": k.refreshFailures.Value(),
+	}
+}
+
+// VerifySignature verifies the signature of the token and returns
+// its payload. If the token is signed by an unknown key the keys
+// are refreshed, in case the issuer has rotated them
+func (k *KeySet) VerifySignature(ctx context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := k.getKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload, ok := verify(jws, keys); ok {
+		return payload, nil
+	}
+
+	keys, err = k.getKeys(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload, ok := verify(jws, keys); ok {
+		return payload, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (k *KeySet) getKeys(ctx context.Context, force bool) ([]jose.JSONWebKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	expired := k.refreshInterval > 0 && now.Sub(k.updated) >= k.refreshInterval
+	allowed := now.Sub(k.lastAttempt) >= k.minRefreshInterval
+
+	refresh := len(k.keys) == 0 || expired || force
+	if !refresh || !allowed {
+		if len(k.keys) == 0 {
+			return nil, ErrNoKeys
+		}
+		return k.keys, nil
+	}
+
+	k.lastAttempt = now
+	keys, err := k.source.Keys(ctx)
+	if err == nil && len(keys) == 0 {
+		err = ErrNoKeys
+	}
+	if err != nil {
+		k.refreshFailures.Incr()
+
+		// keep using the previous keys if the source is not available
+		if len(k.keys) > 0 {
+			return k.keys, nil
+		}
+		return nil, err
+	}
+
+	k.refreshes.Incr()
+	k.keys = keys
+	k.updated = now
+	return k.keys, nil
+}
+
+func verify(jws *jose.JSONWebSignature, keys []jose.JSONWebKey) ([]byte, bool) {
+	var kid string
+	if len(jws.Signatures) > 0 {
+		kid = jws.Signatures[0].Header.KeyID
+	}
+
+	for _, key := range keys {
+		// keys without an id are tried for any token
+		if len(kid) > 0 && len(key.KeyID) > 0 && key.KeyID != kid {
+			continue
+		}
+
+		if payload, err := jws.Verify(&key); err == nil {
+			return payload, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,118 @@
+package jwt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jwks.json")
+	s1 := newSigner(t, "key1")
+	s2 := newSigner(t, "key2")
+	writeKeys(t, path, s1.publicKey())
+
+	c := &clock{now: time.Now()}
+	keySet := NewKeySet(KeySetProps{
+		Source:             FileKeySource{Paths: []string{path}},
+		MinRefreshInterval: time.Minute,
+		Now:                c.Now,
+	})
+
+	_, err = keySet.VerifySignature(context.Background(), s1.sign(t, claims("user1")))
+	assert.Nil(t, err)
+
+	// the new key is not picked up until the minimum refresh
+	// interval has passed
+	writeKeys(t, path, s1.publicKey(), s2.publicKey())
+	_, err = keySet.VerifySignature(context.Background(), s2.sign(t, claims("user1")))
+	assert.Equal(t, ErrUnknownKey, err)
+
+	c.now = c.now.Add(time.Minute)
+	_, err = keySet.VerifySignature(context.Background(), s2.sign(t, claims("user1")))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), keySet.Stats()["keySetRefreshes"])
+}
+
+func TestKeySetRefreshInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jwks.json")
+	s := newSigner(t, "key1")
+	writeKeys(t, path, s.publicKey())
+
+	c := &clock{now: time.Now()}
+	keySet := NewKeySet(KeySetProps{
+		Source:          FileKeySource{Paths: []string{path}},
+		RefreshInterval: time.Hour,
+		Now:             c.Now,
+	})
+
+	_, err = keySet.VerifySignature(context.Background(), s.sign(t, claims("user1")))
+	assert.Nil(t, err)
+
+	// a source failure keeps the previous keys
+	assert.Nil(t, os.Remove(path))
+	c.now = c.now.Add(time.Hour)
+	_, err = keySet.VerifySignature(context.Background(), s.sign(t, claims("user1")))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), keySet.Stats()["keySetRefreshes"])
+	assert.Equal(t, uint64(1), keySet.Stats()["keySetRefreshFailures"])
+}
+
+func TestFileKeySourcePEM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s := newSigner(t, "")
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "key.pem")
+	assert.Nil(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	keySet := NewKeySet(KeySetProps{Source: FileKeySource{Paths: []string{path}}})
+	_, err = keySet.VerifySignature(context.Background(), s.sign(t, claims("user1")))
+	assert.Nil(t, err)
+}
+
+func TestURLKeySource(t *testing.T) {
+	s := newSigner(t, "key1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{s.publicKey()}})
+		assert.Nil(t, err)
+		_, _ = w.Write(p)
+	}))
+	defer server.Close()
+
+	keys, err := URLKeySource{URL: server.URL}.Keys(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(keys))
+	assert.Equal(t, "key1", keys[0].KeyID)
+}
@@ -0,0 +1,221 @@
+package mtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	stderr "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// Identity is the part of the client certificate used to
+// identify the client
+type Identity string
+
+const (
+	// IdentitySubject uses the distinguished name of the subject
+	IdentitySubject Identity = "subject"
+
+	// IdentityCommonName uses the common name of the subject
+	IdentityCommonName Identity = "cn"
+
+	// IdentityDNS uses the first DNS name of the SAN
+	IdentityDNS Identity = "san_dns"
+
+	// IdentityEmail uses the first email address of the SAN
+	IdentityEmail Identity = "san_email"
+
+	// IdentityURI uses the first URI of the SAN
+	IdentityURI Identity = "san_uri"
+)
+
+// SessionKey is the source of the session key of the requests
+type SessionKey string
+
+const (
+	// SessionKeyHeader uses the session key header of the request
+	SessionKeyHeader SessionKey = "header"
+
+	// SessionKeyIdentity uses the identity of the client
+	SessionKeyIdentity SessionKey = "identity"
+
+	// SessionKeyFingerprint uses the SHA-256 fingerprint of the
+	// client certificate
+	SessionKeyFingerprint SessionKey = "fingerprint"
+)
+
+// ErrNoCertificate is returned when the request does not have
+// a verified client certificate
+var ErrNoCertificate = stderr.New("request does not have a verified client certificate")
+
+// Props are the properties used to create an MtlsAuth
+type Props struct {
+	// Identity is the part of the certificate used as the AAD.
+	// Defaults to IdentitySubject
+	Identity Identity
+
+	// SessionKey is the source of the session key. Defaults
+	// to SessionKeyHeader
+	SessionKey SessionKey
+
+	// AllowDeploy if set allows authenticated clients to deploy
+	// services
+	AllowDeploy bool
+}
+
+// MtlsAuth authenticates requests by the client certificate
+// verified by the TLS server
+type MtlsAuth struct {
+	logger      log.Logger
+	identity    Identity
+	sessionKey  SessionKey
+	allowDeploy bool
+
+	successes stats.Counter
+	failures  stats.Counter
+}
+
+// NewMtlsAuth creates a new MtlsAuth
+func NewMtlsAuth(props Props) *MtlsAuth {
+	identity := props.Identity
+	if len(identity) == 0 {
+		identity = IdentitySubject
+	}
+
+	sessionKey := props.SessionKey
+	if len(sessionKey) == 0 {
+		sessionKey = SessionKeyHeader
+	}
+
+	return &MtlsAuth{
+		identity:    identity,
+		sessionKey:  sessionKey,
+		allowDeploy: props.AllowDeploy,
+	}
+}
+
+func (a *MtlsAuth) Name() string {
+	return "auth.mtls.MtlsAuth"
+}
+
+func (a *MtlsAuth) Stats() stats.Metrics {
+	return stats.Metrics{
+		"mtlsSuccesses": a.successes.Value(),
+		"mtlsFailures":  a.failures.Value(),
+	}
+}
+
+// Authenticate sets the AAD of the request from the identity
+// in the verified client certificate
+func (a *MtlsAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.authenticate(req)
+	if err != nil {
+		a.failures.Incr()
+		if a.logger != nil {
+			a.logger.Debug(req.Context(), "failed to authenticate request", log.MapFields{
+				"call_type": "AuthenticateFailure",
+				"err":       err.Error(),
+			})
+		}
+		return req, err
+	}
+
+	a.successes.Incr()
+	return req, nil
+}
+
+func (a *MtlsAuth) authenticate(req *http.Request) (*http.Request, error) {
+	// only the chains verified by the server against the client CAs are
+	// used, PeerCertificates may contain certificates that are not trusted
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return req, ErrNoCertificate
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+	identity, err := a.certificateIdentity(cert)
+	if err != nil {
+		return req, err
+	}
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, identity)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, map[string]interface{}{
+		"subject": cert.Subject.String(),
+		"cn":      cert.Subject.CommonName,
+		"issuer":  cert.Issuer.String(),
+	})
+
+	switch a.sessionKey {
+	case SessionKeyIdentity:
+		ctx = context.WithValue(ctx, core.SessionKey{}, identity)
+	case SessionKeyFingerprint:
+		ctx = context.WithValue(ctx, core.SessionKey{}, Fingerprint(cert))
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+func (a *MtlsAuth) certificateIdentity(cert *x509.Certificate) (string, error) {
+	var identity string
+
+	switch a.identity {
+	case IdentitySubject:
+		identity = cert.Subject.String()
+	case IdentityCommonName:
+		identity = cert.Subject.CommonName
+	case IdentityDNS:
+		if len(cert.DNSNames) > 0 {
+			identity = cert.DNSNames[0]
+		}
+	case IdentityEmail:
+		if len(cert.EmailAddresses) > 0 {
+			identity = cert.EmailAddresses[0]
+		}
+	case IdentityURI:
+		if len(cert.URIs) > 0 {
+			identity = cert.URIs[0].String()
+		}
+	default:
+		return "", fmt.Errorf("unknown certificate identity %s", a.identity)
+	}
+
+	if len(identity) == 0 {
+		return "", fmt.Errorf("client certificate does not have %s", a.identity)
+	}
+
+	return identity, nil
+}
+
+// Fingerprint returns the hex encoded SHA-256 fingerprint of
+// the certificate
+func Fingerprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(hash[:])
+}
+
+// Verify that the AAD in the request data matches the identity
+// of the client certificate
+func (a *MtlsAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	if req.API == "Deploy" {
+		if !a.allowDeploy {
+			return stderr.New("MtlsAuth is not configured to authorize a client to deploy a service")
+		}
+		return nil
+	}
+
+	expectedAAD := core.MustGetAAD(ctx)
+	if string(req.AAD) != expectedAAD {
+		return stderr.New("AAD does not match")
+	}
+
+	return nil
+}
+
+func (a *MtlsAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/mtls", "MtlsAuth")
+}
@@ -0,0 +1,109 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCertificate() *x509.Certificate {
+	uri, _ := url.Parse("spiffe://example.com/billing")
+	return &x509.Certificate{
+		Raw: []byte("certificate"),
+		Subject: pkix.Name{
+			CommonName:   "billing",
+			Organization: []string{"Example"},
+		},
+		DNSNames:       []string{"billing.example.com"},
+		EmailAddresses: []string{"billing@example.com"},
+		URIs:           []*url.URL{uri},
+	}
+}
+
+func newRequest(t *testing.T, cert *x509.Certificate) *http.Request {
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+	}
+
+	return req
+}
+
+func TestAuthenticateIdentity(t *testing.T) {
+	cert := newCertificate()
+
+	for identity, expected := range map[Identity]string{
+		IdentitySubject:    "CN=billing,O=Example",
+		IdentityCommonName: "billing",
+		IdentityDNS:        "billing.example.com",
+		IdentityEmail:      "billing@example.com",
+		IdentityURI:        "spiffe://example.com/billing",
+	} {
+		auth := NewMtlsAuth(Props{Identity: identity})
+		req, err := auth.Authenticate(newRequest(t, cert))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, req.Context().Value(core.AAD{}), string(identity))
+		assert.Nil(t, req.Context().Value(core.SessionKey{}))
+	}
+}
+
+func TestAuthenticateSessionKey(t *testing.T) {
+	cert := newCertificate()
+
+	auth := NewMtlsAuth(Props{Identity: IdentityCommonName, SessionKey: SessionKeyIdentity})
+	req, err := auth.Authenticate(newRequest(t, cert))
+	assert.Nil(t, err)
+	assert.Equal(t, "billing", req.Context().Value(core.SessionKey{}))
+
+	auth = NewMtlsAuth(Props{Identity: IdentityCommonName, SessionKey: SessionKeyFingerprint})
+	req, err = auth.Authenticate(newRequest(t, cert))
+	assert.Nil(t, err)
+	assert.Equal(t, Fingerprint(cert), req.Context().Value(core.SessionKey{}))
+}
+
+func TestAuthenticateNoCertificate(t *testing.T) {
+	auth := NewMtlsAuth(Props{})
+
+	_, err := auth.Authenticate(newRequest(t, nil))
+	assert.Equal(t, ErrNoCertificate, err)
+
+	// certificates that have not been verified are not trusted
+	req := newRequest(t, nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{newCertificate()}}
+	_, err = auth.Authenticate(req)
+	assert.Equal(t, ErrNoCertificate, err)
+	assert.Equal(t, uint64(2), auth.Stats()["mtlsFailures"])
+}
+
+func TestAuthenticateMissingIdentity(t *testing.T) {
+	cert := newCertificate()
+	cert.URIs = nil
+
+	auth := NewMtlsAuth(Props{Identity: IdentityURI})
+	_, err := auth.Authenticate(newRequest(t, cert))
+	assert.Equal(t, "client certificate does not have san_uri", err.Error())
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.WithValue(context.Background(), core.AAD{}, "billing")
+
+	auth := NewMtlsAuth(Props{})
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("billing")}))
+	assert.Error(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", AAD: []byte("other")}))
+	assert.Error(t, auth.Verify(ctx, core.AuthRequest{API: "Deploy"}))
+
+	auth = NewMtlsAuth(Props{AllowDeploy: true})
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Deploy"}))
+}
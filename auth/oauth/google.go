@@ -2,11 +2,15 @@ package oauth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	oidc "github.com/coreos/go-oidc"
+	"github.com/oasislabs/oasis-gateway/auth/cache"
 	"github.com/oasislabs/oasis-gateway/auth/core"
 	auth "github.com/oasislabs/oasis-gateway/auth/core"
 	"github.com/oasislabs/oasis-gateway/log"
@@ -42,18 +46,49 @@ func (g *GoogleIDTokenVerifier) Verify(ctx context.Context, rawIDToken string) (
 	return g.verifier.Verify(ctx, rawIDToken)
 }
 
+// GoogleOauthProps are the properties used to create a GoogleOauth
+type GoogleOauthProps struct {
+	// Verifier verifies the ID tokens of the requests
+	Verifier IDTokenVerifier
+
+	// CacheSize is the maximum number of verified tokens cached
+	// until they expire. If it is 0 tokens are not cached
+	CacheSize int
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
 type GoogleOauth struct {
 	logger   log.Logger
 	verifier IDTokenVerifier
+	cache    *cache.Cache
 }
 
 type OpenIDClaims struct {
 	Email         string `json:"email"`
 	EmailVerified bool   `json:"email_verified"`
+
+	// HostedDomain is the G Suite domain of the user, if any.
+	// It is used as the tenant of the request
+	HostedDomain string `json:"hd"`
+
+	// Expiry is the expiration time of the token in
+	// seconds since epoch
+	Expiry int64 `json:"exp"`
 }
 
 func NewGoogleOauth(verifier IDTokenVerifier) GoogleOauth {
-	return GoogleOauth{verifier: verifier}
+	return NewGoogleOauthWithProps(GoogleOauthProps{Verifier: verifier})
+}
+
+func NewGoogleOauthWithProps(props GoogleOauthProps) GoogleOauth {
+	g := GoogleOauth{verifier: props.Verifier}
+	if props.CacheSize > 0 {
+		g.cache = cache.New(cache.Props{MaxSize: props.CacheSize, Now: props.Now})
+	}
+
+	return g
 }
 
 func (g GoogleOauth) Name() string {
@@ -61,7 +96,11 @@ func (g GoogleOauth) Name() string {
 }
 
 func (g GoogleOauth) Stats() stats.Metrics {
-	return nil
+	if g.cache == nil {
+		return nil
+	}
+
+	return stats.Metrics{"idTokenCache": g.cache.Stats()}
 }
 
 // Authenticates the user using the ID Token received from Google.
@@ -71,23 +110,57 @@ func (g GoogleOauth) Authenticate(req *http.Request) (*http.Request, error) {
 		return req, fmt.Errorf("%s header not set", GOOGLE_ID_TOKEN_KEY)
 	}
 
-	idToken, err := g.verifier.Verify(req.Context(), rawIDToken)
+	claims, err := g.verify(req.Context(), rawIDToken)
 	if err != nil {
 		return req, err
 	}
-
-	var claims OpenIDClaims
-	if err = idToken.Claims(&claims); err != nil {
-		return req, err
-	}
 	if !claims.EmailVerified {
 		return req, errors.New("Email is unverified")
 	}
 
 	ctx := context.WithValue(req.Context(), core.AAD{}, claims.Email)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, map[string]interface{}{
+		"email": claims.Email,
+		"hd":    claims.HostedDomain,
+	})
+	if len(claims.HostedDomain) > 0 {
+		ctx = context.WithValue(ctx, core.Tenant{}, claims.HostedDomain)
+	}
+
 	return req.WithContext(ctx), nil
 }
 
+// verify returns the claims of the token. The claims of verified tokens
+// are cached by the hash of the token until the token expires, so
+// that a token is only verified once
+func (g GoogleOauth) verify(ctx context.Context, rawIDToken string) (OpenIDClaims, error) {
+	var key string
+	if g.cache != nil {
+		hash := sha256.Sum256([]byte(rawIDToken))
+		key = hex.EncodeToString(hash[:])
+		if claims, ok := g.cache.Get(key); ok {
+			return claims.(OpenIDClaims), nil
+		}
+	}
+
+	idToken, err := g.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return OpenIDClaims{}, err
+	}
+
+	var claims OpenIDClaims
+	if err = idToken.Claims(&claims); err != nil {
+		return OpenIDClaims{}, err
+	}
+
+	// tokens without an expiration time are never cached
+	if g.cache != nil && claims.Expiry > 0 {
+		g.cache.Set(key, claims, time.Unix(claims.Expiry, 0))
+	}
+
+	return claims, nil
+}
+
 // Verify the provided AAD in the transaction data with the expected AAD
 // Transaction data is expected to be in the following format:
 //   pk || cipher length || aad length || cipher || aad || nonce
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/stats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +63,98 @@ func TestAuthenticateUnverified(t *testing.T) {
 	assert.Equal(t, "Email is unverified", err.Error())
 	assert.Nil(t, req.Context().Value(core.AAD{}))
 }
+
+func TestAuthenticateHostedDomain(t *testing.T) {
+	claims := OpenIDClaims{
+		Email:         "test@email.com",
+		EmailVerified: true,
+		HostedDomain:  "email.com",
+	}
+	jsonStr, err := json.Marshal(claims)
+	assert.Nil(t, err)
+
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+	req.Header.Add(GOOGLE_ID_TOKEN_KEY, string(jsonStr))
+
+	auth := NewGoogleOauth(&MockIDTokenVerifier{})
+	req, err = auth.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "email.com", core.GetTenant(req.Context()))
+}
+
+type countingIDTokenVerifier struct {
+	MockIDTokenVerifier
+	count int
+}
+
+func (mock *countingIDTokenVerifier) Verify(ctx context.Context, rawIDToken string) (IDToken, error) {
+	mock.count++
+	return mock.MockIDTokenVerifier.Verify(ctx, rawIDToken)
+}
+
+func authenticateToken(t *testing.T, auth GoogleOauth, claims OpenIDClaims) {
+	jsonStr, err := json.Marshal(claims)
+	assert.Nil(t, err)
+
+	req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+	assert.Nil(t, err)
+	req.Header.Add(GOOGLE_ID_TOKEN_KEY, string(jsonStr))
+
+	req, err = auth.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, claims.Email, req.Context().Value(core.AAD{}))
+}
+
+func TestAuthenticateCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	verifier := &countingIDTokenVerifier{}
+	auth := NewGoogleOauthWithProps(GoogleOauthProps{
+		Verifier:  verifier,
+		CacheSize: 16,
+		Now:       func() time.Time { return now },
+	})
+	claims := OpenIDClaims{Email: "test@email.com", EmailVerified: true, Expiry: 1060}
+
+	authenticateToken(t, auth, claims)
+	authenticateToken(t, auth, claims)
+	assert.Equal(t, 1, verifier.count)
+
+	// once the token expires it is verified again
+	now = now.Add(time.Minute)
+	authenticateToken(t, auth, claims)
+	assert.Equal(t, 2, verifier.count)
+
+	metrics := auth.Stats()["idTokenCache"].(stats.Metrics)
+	assert.Equal(t, uint64(1), metrics["hits"])
+	assert.Equal(t, uint64(2), metrics["misses"])
+	assert.Equal(t, float64(1)/3, metrics["hitRate"])
+}
+
+func TestAuthenticateCacheDisabled(t *testing.T) {
+	verifier := &countingIDTokenVerifier{}
+	auth := NewGoogleOauthWithProps(GoogleOauthProps{Verifier: verifier})
+	claims := OpenIDClaims{Email: "test@email.com", EmailVerified: true, Expiry: time.Now().Add(time.Hour).Unix()}
+
+	authenticateToken(t, auth, claims)
+	authenticateToken(t, auth, claims)
+	assert.Equal(t, 2, verifier.count)
+	assert.Nil(t, auth.Stats())
+}
+
+func TestAuthenticateCacheUnverifiedEmail(t *testing.T) {
+	verifier := &countingIDTokenVerifier{}
+	auth := NewGoogleOauthWithProps(GoogleOauthProps{Verifier: verifier, CacheSize: 16})
+	claims := OpenIDClaims{Email: "test@email.com", Expiry: time.Now().Add(time.Hour).Unix()}
+	jsonStr, err := json.Marshal(claims)
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("POST", "gateway.oasiscloud.io", nil)
+		assert.Nil(t, err)
+		req.Header.Add(GOOGLE_ID_TOKEN_KEY, string(jsonStr))
+
+		_, err = auth.Authenticate(req)
+		assert.Equal(t, "Email is unverified", err.Error())
+	}
+}
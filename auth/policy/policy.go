@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// Props are the properties used to create a PolicyAuth
+type Props struct {
+	// Path to the rules file
+	Path string
+
+	// ReloadInterval is the minimum time between two checks of
+	// the rules file for changes
+	ReloadInterval time.Duration
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// PolicyAuth wraps an Auth so that requests that are verified by
+// the wrapped Auth are also authorized by a set of rules loaded
+// from a rules file. The rules file is checked for changes when
+// requests are verified, so rules can be updated without
+// restarting the gateway
+type PolicyAuth struct {
+	auth           core.Auth
+	logger         log.Logger
+	path           string
+	reloadInterval time.Duration
+	now            func() time.Time
+
+	mu        sync.RWMutex
+	rules     *Rules
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+
+	allowed        stats.Counter
+	denied         stats.Counter
+	reloads        stats.Counter
+	reloadFailures stats.Counter
+}
+
+// NewPolicyAuth creates a new PolicyAuth that wraps the provided
+// Auth and loads the rules file
+func NewPolicyAuth(auth core.Auth, props Props) (*PolicyAuth, error) {
+	if auth == nil {
+		panic("auth must be set")
+	}
+	if len(props.Path) == 0 {
+		panic("Path must be set")
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	a := &PolicyAuth{
+		auth:           auth,
+		path:           props.Path,
+		reloadInterval: props.ReloadInterval,
+		now:            now,
+	}
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.loadLocked(info); err != nil {
+		return nil, err
+	}
+	a.lastCheck = now()
+
+	return a, nil
+}
+
+func (a *PolicyAuth) Name() string {
+	return "auth.policy.PolicyAuth(" + a.auth.Name() + ")"
+}
+
+func (a *PolicyAuth) Stats() stats.Metrics {
+	metrics := make(stats.Metrics)
+	for k, v := range a.auth.Stats() {
+		metrics[k] = v
+	}
+
+	metrics["policyAllowed"] = a.allowed.Value()
+	metrics["policyDenied"] = a.denied.Value()
+	metrics["policyReloads"] = a.reloads.Value()
+	metrics["policyReloadFailures"] = a.reloadFailures.Value()
+	return metrics
+}
+
+// Authenticate implementation of Auth for PolicyAuth. Requests are
+// authenticated by the wrapped Auth
+func (a *PolicyAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	return a.auth.Authenticate(req)
+}
+
+// Verify verifies the request with the wrapped Auth and then
+// evaluates the rules for the request
+func (a *PolicyAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	if err := a.auth.Verify(ctx, req); err != nil {
+		return err
+	}
+
+	aad, _ := ctx.Value(core.AAD{}).(string)
+	decision := a.getRules().Evaluate(Request{
+		AuthRequest: req,
+		AAD:         aad,
+		Tenant:      core.GetTenant(ctx),
+		Claims:      core.GetIdentityClaims(ctx),
+	})
+
+	fields := log.MapFields{
+		"api":     req.API,
+		"address": req.Address,
+		"aad":     aad,
+		"rule":    decision.Rule,
+		"effect":  string(decision.Effect),
+	}
+
+	if decision.Effect != Allow {
+		a.denied.Incr()
+		if a.logger != nil {
+			fields["call_type"] = "PolicyDeny"
+			a.logger.Info(ctx, "request denied by policy", fields)
+		}
+		if len(decision.Rule) == 0 {
+			return stderr.New("request denied by default policy")
+		}
+		return fmt.Errorf("request denied by policy rule %s", decision.Rule)
+	}
+
+	a.allowed.Incr()
+	if a.logger != nil {
+		fields["call_type"] = "PolicyAllow"
+		a.logger.Debug(ctx, "request allowed by policy", fields)
+	}
+	return nil
+}
+
+func (a *PolicyAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/policy", "PolicyAuth")
+	a.auth.SetLogger(l)
+}
+
+func (a *PolicyAuth) getRules() *Rules {
+	a.reloadIfChanged()
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.rules
+}
+
+func (a *PolicyAuth) reloadIfChanged() {
+	now := a.now()
+
+	a.mu.RLock()
+	check := now.Sub(a.lastCheck) >= a.reloadInterval
+	a.mu.RUnlock()
+	if !check {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// another goroutine may have checked the file already
+	if now.Sub(a.lastCheck) < a.reloadInterval {
+		return
+	}
+	a.lastCheck = now
+
+	info, err := os.Stat(a.path)
+	if err == nil && info.ModTime().Equal(a.modTime) && info.Size() == a.size {
+		return
+	}
+	if err == nil {
+		err = a.loadLocked(info)
+	}
+
+	if err != nil {
+		// keep the rules loaded if the new rules cannot be loaded
+		a.reloadFailures.Incr()
+		if a.logger != nil {
+			a.logger.Warn(context.Background(), "failed to reload policy rules", log.MapFields{
+				"call_type": "PolicyReloadFailure",
+				"path":      a.path,
+				"err":       err.Error(),
+			})
+		}
+		return
+	}
+
+	a.reloads.Incr()
+	if a.logger != nil {
+		a.logger.Info(context.Background(), "policy rules reloaded", log.MapFields{
+			"call_type": "PolicyReloadSuccess",
+			"path":      a.path,
+			"rules":     len(a.rules.Rules),
+		})
+	}
+}
+
+func (a *PolicyAuth) loadLocked(info os.FileInfo) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	rules, err := ReadRules(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse policy rules %s: %s", a.path, err.Error())
+	}
+
+	a.rules = rules
+	a.modTime = info.ModTime()
+	a.size = info.Size()
+	return nil
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/auth/insecure"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var Logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Output: ioutil.Discard,
+})
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func writeRules(t *testing.T, path string, modTime time.Time, rules string) {
+	assert.Nil(t, ioutil.WriteFile(path, []byte(rules), 0600))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func createPolicyAuth(t *testing.T, rules string) (*PolicyAuth, *clock, string) {
+	dir, err := ioutil.TempDir("", "policy")
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "rules.json")
+	c := &clock{now: time.Now()}
+	writeRules(t, path, c.now, rules)
+
+	auth, err := NewPolicyAuth(insecure.InsecureAuth{}, Props{
+		Path:           path,
+		ReloadInterval: time.Second,
+		Now:            c.Now,
+	})
+	assert.Nil(t, err)
+	auth.SetLogger(Logger)
+	return auth, c, path
+}
+
+func TestVerify(t *testing.T) {
+	auth, _, path := createPolicyAuth(t, `{"default":"deny","rules":[
+		{"name":"user","effect":"allow","claims":{"aad":["user"]}}
+	]}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user")
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", Data: "0x00"}))
+
+	ctx = context.WithValue(context.Background(), core.AAD{}, "other")
+	err := auth.Verify(ctx, core.AuthRequest{API: "Execute", Data: "0x00"})
+	assert.Equal(t, "request denied by default policy", err.Error())
+
+	assert.Equal(t, uint64(1), auth.Stats()["policyAllowed"])
+	assert.Equal(t, uint64(1), auth.Stats()["policyDenied"])
+}
+
+func TestVerifyWrappedAuthFailure(t *testing.T) {
+	auth, _, path := createPolicyAuth(t, `{"default":"allow"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	// the wrapped auth rejects requests without data
+	err := auth.Verify(context.Background(), core.AuthRequest{API: "Execute"})
+	assert.Equal(t, insecure.ErrDataTooShort, err)
+	assert.Equal(t, uint64(0), auth.Stats()["policyAllowed"])
+}
+
+func TestVerifyReload(t *testing.T) {
+	auth, c, path := createPolicyAuth(t, `{"default":"allow"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user")
+	req := core.AuthRequest{API: "Execute", Data: "0x00"}
+	assert.Nil(t, auth.Verify(ctx, req))
+
+	writeRules(t, path, c.now.Add(time.Second), `{"default":"allow","rules":[
+		{"name":"blocked","effect":"deny","claims":{"aad":["user"]}}
+	]}`)
+
+	// the rules file is not checked until the reload interval passes
+	assert.Nil(t, auth.Verify(ctx, req))
+
+	c.now = c.now.Add(time.Second)
+	err := auth.Verify(ctx, req)
+	assert.Equal(t, "request denied by policy rule blocked", err.Error())
+	assert.Equal(t, uint64(1), auth.Stats()["policyReloads"])
+
+	// invalid rules keep the rules previously loaded
+	writeRules(t, path, c.now.Add(time.Second), `{"default":"maybe"}`)
+	c.now = c.now.Add(time.Second)
+	assert.Error(t, auth.Verify(ctx, req))
+	assert.Equal(t, uint64(1), auth.Stats()["policyReloadFailures"])
+}
@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+)
+
+// Effect is the decision taken for a request
+type Effect string
+
+const (
+	// Allow allows the request
+	Allow Effect = "allow"
+
+	// Deny denies the request
+	Deny Effect = "deny"
+)
+
+const (
+	// ClaimAAD is the claim name that matches the AAD of
+	// the request
+	ClaimAAD = "aad"
+
+	// ClaimTenant is the claim name that matches the tenant
+	// of the request
+	ClaimTenant = "tenant"
+)
+
+// Rule is a condition on a request and the effect that applies to
+// the request if the condition is met. A request meets the condition
+// if it matches all the constraints set in the rule
+type Rule struct {
+	// Name identifies the rule in the logs
+	Name string `json:"name"`
+
+	// Effect applied to the request if the rule matches
+	Effect Effect `json:"effect"`
+
+	// APIs if set is the list of APIs that the rule matches
+	APIs []string `json:"apis"`
+
+	// Claims if set maps claim names to the values that the rule
+	// matches. The claims aad and tenant match the AAD and the tenant
+	// of the request. Other claims are provided by the authentication
+	// provider
+	Claims map[string][]string `json:"claims"`
+
+	// Addresses if set is the list of service addresses that the
+	// rule matches. Only requests to execute a service have an address
+	Addresses []string `json:"addresses"`
+
+	// CodeHashes if set is the list of keccak256 hashes of the
+	// bytecode that the rule matches. Only requests to deploy a
+	// service have a code hash
+	CodeHashes []string `json:"codeHashes"`
+
+	// MinDataSize if set is the minimum size in bytes of the request
+	// data that the rule matches
+	MinDataSize *int `json:"minDataSize"`
+
+	// MaxDataSize if set is the maximum size in bytes of the request
+	// data that the rule matches
+	MaxDataSize *int `json:"maxDataSize"`
+}
+
+// Rules is the format of the rules file. Rules are evaluated in
+// order and the effect of the first matching rule is applied. If
+// no rule matches, the Default effect is applied
+type Rules struct {
+	Default Effect `json:"default"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Request is the information about a request evaluated by the rules
+type Request struct {
+	core.AuthRequest
+	AAD    string
+	Tenant string
+	Claims map[string]interface{}
+}
+
+// Decision is the result of evaluating the rules for a request
+type Decision struct {
+	// Effect applied to the request
+	Effect Effect
+
+	// Rule is the name of the rule that matched or empty if the
+	// default effect was applied
+	Rule string
+}
+
+// ReadRules reads and validates rules from a rules file
+func ReadRules(r io.Reader) (*Rules, error) {
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(p, &rules); err != nil {
+		return nil, err
+	}
+
+	if err := validateEffect(rules.Default); err != nil {
+		return nil, fmt.Errorf("default %s", err.Error())
+	}
+
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if len(rule.Name) == 0 {
+			rule.Name = fmt.Sprintf("rule-%d", i)
+		}
+		if err := validateEffect(rule.Effect); err != nil {
+			return nil, fmt.Errorf("rule %s %s", rule.Name, err.Error())
+		}
+
+		for j, address := range rule.Addresses {
+			rule.Addresses[j] = strings.ToLower(address)
+		}
+		for j, hash := range rule.CodeHashes {
+			rule.CodeHashes[j] = strings.ToLower(hash)
+		}
+	}
+
+	return &rules, nil
+}
+
+func validateEffect(effect Effect) error {
+	switch effect {
+	case Allow, Deny:
+		return nil
+	default:
+		return fmt.Errorf("effect must be %s or %s", Allow, Deny)
+	}
+}
+
+// Evaluate returns the decision for the request
+func (r *Rules) Evaluate(req Request) Decision {
+	data, err := hexutil.Decode(req.Data)
+	if err != nil {
+		// data that is not hex is still sent to the backend as
+		// is, which is the size that the rules are applied to
+		data = []byte(req.Data)
+	}
+
+	for _, rule := range r.Rules {
+		if rule.matches(req, data) {
+			return Decision{Effect: rule.Effect, Rule: rule.Name}
+		}
+	}
+
+	return Decision{Effect: r.Default}
+}
+
+func (rule *Rule) matches(req Request, data []byte) bool {
+	if len(rule.APIs) > 0 && !contains(rule.APIs, req.API) {
+		return false
+	}
+
+	for name, values := range rule.Claims {
+		if !containsAny(values, claimValues(req, name)) {
+			return false
+		}
+	}
+
+	if len(rule.Addresses) > 0 && !contains(rule.Addresses, strings.ToLower(req.Address)) {
+		return false
+	}
+
+	if len(rule.CodeHashes) > 0 {
+		if req.API != "Deploy" {
+			return false
+		}
+
+		hash := hexutil.Encode(crypto.Keccak256(data))
+		if !contains(rule.CodeHashes, hash) {
+			return false
+		}
+	}
+
+	if rule.MinDataSize != nil && len(data) < *rule.MinDataSize {
+		return false
+	}
+
+	if rule.MaxDataSize != nil && len(data) > *rule.MaxDataSize {
+		return false
+	}
+
+	return true
+}
+
+// claimValues returns the values of a claim. Claims with a list of
+// values, such as groups or roles, match any of their values
+func claimValues(req Request, name string) []string {
+	switch name {
+	case ClaimAAD:
+		return []string{req.AAD}
+	case ClaimTenant:
+		return []string{req.Tenant}
+	}
+
+	switch value := req.Claims[name].(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	case []string:
+		return value
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
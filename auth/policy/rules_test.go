@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/stretchr/testify/assert"
+)
+
+const code = "0x6080604052"
+
+var codeHash = hexutil.Encode(crypto.Keccak256(hexutil.MustDecode(code)))
+
+func readRules(t *testing.T, s string) *Rules {
+	rules, err := ReadRules(strings.NewReader(s))
+	assert.Nil(t, err)
+	return rules
+}
+
+func TestReadRulesInvalid(t *testing.T) {
+	_, err := ReadRules(strings.NewReader(`{"rules":[]}`))
+	assert.Equal(t, "default effect must be allow or deny", err.Error())
+
+	_, err = ReadRules(strings.NewReader(`{"default":"deny","rules":[{"name":"r","effect":"maybe"}]}`))
+	assert.Equal(t, "rule r effect must be allow or deny", err.Error())
+}
+
+func TestEvaluateDefault(t *testing.T) {
+	rules := readRules(t, `{"default":"deny","rules":[{"name":"deploy","effect":"allow","apis":["Deploy"]}]}`)
+
+	assert.Equal(t, Decision{Effect: Allow, Rule: "deploy"},
+		rules.Evaluate(Request{AuthRequest: core.AuthRequest{API: "Deploy"}}))
+	assert.Equal(t, Decision{Effect: Deny},
+		rules.Evaluate(Request{AuthRequest: core.AuthRequest{API: "Execute"}}))
+}
+
+func TestEvaluateFirstMatch(t *testing.T) {
+	rules := readRules(t, `{"default":"allow","rules":[
+		{"name":"blocked","effect":"deny","claims":{"aad":["blocked"]}},
+		{"name":"all","effect":"allow"}
+	]}`)
+
+	assert.Equal(t, Decision{Effect: Deny, Rule: "blocked"}, rules.Evaluate(Request{AAD: "blocked"}))
+	assert.Equal(t, Decision{Effect: Allow, Rule: "all"}, rules.Evaluate(Request{AAD: "other"}))
+}
+
+func TestEvaluateClaims(t *testing.T) {
+	rules := readRules(t, `{"default":"deny","rules":[
+		{"name":"admins","effect":"allow","claims":{"tenant":["example.com"],"groups":["admin"]}}
+	]}`)
+
+	assert.Equal(t, Allow, rules.Evaluate(Request{
+		Tenant: "example.com",
+		Claims: map[string]interface{}{"groups": []interface{}{"dev", "admin"}},
+	}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{
+		Tenant: "example.com",
+		Claims: map[string]interface{}{"groups": []interface{}{"dev"}},
+	}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{
+		Tenant: "other.com",
+		Claims: map[string]interface{}{"groups": []interface{}{"admin"}},
+	}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{Tenant: "example.com"}).Effect)
+}
+
+func TestEvaluateAddresses(t *testing.T) {
+	rules := readRules(t, `{"default":"deny","rules":[
+		{"name":"service","effect":"allow","apis":["Execute"],"addresses":["0xB8b3666d8fEa887D97Ab54f571B8E5020c5c8b58"]}
+	]}`)
+
+	assert.Equal(t, Allow, rules.Evaluate(Request{AuthRequest: core.AuthRequest{
+		API:     "Execute",
+		Address: "0xb8b3666d8fea887d97ab54f571b8e5020c5c8b58",
+	}}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{AuthRequest: core.AuthRequest{
+		API:     "Execute",
+		Address: "0x0000000000000000000000000000000000000000",
+	}}).Effect)
+}
+
+func TestEvaluateCodeHashes(t *testing.T) {
+	rules := readRules(t, `{"default":"deny","rules":[
+		{"name":"code","effect":"allow","codeHashes":["`+strings.ToUpper(codeHash[2:])+`","`+codeHash+`"]}
+	]}`)
+
+	assert.Equal(t, Allow, rules.Evaluate(Request{AuthRequest: core.AuthRequest{API: "Deploy", Data: code}}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{AuthRequest: core.AuthRequest{API: "Deploy", Data: "0x00"}}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{AuthRequest: core.AuthRequest{API: "Execute", Data: code}}).Effect)
+}
+
+func TestEvaluateDataSize(t *testing.T) {
+	rules := readRules(t, `{"default":"allow","rules":[
+		{"name":"large","effect":"deny","minDataSize":4}
+	]}`)
+
+	assert.Equal(t, Allow, rules.Evaluate(Request{AuthRequest: core.AuthRequest{Data: "0x000000"}}).Effect)
+	assert.Equal(t, Deny, rules.Evaluate(Request{AuthRequest: core.AuthRequest{Data: "0x00000000"}}).Effect)
+}
@@ -0,0 +1,56 @@
+package signature
+
+import (
+	"context"
+	"fmt"
+
+	mqueue "github.com/oasislabs/oasis-gateway/mqueue/core"
+)
+
+// nonceKeyFormat is the format of the mqueue key used to
+// keep track of a nonce used by a client
+const nonceKeyFormat = "signature:nonce:%s"
+
+// NonceCache keeps track of the nonces that have already
+// been used by clients
+type NonceCache interface {
+	// Use marks the nonce as used and returns true if it
+	// had already been used before
+	Use(ctx context.Context, nonce string) (bool, error)
+}
+
+// MQueueNonceCache is a NonceCache that keeps the nonces in an
+// mqueue so that they are shared amongst all the gateway instances
+// that use the same mqueue backend.
+//
+// Nonces are not scoped by the address that signed the request,
+// since a request replayed by another identity recovers a different
+// address. Each nonce is represented by a queue, and reserving the
+// next offset on the queue is atomic, so only the first request with
+// a nonce gets offset 0. The queues expire after a period of
+// inactivity, which must be longer than the window in which a
+// timestamp is accepted
+type MQueueNonceCache struct {
+	mqueue mqueue.MQueue
+}
+
+// NewMQueueNonceCache creates a new NonceCache backed by an mqueue
+func NewMQueueNonceCache(m mqueue.MQueue) *MQueueNonceCache {
+	if m == nil {
+		panic("mqueue must be set")
+	}
+
+	return &MQueueNonceCache{mqueue: m}
+}
+
+// Use implementation of NonceCache for MQueueNonceCache
+func (c *MQueueNonceCache) Use(ctx context.Context, nonce string) (bool, error) {
+	offset, err := c.mqueue.Next(ctx, mqueue.NextRequest{
+		Key: fmt.Sprintf(nonceKeyFormat, nonce),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return offset > 0, nil
+}
@@ -0,0 +1,372 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	stderr "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+const (
+	// SignatureHeader is the header with the hex encoded secp256k1
+	// signature of the request, as returned by personal_sign
+	SignatureHeader = "X-OASIS-SIGNATURE"
+
+	// TimestampHeader is the header with the unix time in seconds
+	// at which the request was signed
+	TimestampHeader = "X-OASIS-SIGNATURE-TIMESTAMP"
+
+	// NonceHeader is the header with a unique value for the
+	// request so that the request cannot be replayed
+	NonceHeader = "X-OASIS-SIGNATURE-NONCE"
+
+	// MaxSkew is the maximum allowed difference between the
+	// timestamp of a request and the time of the gateway. Nonces are
+	// kept by the mqueue for as long as its queues live, and
+	// a request must not be accepted after its nonce has expired
+	MaxSkew = 5 * time.Minute
+
+	// maxNonceLength is the maximum length of a nonce
+	maxNonceLength = 128
+
+	// signatureLength is the length of a [R || S || V] signature
+	signatureLength = 65
+
+	// recoveryIDOffset is the offset of V in the signature
+	recoveryIDOffset = 64
+)
+
+var (
+	// ErrNoSignature is returned when the request is not signed
+	ErrNoSignature = fmt.Errorf("%s header not set", SignatureHeader)
+
+	// ErrInvalidSignature is returned when the address cannot be
+	// recovered from the signature of the request
+	ErrInvalidSignature = stderr.New("invalid request signature")
+
+	// ErrBodyTooLarge is returned when the body of the request
+	// is larger than the maximum size that is read to verify
+	// its signature
+	ErrBodyTooLarge = stderr.New("request body is too large")
+
+	// ErrAADMismatch is returned when the AAD in the request data
+	// is not the address that signed the request
+	ErrAADMismatch = stderr.New("AAD does not match the address that signed the request")
+
+	// ErrStaleTimestamp is returned when the timestamp of the
+	// request is too far from the gateway time
+	ErrStaleTimestamp = stderr.New("request timestamp is outside of the accepted window")
+
+	// ErrReplayedNonce is returned when the nonce of the request
+	// has already been used
+	ErrReplayedNonce = stderr.New("request nonce has already been used")
+)
+
+// address is the context key with the address recovered
+// from the signature of the request
+type address struct{}
+
+// identity is the context key with the AAD set by the
+// wrapped Auth
+type identity struct{}
+
+// Props are the properties used to create a SignatureAuth
+type Props struct {
+	// Nonces keeps track of the nonces already used
+	Nonces NonceCache
+
+	// MaxSkew is the maximum allowed difference between the
+	// timestamp of a request and the time of the gateway. It cannot
+	// be larger than MaxSkew. Defaults to MaxSkew
+	MaxSkew time.Duration
+
+	// MaxBodyBytes is the maximum size of a body that is read
+	// to verify its signature
+	MaxBodyBytes int64
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// SignatureAuth wraps an Auth so that requests authenticated by the
+// wrapped Auth must also be signed with a secp256k1 key. The address
+// recovered from the signature replaces the AAD of the request, so
+// a client can only issue requests with the AAD of the keys it holds,
+// and the session of the request is bound to both the identity
+// authenticated by the wrapped Auth and the address
+type SignatureAuth struct {
+	auth         core.Auth
+	logger       log.Logger
+	nonces       NonceCache
+	maxSkew      time.Duration
+	maxBodyBytes int64
+	now          func() time.Time
+
+	successes stats.Counter
+	failures  stats.Counter
+	replays   stats.Counter
+}
+
+// NewSignatureAuth creates a new SignatureAuth that wraps the
+// provided Auth
+func NewSignatureAuth(auth core.Auth, props Props) *SignatureAuth {
+	if auth == nil {
+		panic("auth must be set")
+	}
+	if props.Nonces == nil {
+		panic("Nonces must be set")
+	}
+	if props.MaxBodyBytes <= 0 {
+		panic("MaxBodyBytes must be positive")
+	}
+
+	maxSkew := props.MaxSkew
+	if maxSkew <= 0 || maxSkew > MaxSkew {
+		maxSkew = MaxSkew
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &SignatureAuth{
+		auth:         auth,
+		nonces:       props.Nonces,
+		maxSkew:      maxSkew,
+		maxBodyBytes: props.MaxBodyBytes,
+		now:          now,
+	}
+}
+
+// SigningHash returns the hash signed by the client. It is the hash
+// personal_sign signs for the message made of the method, the path,
+// the timestamp, the nonce, the AAD assigned by the wrapped Auth, the
+// session key and the body of the request, separated by new lines.
+// The body goes last since it is the only part that may contain
+// new lines
+func SigningHash(method, path, timestamp, nonce, identity, sessionKey string, body []byte) []byte {
+	message := strings.Join([]string{method, path, timestamp, nonce, identity, sessionKey, string(body)}, "\n")
+	prefixed := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
+	return crypto.Keccak256([]byte(prefixed))
+}
+
+// Recover returns the address that produced the signature
+// of the hash
+func Recover(hash, signature []byte) (common.Address, error) {
+	if len(signature) != signatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	// personal_sign returns signatures with a recovery
+	// id of 27 or 28
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	if sig[recoveryIDOffset] >= 27 {
+		sig[recoveryIDOffset] -= 27
+	}
+
+	pk, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	return crypto.PubkeyToAddress(*pk), nil
+}
+
+// GetAddress returns the address that signed the request
+func GetAddress(ctx context.Context) string {
+	value, _ := ctx.Value(address{}).(string)
+	return value
+}
+
+func (a *SignatureAuth) Name() string {
+	return "auth.signature.SignatureAuth(" + a.auth.Name() + ")"
+}
+
+func (a *SignatureAuth) Stats() stats.Metrics {
+	metrics := make(stats.Metrics)
+	for k, v := range a.auth.Stats() {
+		metrics[k] = v
+	}
+
+	metrics["signatureSuccesses"] = a.successes.Value()
+	metrics["signatureFailures"] = a.failures.Value()
+	metrics["signatureReplays"] = a.replays.Value()
+	return metrics
+}
+
+// Authenticate authenticates the request with the wrapped Auth and
+// then verifies the signature of the request. The address that
+// signed the request is used as the AAD, as a lowercase hex
+// string with the 0x prefix. The AAD set by the wrapped Auth is
+// kept to identify the session and to verify the request with
+// the wrapped Auth. Since the signature covers that AAD and the
+// session key, a signed request cannot be replayed by another
+// identity or in another session
+func (a *SignatureAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	req, err := a.auth.Authenticate(req)
+	if err != nil {
+		return req, err
+	}
+
+	wrapped, _ := req.Context().Value(core.AAD{}).(string)
+	addr, err := a.recoverAddress(req, wrapped)
+	if err != nil {
+		a.failures.Incr()
+		if err == ErrReplayedNonce {
+			a.replays.Incr()
+		}
+		if a.logger != nil {
+			a.logger.Debug(req.Context(), "failed to verify request signature", log.MapFields{
+				"call_type": "SignatureVerifyFailure",
+				"err":       err.Error(),
+			})
+		}
+		return req, err
+	}
+	a.successes.Incr()
+
+	// keep the identity provided by the wrapped Auth in the claims so
+	// that policies can still refer to it
+	claims := make(map[string]interface{})
+	for k, v := range core.GetIdentityClaims(req.Context()) {
+		claims[k] = v
+	}
+	claims["identity"] = wrapped
+	claims["address"] = addr
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, addr)
+	ctx = context.WithValue(ctx, address{}, addr)
+	ctx = context.WithValue(ctx, identity{}, wrapped)
+	ctx = context.WithValue(ctx, core.IdentityClaims{}, claims)
+	ctx = context.WithValue(ctx, core.SessionIdentity{}, wrapped+":"+addr)
+	return req.WithContext(ctx), nil
+}
+
+func (a *SignatureAuth) recoverAddress(req *http.Request, wrapped string) (string, error) {
+	value := req.Header.Get(SignatureHeader)
+	if len(value) == 0 {
+		return "", ErrNoSignature
+	}
+
+	timestamp := req.Header.Get(TimestampHeader)
+	nonce := req.Header.Get(NonceHeader)
+	for header, value := range map[string]string{
+		TimestampHeader: timestamp,
+		NonceHeader:     nonce,
+	} {
+		if len(value) == 0 {
+			return "", fmt.Errorf("%s header not set", header)
+		}
+	}
+
+	if len(nonce) > maxNonceLength {
+		return "", fmt.Errorf("%s header exceeds %d characters", NonceHeader, maxNonceLength)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%s header is not a unix timestamp", TimestampHeader)
+	}
+	skew := a.now().Sub(time.Unix(seconds, 0))
+	if skew > a.maxSkew || skew < -a.maxSkew {
+		return "", ErrStaleTimestamp
+	}
+
+	signature, err := hexutil.Decode(value)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	body, err := a.readBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	// the session key is resolved as core.HttpMiddlewareAuth
+	// does to derive the session of the request
+	sessionKey, _ := req.Context().Value(core.SessionKey{}).(string)
+	if len(sessionKey) == 0 {
+		sessionKey = req.Header.Get(core.RequestHeaderSessionKey)
+	}
+
+	addr, err := Recover(SigningHash(req.Method, req.URL.Path, timestamp, nonce, wrapped, sessionKey, body), signature)
+	if err != nil {
+		return "", err
+	}
+
+	// the nonce is only recorded once the address is recovered so that
+	// requests without a valid signature cannot consume nonces
+	used, err := a.nonces.Use(req.Context(), nonce)
+	if err != nil {
+		return "", err
+	}
+	if used {
+		return "", ErrReplayedNonce
+	}
+
+	return strings.ToLower(addr.Hex()), nil
+}
+
+// readBody reads the body of the request and replaces it so that
+// it can be read again by the handlers
+func (a *SignatureAuth) readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, a.maxBodyBytes+1))
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > a.maxBodyBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Verify that the AAD in the request data is the address that signed
+// the request and then verifies the request with the wrapped Auth.
+// Since the AAD is bound to the address by the signature, the wrapped
+// Auth verifies the request on behalf of the identity it authenticated,
+// with that identity as the AAD of both the context and the request
+func (a *SignatureAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	addr := GetAddress(ctx)
+	if len(addr) == 0 {
+		return stderr.New("request without signature cannot be verified")
+	}
+
+	if req.API == "Execute" && string(req.AAD) != addr {
+		return ErrAADMismatch
+	}
+
+	wrapped, _ := ctx.Value(identity{}).(string)
+	ctx = context.WithValue(ctx, core.AAD{}, wrapped)
+	if req.API == "Execute" {
+		req.AAD = []byte(wrapped)
+	}
+
+	return a.auth.Verify(ctx, req)
+}
+
+func (a *SignatureAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/signature", "SignatureAuth")
+	a.auth.SetLogger(l)
+}
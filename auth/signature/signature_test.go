@@ -0,0 +1,305 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/auth/apikey"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/auth/hmac"
+	"github.com/oasislabs/oasis-gateway/auth/insecure"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+const body = `{"address":"0x0000000000000000000000000000000000000000","data":"0x00"}`
+
+var logger = log.NewLogrus(log.LogrusLoggerProperties{Output: ioutil.Discard})
+
+func newAuth(ctx context.Context, auth core.Auth, maxBodyBytes int64) *SignatureAuth {
+	return NewSignatureAuth(auth, Props{
+		Nonces:       NewMQueueNonceCache(mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})),
+		MaxBodyBytes: maxBodyBytes,
+	})
+}
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	return key, strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex())
+}
+
+func newRequest(t *testing.T, key *ecdsa.PrivateKey, body string) *http.Request {
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Add(insecure.HeaderKey, "identity")
+	req.Header.Add(core.RequestHeaderSessionKey, "session")
+	sign(t, req, key, "identity", body)
+	return req
+}
+
+// sign signs the request for the identity assigned by the wrapped
+// Auth with a new nonce and the current time
+func sign(t *testing.T, req *http.Request, key *ecdsa.PrivateKey, identity, body string) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	assert.Nil(t, err)
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(NonceHeader, hexutil.Encode(nonce))
+
+	sig, err := crypto.Sign(SigningHash(req.Method, req.URL.Path, ts, hexutil.Encode(nonce),
+		identity, req.Header.Get(core.RequestHeaderSessionKey), []byte(body)), key)
+	assert.Nil(t, err)
+
+	// sign as personal_sign does, with a recovery id of 27 or 28
+	sig[recoveryIDOffset] += 27
+	req.Header.Add(SignatureHeader, hexutil.Encode(sig))
+}
+
+func TestAuthenticate(t *testing.T) {
+	key, addr := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	req, err := auth.Authenticate(newRequest(t, key, body))
+	assert.Nil(t, err)
+	assert.Equal(t, addr, core.MustGetAAD(req.Context()))
+	assert.Equal(t, addr, GetAddress(req.Context()))
+	assert.Equal(t, "identity", core.GetIdentityClaims(req.Context())["identity"])
+	assert.Equal(t, "identity:"+addr, req.Context().Value(core.SessionIdentity{}))
+
+	// the body can still be read by the handlers
+	b, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, body, string(b))
+
+	assert.Equal(t, uint64(1), auth.Stats()["signatureSuccesses"])
+}
+
+func TestAuthenticateNoSignature(t *testing.T) {
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Add(insecure.HeaderKey, "identity")
+
+	_, err = auth.Authenticate(req)
+	assert.Equal(t, ErrNoSignature, err)
+	assert.Equal(t, uint64(1), auth.Stats()["signatureFailures"])
+}
+
+func TestAuthenticateTamperedBody(t *testing.T) {
+	key, addr := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	// a modified body recovers a different address, so the
+	// request cannot be issued with the AAD of the signer
+	req := newRequest(t, key, body)
+	req.Body = ioutil.NopCloser(strings.NewReader(body + " "))
+
+	req, err := auth.Authenticate(req)
+	assert.Nil(t, err)
+	assert.NotEqual(t, addr, core.MustGetAAD(req.Context()))
+}
+
+func TestAuthenticateInvalidSignature(t *testing.T) {
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Add(insecure.HeaderKey, "identity")
+	req.Header.Add(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Add(NonceHeader, "nonce")
+	req.Header.Add(SignatureHeader, "0x1234")
+
+	_, err = auth.Authenticate(req)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestAuthenticateStaleTimestamp(t *testing.T) {
+	key, _ := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+	auth.now = func() time.Time { return time.Now().Add(MaxSkew + time.Minute) }
+
+	_, err := auth.Authenticate(newRequest(t, key, body))
+	assert.Equal(t, ErrStaleTimestamp, err)
+}
+
+func TestAuthenticateReplayedNonce(t *testing.T) {
+	key, addr := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	req := newRequest(t, key, body)
+	replayed := req.Clone(context.Background())
+	replayed.Body = ioutil.NopCloser(strings.NewReader(body))
+
+	authenticated, err := auth.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, addr, core.MustGetAAD(authenticated.Context()))
+
+	_, err = auth.Authenticate(replayed)
+	assert.Equal(t, ErrReplayedNonce, err)
+	assert.Equal(t, uint64(1), auth.Stats()["signatureReplays"])
+}
+
+func TestAuthenticateReplayOtherIdentity(t *testing.T) {
+	key, addr := newKey(t)
+
+	// the signed request of the victim is captured and sent
+	// with the same signature headers by another client
+	victim := newRequest(t, key, body)
+	replay := func(identity, sessionKey string) *http.Request {
+		req := victim.Clone(context.Background())
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+		req.Header.Set(insecure.HeaderKey, identity)
+		req.Header.Set(core.RequestHeaderSessionKey, sessionKey)
+		return req
+	}
+
+	// once the request of the victim is seen its nonce is used
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+	_, err := auth.Authenticate(replay("identity", "session"))
+	assert.Nil(t, err)
+	_, err = auth.Authenticate(replay("attacker", "session"))
+	assert.Equal(t, ErrReplayedNonce, err)
+
+	// if the replay arrives first, the signature does not cover the
+	// identity or the session of the replayer, so the address of the
+	// victim is not recovered and its AAD cannot be used
+	for _, r := range []*http.Request{replay("attacker", "session"), replay("identity", "other")} {
+		auth = newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+		req, err := auth.Authenticate(r)
+		assert.Nil(t, err)
+		assert.NotEqual(t, addr, core.MustGetAAD(req.Context()))
+
+		err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(addr), Data: "0x00"})
+		assert.Equal(t, ErrAADMismatch, err)
+	}
+}
+
+func TestAuthenticateBodyTooLarge(t *testing.T) {
+	key, _ := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 16)
+
+	_, err := auth.Authenticate(newRequest(t, key, body))
+	assert.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestVerify(t *testing.T) {
+	key, addr := newKey(t)
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	req, err := auth.Authenticate(newRequest(t, key, body))
+	assert.Nil(t, err)
+
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(addr), Data: "0x00"})
+	assert.Nil(t, err)
+
+	// the AAD of another user cannot be used
+	_, other := newKey(t)
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(other), Data: "0x00"})
+	assert.Equal(t, ErrAADMismatch, err)
+}
+
+func TestVerifyNotAuthenticated(t *testing.T) {
+	auth := newAuth(context.Background(), insecure.InsecureAuth{}, 1024)
+
+	err := auth.Verify(context.Background(), core.AuthRequest{API: "Execute"})
+	assert.Error(t, err)
+}
+
+func TestVerifyApiKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signature")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keys.json")
+	err = ioutil.WriteFile(path, []byte(`{"keys":[`+
+		`{"hash":"`+apikey.HashKey("key1")+`","owner":"owner1","apis":["Execute"]},`+
+		`{"hash":"`+apikey.HashKey("key2")+`","owner":"owner2","apis":["Deploy"]}`+
+		`]}`), 0600)
+	assert.Nil(t, err)
+
+	store, err := apikey.NewStore(apikey.StoreProps{Path: path})
+	assert.Nil(t, err)
+	auth := newAuth(context.Background(), apikey.NewApiKeyAuth(store), 1024)
+
+	key, addr := newKey(t)
+	newApiKeyRequest := func(apiKey, owner string) *http.Request {
+		req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Add(apikey.ApiKeyHeader, apiKey)
+		sign(t, req, key, owner, body)
+		return req
+	}
+
+	req, err := auth.Authenticate(newApiKeyRequest("key1", "owner1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "owner1:"+addr, req.Context().Value(core.SessionIdentity{}))
+
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(addr), Data: "0x00"})
+	assert.Nil(t, err)
+
+	// the api key still restricts the APIs that can be used
+	req, err = auth.Authenticate(newApiKeyRequest("key2", "owner2"))
+	assert.Nil(t, err)
+
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(addr), Data: "0x00"})
+	assert.Equal(t, "api key is not allowed to use Execute", err.Error())
+}
+
+func TestVerifyHmac(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secret := bytes.Repeat([]byte{1}, 32)
+	keys, err := hmac.ReadKeys(strings.NewReader(`{"keys":[` +
+		`{"id":"client1","secret":"` + base64.StdEncoding.EncodeToString(secret) + `","apis":["Execute"]}` +
+		`]}`))
+	assert.Nil(t, err)
+
+	auth := newAuth(ctx, hmac.NewHmacAuth(hmac.Props{
+		Keys:         keys,
+		Nonces:       hmac.NewMQueueNonceCache(mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})),
+		MaxBodyBytes: 1024,
+	}), 1024)
+
+	key, addr := newKey(t)
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", strings.NewReader(body))
+	assert.Nil(t, err)
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Add(hmac.KeyIDHeader, "client1")
+	req.Header.Add(hmac.TimestampHeader, ts)
+	req.Header.Add(hmac.NonceHeader, "nonce1")
+	req.Header.Add(hmac.SignatureHeader, hmac.Sign(secret,
+		hmac.SigningString("POST", "/v0/api/service/execute", ts, "nonce1", []byte(body))))
+	sign(t, req, key, "client1", body)
+
+	req, err = auth.Authenticate(req)
+	assert.Nil(t, err)
+	assert.Equal(t, addr, core.MustGetAAD(req.Context()))
+	assert.Equal(t, "client1:"+addr, req.Context().Value(core.SessionIdentity{}))
+
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(addr), Data: "0x00"})
+	assert.Nil(t, err)
+
+	_, other := newKey(t)
+	err = auth.Verify(req.Context(), core.AuthRequest{API: "Execute", AAD: []byte(other), Data: "0x00"})
+	assert.Equal(t, ErrAADMismatch, err)
+}
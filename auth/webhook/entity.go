@@ -0,0 +1,91 @@
+package webhook
+
+const (
+	// DecisionAuthenticate is the type of the requests sent to
+	// authenticate an http request
+	DecisionAuthenticate = "authenticate"
+
+	// DecisionVerify is the type of the requests sent to verify
+	// that an authenticated issuer can perform a request
+	DecisionVerify = "verify"
+)
+
+// AuthenticateRequest is the request sent to the webhook to
+// authenticate an http request
+type AuthenticateRequest struct {
+	// Type is always DecisionAuthenticate
+	Type string `json:"type"`
+
+	// Method of the http request
+	Method string `json:"method"`
+
+	// Path of the http request
+	Path string `json:"path"`
+
+	// Headers are the headers of the http request that are
+	// forwarded to the webhook
+	Headers map[string]string `json:"headers"`
+}
+
+// AuthenticateResponse is the response expected from the webhook
+// to an AuthenticateRequest
+type AuthenticateResponse struct {
+	// Allow is true if the request is authenticated
+	Allow bool `json:"allow"`
+
+	// Reason explains why the request is denied
+	Reason string `json:"reason"`
+
+	// AAD of the authenticated issuer. Required if the
+	// request is allowed
+	AAD string `json:"aad"`
+
+	// SessionKey if set is used as the session key of the
+	// request instead of the session key header
+	SessionKey string `json:"sessionKey"`
+
+	// Tenant of the authenticated issuer, if any
+	Tenant string `json:"tenant"`
+
+	// Claims about the identity of the issuer that can be used
+	// by the authorization policy
+	Claims map[string]interface{} `json:"claims"`
+}
+
+// VerifyRequest is the request sent to the webhook to verify that
+// an authenticated issuer can perform a request
+type VerifyRequest struct {
+	// Type is always DecisionVerify
+	Type string `json:"type"`
+
+	// API of the request, Deploy or Execute
+	API string `json:"api"`
+
+	// Address of the service executed
+	Address string `json:"address"`
+
+	// AAD of the authenticated issuer
+	AAD string `json:"aad"`
+
+	// DataAAD is the hex encoded AAD extracted from the data
+	DataAAD string `json:"dataAad"`
+
+	// Data of the request
+	Data string `json:"data"`
+
+	// Tenant of the authenticated issuer, if any
+	Tenant string `json:"tenant"`
+
+	// Claims about the identity of the issuer
+	Claims map[string]interface{} `json:"claims"`
+}
+
+// VerifyResponse is the response expected from the webhook
+// to a VerifyRequest
+type VerifyResponse struct {
+	// Allow is true if the request is authorized
+	Allow bool `json:"allow"`
+
+	// Reason explains why the request is denied
+	Reason string `json:"reason"`
+}
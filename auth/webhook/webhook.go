@@ -0,0 +1,305 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderr "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/cache"
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// maxResponseBytes is the maximum size of a webhook response
+const maxResponseBytes = 1 << 16
+
+// FailureMode defines the decision taken when the webhook
+// cannot be reached or does not reply with a valid answer
+type FailureMode string
+
+const (
+	// FailClosed denies the request if the webhook fails
+	FailClosed FailureMode = "closed"
+
+	// FailOpen allows the request if the webhook fails. A request
+	// cannot be authenticated without the webhook, so it only
+	// applies to the verification of authenticated requests
+	FailOpen FailureMode = "open"
+)
+
+// ErrWebhookUnavailable is returned when the webhook fails and
+// the request is denied
+var ErrWebhookUnavailable = stderr.New("authorization webhook is unavailable")
+
+// Props are the properties used to create a WebhookAuth
+type Props struct {
+	// URL of the webhook
+	URL string
+
+	// Client used to send requests to the webhook. Defaults to
+	// a client with Timeout
+	Client *http.Client
+
+	// Timeout for each request to the webhook
+	Timeout time.Duration
+
+	// Headers are the names of the headers of the http request that
+	// are forwarded to the webhook on authentication
+	Headers []string
+
+	// FailureMode is the decision taken if the webhook fails.
+	// Defaults to FailClosed
+	FailureMode FailureMode
+
+	// CacheSize is the maximum number of decisions cached. If it
+	// is 0 decisions are not cached
+	CacheSize int
+
+	// CacheTTL is the time decisions are cached for
+	CacheTTL time.Duration
+
+	// Now returns the current time. Defaults to time.Now
+	Now func() time.Time
+}
+
+// WebhookAuth delegates the authentication and authorization of
+// requests to an external http service
+type WebhookAuth struct {
+	logger      log.Logger
+	url         string
+	client      *http.Client
+	headers     []string
+	failureMode FailureMode
+	cache       *cache.Cache
+	cacheTTL    time.Duration
+	now         func() time.Time
+
+	allowed  stats.Counter
+	denied   stats.Counter
+	failures stats.Counter
+}
+
+// NewWebhookAuth creates a new WebhookAuth
+func NewWebhookAuth(props Props) *WebhookAuth {
+	if len(props.URL) == 0 {
+		panic("URL must be set")
+	}
+
+	client := props.Client
+	if client == nil {
+		client = &http.Client{Timeout: props.Timeout}
+	}
+
+	failureMode := props.FailureMode
+	if len(failureMode) == 0 {
+		failureMode = FailClosed
+	}
+
+	now := props.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	a := &WebhookAuth{
+		url:         props.URL,
+		client:      client,
+		headers:     props.Headers,
+		failureMode: failureMode,
+		cacheTTL:    props.CacheTTL,
+		now:         now,
+	}
+
+	if props.CacheSize > 0 && props.CacheTTL > 0 {
+		a.cache = cache.New(cache.Props{MaxSize: props.CacheSize, Now: now})
+	}
+
+	return a
+}
+
+func (a *WebhookAuth) Name() string {
+	return "auth.webhook.WebhookAuth"
+}
+
+func (a *WebhookAuth) Stats() stats.Metrics {
+	metrics := stats.Metrics{
+		"webhookAllowed":  a.allowed.Value(),
+		"webhookDenied":   a.denied.Value(),
+		"webhookFailures": a.failures.Value(),
+	}
+
+	if a.cache != nil {
+		metrics["webhookCache"] = a.cache.Stats()
+	}
+
+	return metrics
+}
+
+// Authenticate sends the relevant data of the http request to the
+// webhook and sets the identity returned by the webhook
+func (a *WebhookAuth) Authenticate(req *http.Request) (*http.Request, error) {
+	headers := make(map[string]string)
+	for _, header := range a.headers {
+		if value := req.Header.Get(header); len(value) > 0 {
+			headers[header] = value
+		}
+	}
+
+	var res AuthenticateResponse
+	if err := a.decide(req.Context(), AuthenticateRequest{
+		Type:    DecisionAuthenticate,
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Headers: headers,
+	}, &res); err != nil {
+		// a request cannot be authenticated without the webhook
+		// regardless of the failure mode
+		return req, err
+	}
+
+	if !res.Allow {
+		return req, denied(res.Reason)
+	}
+	if len(res.AAD) == 0 {
+		a.failures.Incr()
+		return req, stderr.New("authorization webhook allowed request without aad")
+	}
+
+	ctx := context.WithValue(req.Context(), core.AAD{}, res.AAD)
+	if len(res.SessionKey) > 0 {
+		ctx = context.WithValue(ctx, core.SessionKey{}, res.SessionKey)
+	}
+	if len(res.Tenant) > 0 {
+		ctx = context.WithValue(ctx, core.Tenant{}, res.Tenant)
+	}
+	if res.Claims != nil {
+		ctx = context.WithValue(ctx, core.IdentityClaims{}, res.Claims)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// Verify sends the request to the webhook to verify that the
+// authenticated issuer can perform it. The AAD of an Execute request
+// is checked against the authenticated AAD before the webhook is
+// called, so that the failure mode never allows a mismatched AAD
+func (a *WebhookAuth) Verify(ctx context.Context, req core.AuthRequest) error {
+	aad, _ := ctx.Value(core.AAD{}).(string)
+	if req.API == "Execute" && string(req.AAD) != aad {
+		return stderr.New("AAD does not match")
+	}
+
+	var res VerifyResponse
+	if err := a.decide(ctx, VerifyRequest{
+		Type:    DecisionVerify,
+		API:     req.API,
+		Address: req.Address,
+		AAD:     aad,
+		DataAAD: hex.EncodeToString(req.AAD),
+		Data:    req.Data,
+		Tenant:  core.GetTenant(ctx),
+		Claims:  core.GetIdentityClaims(ctx),
+	}, &res); err != nil {
+		if a.failureMode == FailOpen {
+			return nil
+		}
+		return err
+	}
+
+	if !res.Allow {
+		return denied(res.Reason)
+	}
+
+	return nil
+}
+
+func denied(reason string) error {
+	if len(reason) == 0 {
+		return stderr.New("request denied by authorization webhook")
+	}
+	return fmt.Errorf("request denied by authorization webhook: %s", reason)
+}
+
+// decide sends the request to the webhook and decodes its response
+// into res. Responses are cached by the hash of the request
+func (a *WebhookAuth) decide(ctx context.Context, req interface{}, res interface{}) error {
+	p, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(p)
+	key := hex.EncodeToString(hash[:])
+
+	if a.cache != nil {
+		if cached, ok := a.cache.Get(key); ok {
+			return json.Unmarshal(cached.([]byte), res)
+		}
+	}
+
+	body, err := a.send(ctx, p)
+	if err == nil {
+		err = json.Unmarshal(body, res)
+	}
+	if err != nil {
+		a.failures.Incr()
+		if a.logger != nil {
+			a.logger.Warn(ctx, "authorization webhook failed", log.MapFields{
+				"call_type":    "WebhookFailure",
+				"failure_mode": string(a.failureMode),
+				"err":          err.Error(),
+			})
+		}
+		return ErrWebhookUnavailable
+	}
+
+	var decision struct {
+		Allow bool `json:"allow"`
+	}
+	_ = json.Unmarshal(body, &decision)
+	if decision.Allow {
+		a.allowed.Incr()
+	} else {
+		a.denied.Incr()
+	}
+
+	if a.cache != nil {
+		a.cache.Set(key, body, a.now().Add(a.cacheTTL))
+	}
+
+	return nil
+}
+
+func (a *WebhookAuth) send(ctx context.Context, p []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", a.url, bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		// drain the body so that the connection can be reused
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxResponseBytes))
+		return nil, fmt.Errorf("authorization webhook replied with status %d", res.StatusCode)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+}
+
+func (a *WebhookAuth) SetLogger(l log.Logger) {
+	a.logger = l.ForClass("auth/webhook", "WebhookAuth")
+}
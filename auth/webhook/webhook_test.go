@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oasislabs/oasis-gateway/auth/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type server struct {
+	*httptest.Server
+	calls int32
+}
+
+func newServer(t *testing.T, handler func(req map[string]interface{}) (int, interface{})) *server {
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.calls, 1)
+
+		var req map[string]interface{}
+		p, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Nil(t, json.Unmarshal(p, &req))
+
+		status, res := handler(req)
+		w.WriteHeader(status)
+		assert.Nil(t, json.NewEncoder(w).Encode(res))
+	}))
+	return s
+}
+
+func newRequest(t *testing.T, token string) *http.Request {
+	req, err := http.NewRequest("POST", "http://gateway.oasiscloud.io/v0/api/service/execute", nil)
+	assert.Nil(t, err)
+	req.Header.Add("Authorization", token)
+	req.Header.Add("Other", "value")
+	return req
+}
+
+func authenticateHandler(req map[string]interface{}) (int, interface{}) {
+	headers := req["headers"].(map[string]interface{})
+	if req["type"] != DecisionAuthenticate || headers["Authorization"] != "token" {
+		return http.StatusOK, AuthenticateResponse{Allow: false, Reason: "invalid token"}
+	}
+	if _, ok := headers["Other"]; ok {
+		return http.StatusOK, AuthenticateResponse{Allow: false, Reason: "header forwarded"}
+	}
+
+	return http.StatusOK, AuthenticateResponse{
+		Allow:      true,
+		AAD:        "user",
+		SessionKey: "session",
+		Tenant:     "tenant",
+		Claims:     map[string]interface{}{"role": "admin"},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newServer(t, authenticateHandler)
+	defer s.Close()
+
+	auth := NewWebhookAuth(Props{URL: s.URL, Timeout: time.Second, Headers: []string{"Authorization"}})
+
+	req, err := auth.Authenticate(newRequest(t, "token"))
+	assert.Nil(t, err)
+	assert.Equal(t, "user", req.Context().Value(core.AAD{}))
+	assert.Equal(t, "session", req.Context().Value(core.SessionKey{}))
+	assert.Equal(t, "tenant", core.GetTenant(req.Context()))
+	assert.Equal(t, "admin", core.GetIdentityClaims(req.Context())["role"])
+
+	_, err = auth.Authenticate(newRequest(t, "other"))
+	assert.Equal(t, "request denied by authorization webhook: invalid token", err.Error())
+	assert.Equal(t, uint64(1), auth.Stats()["webhookAllowed"])
+	assert.Equal(t, uint64(1), auth.Stats()["webhookDenied"])
+}
+
+func TestAuthenticateCache(t *testing.T) {
+	s := newServer(t, authenticateHandler)
+	defer s.Close()
+
+	now := time.Now()
+	auth := NewWebhookAuth(Props{
+		URL:       s.URL,
+		Timeout:   time.Second,
+		Headers:   []string{"Authorization"},
+		CacheSize: 10,
+		CacheTTL:  time.Minute,
+		Now:       func() time.Time { return now },
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := auth.Authenticate(newRequest(t, "token"))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&s.calls))
+
+	// the decision expires
+	now = now.Add(time.Minute)
+	_, err := auth.Authenticate(newRequest(t, "token"))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&s.calls))
+}
+
+func TestAuthenticateFailure(t *testing.T) {
+	s := newServer(t, func(req map[string]interface{}) (int, interface{}) {
+		return http.StatusInternalServerError, nil
+	})
+	defer s.Close()
+
+	// requests are never authenticated if the webhook fails
+	auth := NewWebhookAuth(Props{URL: s.URL, Timeout: time.Second, FailureMode: FailOpen})
+	_, err := auth.Authenticate(newRequest(t, "token"))
+	assert.Equal(t, ErrWebhookUnavailable, err)
+	assert.Equal(t, uint64(1), auth.Stats()["webhookFailures"])
+}
+
+func TestAuthenticateWithoutAAD(t *testing.T) {
+	s := newServer(t, func(req map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, AuthenticateResponse{Allow: true}
+	})
+	defer s.Close()
+
+	auth := NewWebhookAuth(Props{URL: s.URL, Timeout: time.Second})
+	_, err := auth.Authenticate(newRequest(t, "token"))
+	assert.Equal(t, "authorization webhook allowed request without aad", err.Error())
+}
+
+func TestVerify(t *testing.T) {
+	s := newServer(t, func(req map[string]interface{}) (int, interface{}) {
+		if req["type"] == DecisionVerify && req["aad"] == "user" && req["address"] == "0x01" &&
+			req["dataAad"] == "75736572" {
+			return http.StatusOK, VerifyResponse{Allow: true}
+		}
+		return http.StatusOK, VerifyResponse{Allow: false}
+	})
+	defer s.Close()
+
+	auth := NewWebhookAuth(Props{URL: s.URL, Timeout: time.Second})
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user")
+
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", Address: "0x01", AAD: []byte("user")}))
+	err := auth.Verify(ctx, core.AuthRequest{API: "Execute", Address: "0x02", AAD: []byte("user")})
+	assert.Equal(t, "request denied by authorization webhook", err.Error())
+}
+
+func TestVerifyTimeout(t *testing.T) {
+	done := make(chan struct{})
+	s := newServer(t, func(req map[string]interface{}) (int, interface{}) {
+		<-done
+		return http.StatusOK, VerifyResponse{Allow: false}
+	})
+	defer s.Close()
+	defer close(done)
+
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user")
+	req := core.AuthRequest{API: "Execute", Address: "0x01", AAD: []byte("user")}
+
+	auth := NewWebhookAuth(Props{URL: s.URL, Timeout: 10 * time.Millisecond, FailureMode: FailClosed})
+	assert.Equal(t, ErrWebhookUnavailable, auth.Verify(ctx, req))
+
+	auth = NewWebhookAuth(Props{URL: s.URL, Timeout: 10 * time.Millisecond, FailureMode: FailOpen})
+	assert.Nil(t, auth.Verify(ctx, req))
+}
+
+func TestVerifyUnavailableAADMismatch(t *testing.T) {
+	s := newServer(t, func(req map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, VerifyResponse{Allow: true}
+	})
+	url := s.URL
+	s.Close()
+
+	// the webhook is unreachable, but a request for the AAD of another
+	// user is denied even when the webhook fails open
+	auth := NewWebhookAuth(Props{URL: url, Timeout: 10 * time.Millisecond, FailureMode: FailOpen})
+	ctx := context.WithValue(context.Background(), core.AAD{}, "user")
+
+	err := auth.Verify(ctx, core.AuthRequest{API: "Execute", Address: "0x01", AAD: []byte("other")})
+	assert.Equal(t, "AAD does not match", err.Error())
+	assert.Nil(t, auth.Verify(ctx, core.AuthRequest{API: "Execute", Address: "0x01", AAD: []byte("user")}))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&s.calls))
+}
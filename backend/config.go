@@ -1,10 +1,22 @@
 package backend
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/oasislabs/oasis-gateway/config"
 	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/tx"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -77,12 +89,16 @@ type BackendConfig interface {
 }
 
 type EthereumConfig struct {
-	URL          string
-	WalletConfig WalletConfig
+	URL                string
+	WalletConfig       WalletConfig
+	GasConfig          GasConfig
+	ConfirmationConfig ConfirmationConfig
 }
 
 func (c *EthereumConfig) Log(fields log.Fields) {
 	fields.Add("eth.url", c.URL)
+	c.GasConfig.Log(fields)
+	c.ConfirmationConfig.Log(fields)
 }
 
 func (c *EthereumConfig) Configure(v *viper.Viper) error {
@@ -91,7 +107,15 @@ func (c *EthereumConfig) Configure(v *viper.Viper) error {
 		return errors.New("eth.url must be set")
 	}
 
-	return c.WalletConfig.Configure(v)
+	if err := c.WalletConfig.Configure(v); err != nil {
+		return err
+	}
+
+	if err := c.GasConfig.Configure(v); err != nil {
+		return err
+	}
+
+	return c.ConfirmationConfig.Configure(v)
 }
 
 func (c *EthereumConfig) ID() BackendProvider {
@@ -100,37 +124,913 @@ func (c *EthereumConfig) ID() BackendProvider {
 
 func (c *EthereumConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
 	cmd.PersistentFlags().String("eth.url", "", "url for the eth endpoint")
-	return c.WalletConfig.Bind(v, cmd)
+	if err := c.WalletConfig.Bind(v, cmd); err != nil {
+		return err
+	}
+
+	if err := c.GasConfig.Bind(v, cmd); err != nil {
+		return err
+	}
+
+	return c.ConfirmationConfig.Bind(v, cmd)
 }
 
 // WalletConfig holds the configuration of a single wallet
 type WalletConfig struct {
 	// PrivateKeys for the wallet
 	PrivateKeys []string
+
+	// KeystoreFiles are go-ethereum encrypted JSON keystore
+	// files with the private keys for the wallet
+	KeystoreFiles []string
+
+	// KeystoreDir is a directory with keystore files
+	KeystoreDir string
+
+	// PassphraseFile is the file with the passphrase
+	// of the keystore files
+	PassphraseFile string
+
+	// PassphraseEnv is the environment variable with the
+	// passphrase of the keystore files
+	PassphraseEnv string
+
+	// SignerURL is the URL of a remote signer that signs the
+	// transactions of accounts whose keys are not in the gateway
+	SignerURL string
+
+	// SignerAccounts are the accounts of the remote signer to
+	// use. If not set all the accounts of the signer are used
+	SignerAccounts []string
+
+	// SignerTimeout is the maximum time to wait for
+	// a response from the remote signer
+	SignerTimeout time.Duration
+
+	// HDMnemonicFile is the file with the BIP-39 mnemonic
+	// from which the keys of HD wallets are derived
+	HDMnemonicFile string
+
+	// HDPath is the derivation path of the HD wallets. The
+	// wallets are derived from the children of the path
+	HDPath accounts.DerivationPath
+
+	// HDCount is the number of HD wallets derived at startup
+	HDCount int
+
+	// MaxInFlight is the maximum number of transactions
+	// that each wallet can have in flight
+	MaxInFlight int
+
+	// Selection is the strategy used to choose the
+	// wallet that sends a transaction
+	Selection tx.SelectionStrategy
+
+	// MinBalance is the balance below which a wallet is
+	// not used. A value of 0 disables the threshold
+	MinBalance uint64
+
+	// TreasuryConfig is the configuration of the automatic
+	// top up of the wallets from a treasury wallet
+	TreasuryConfig TreasuryConfig
 }
 
 func (c *WalletConfig) Log(fields log.Fields) {
 	// do not log the private keys themselves
 	fields.Add("eth.wallet.private_keys", len(c.PrivateKeys))
+	fields.Add("eth.wallet.keystore_files", strings.Join(c.KeystoreFiles, ", "))
+	fields.Add("eth.wallet.keystore_dir", c.KeystoreDir)
+	fields.Add("eth.wallet.passphrase_file", c.PassphraseFile)
+	fields.Add("eth.wallet.passphrase_env", c.PassphraseEnv)
+	if len(c.SignerURL) > 0 {
+		fields.Add("eth.wallet.signer_url", c.SignerURL)
+		fields.Add("eth.wallet.signer_accounts", strings.Join(c.SignerAccounts, ", "))
+		fields.Add("eth.wallet.signer_timeout_ms", c.SignerTimeout.Milliseconds())
+	}
+	if len(c.HDMnemonicFile) > 0 {
+		// do not log the mnemonic itself
+		fields.Add("eth.wallet.hd.mnemonic_file", c.HDMnemonicFile)
+		fields.Add("eth.wallet.hd.path", c.HDPath.String())
+		fields.Add("eth.wallet.hd.count", c.HDCount)
+	}
+	fields.Add("eth.wallet.max_in_flight", c.MaxInFlight)
+	fields.Add("eth.wallet.selection", c.Selection)
+	fields.Add("eth.wallet.min_balance", c.MinBalance)
+	c.TreasuryConfig.Log(fields)
 }
 
 func (c *WalletConfig) Configure(v *viper.Viper) error {
 	c.PrivateKeys = v.GetStringSlice("eth.wallet.private_keys")
+	c.KeystoreFiles = v.GetStringSlice("eth.wallet.keystore_files")
+	c.KeystoreDir = v.GetString("eth.wallet.keystore_dir")
+	c.PassphraseFile = v.GetString("eth.wallet.passphrase_file")
+	c.PassphraseEnv = v.GetString("eth.wallet.passphrase_env")
+	c.SignerURL = v.GetString("eth.wallet.signer_url")
+	c.SignerAccounts = v.GetStringSlice("eth.wallet.signer_accounts")
+	c.HDMnemonicFile = v.GetString("eth.wallet.hd.mnemonic_file")
 
-	if len(c.PrivateKeys) == 0 {
-		return errors.New("eth.wallet.private_keys must be set")
+	if len(c.PrivateKeys) == 0 && len(c.KeystoreFiles) == 0 && len(c.KeystoreDir) == 0 &&
+		len(c.SignerURL) == 0 && len(c.HDMnemonicFile) == 0 {
+		return errors.New("eth.wallet.private_keys, eth.wallet.keystore_files, eth.wallet.keystore_dir, " +
+			"eth.wallet.signer_url or eth.wallet.hd.mnemonic_file must be set")
 	}
 
+	for _, account := range c.SignerAccounts {
+		if !common.IsHexAddress(account) {
+			return config.ErrInvalidValue{
+				Key:          "eth.wallet.signer_accounts",
+				InvalidValue: account,
+				Values:       []string{},
+			}
+		}
+	}
+
+	signerTimeout := v.GetInt64("eth.wallet.signer_timeout_ms")
+	if signerTimeout <= 0 {
+		return errors.New("eth.wallet.signer_timeout_ms must be greater than 0")
+	}
+	c.SignerTimeout = time.Duration(signerTimeout) * time.Millisecond
+
 	for _, key := range c.PrivateKeys {
 		if len(key) == 0 {
 			return errors.New("eth.wallet.private_keys cannot have empty keys")
 		}
 	}
 
+	for _, file := range c.KeystoreFiles {
+		if len(file) == 0 {
+			return errors.New("eth.wallet.keystore_files cannot have empty paths")
+		}
+	}
+
+	if err := c.TreasuryConfig.Configure(v); err != nil {
+		return err
+	}
+
+	if len(c.KeystoreFiles) > 0 || len(c.KeystoreDir) > 0 || len(c.TreasuryConfig.KeystoreFile) > 0 {
+		if len(c.PassphraseFile) == 0 && len(c.PassphraseEnv) == 0 {
+			return errors.New("eth.wallet.passphrase_file or eth.wallet.passphrase_env must be set " +
+				"if eth.wallet.keystore_files, eth.wallet.keystore_dir or eth.wallet.treasury.keystore_file is set")
+		}
+		if len(c.PassphraseFile) > 0 && len(c.PassphraseEnv) > 0 {
+			return errors.New("only one of eth.wallet.passphrase_file and eth.wallet.passphrase_env can be set")
+		}
+	}
+
+	if len(c.HDMnemonicFile) > 0 {
+		path, err := accounts.ParseDerivationPath(v.GetString("eth.wallet.hd.path"))
+		if err != nil {
+			return config.ErrInvalidValue{
+				Key:          "eth.wallet.hd.path",
+				InvalidValue: v.GetString("eth.wallet.hd.path"),
+				Values:       []string{},
+			}
+		}
+		c.HDPath = path
+
+		c.HDCount = v.GetInt("eth.wallet.hd.count")
+		if c.HDCount < 0 || c.HDCount > tx.MaxHDWallets {
+			return fmt.Errorf("eth.wallet.hd.count must be between 0 and %d", tx.MaxHDWallets)
+		}
+	}
+
+	c.MaxInFlight = v.GetInt("eth.wallet.max_in_flight")
+	if c.MaxInFlight < 1 {
+		return errors.New("eth.wallet.max_in_flight must be greater than 0")
+	}
+
+	c.Selection = tx.SelectionStrategy(v.GetString("eth.wallet.selection"))
+	switch c.Selection {
+	case tx.SelectRoundRobin, tx.SelectLeastPending, tx.SelectHighestBalance, tx.SelectStickyAAD:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "eth.wallet.selection",
+			InvalidValue: string(c.Selection),
+			Values: []string{string(tx.SelectRoundRobin), string(tx.SelectLeastPending),
+				string(tx.SelectHighestBalance), string(tx.SelectStickyAAD)},
+		}
+	}
+
+	c.MinBalance = v.GetUint64("eth.wallet.min_balance")
 	return nil
 }
 
 func (c *WalletConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
 	cmd.PersistentFlags().StringSlice("eth.wallet.private_keys", []string{}, "private keys for the wallet")
+	cmd.PersistentFlags().StringSlice("eth.wallet.keystore_files", []string{},
+		"go-ethereum encrypted JSON keystore files with the private keys for the wallet")
+	cmd.PersistentFlags().String("eth.wallet.keystore_dir", "",
+		"directory with go-ethereum encrypted JSON keystore files with the private keys for the wallet")
+	cmd.PersistentFlags().String("eth.wallet.passphrase_file", "",
+		"file with the passphrase of the keystore files")
+	cmd.PersistentFlags().String("eth.wallet.passphrase_env", "",
+		"environment variable with the passphrase of the keystore files")
+	cmd.PersistentFlags().String("eth.wallet.signer_url", "",
+		"URL of a remote signer with the Clef JSON-RPC API that signs the transactions of its accounts")
+	cmd.PersistentFlags().StringSlice("eth.wallet.signer_accounts", []string{},
+		"accounts of the remote signer used by the wallet. If not set all the accounts of the signer are used")
+	cmd.PersistentFlags().Int64("eth.wallet.signer_timeout_ms", 10000,
+		"maximum time to wait for a response from the remote signer")
+	cmd.PersistentFlags().String("eth.wallet.hd.mnemonic_file", "",
+		"file with the BIP-39 mnemonic from which the keys of HD wallets are derived")
+	cmd.PersistentFlags().String("eth.wallet.hd.path", tx.DefaultHDPath,
+		"derivation path of the HD wallets. The wallets are derived from the children of the path")
+	cmd.PersistentFlags().Int("eth.wallet.hd.count", 1,
+		"number of HD wallets derived at startup. More can be derived at runtime through the admin API")
+	cmd.PersistentFlags().Int("eth.wallet.max_in_flight", 1,
+		"maximum number of transactions that each wallet can have in flight. "+
+			"With a value greater than 1 transactions of the same wallet are pipelined with consecutive nonces")
+	cmd.PersistentFlags().String("eth.wallet.selection", string(tx.SelectLeastPending),
+		"strategy used to choose the wallet that sends a transaction. Options are "+
+			string(tx.SelectRoundRobin)+", "+string(tx.SelectLeastPending)+", "+
+			string(tx.SelectHighestBalance)+", "+string(tx.SelectStickyAAD)+".")
+	cmd.PersistentFlags().Uint64("eth.wallet.min_balance", 0,
+		"balance below which a wallet is not used to send transactions. 0 disables the threshold")
+	return c.TreasuryConfig.Bind(v, cmd)
+}
+
+// LoadPrivateKeys returns the private keys for the wallet, decrypting the
+// keystore files if any are set. The keys are only kept in memory
+func (c *WalletConfig) LoadPrivateKeys() ([]*ecdsa.PrivateKey, error) {
+	var privateKeys []*ecdsa.PrivateKey
+	for _, key := range c.PrivateKeys {
+		privateKey, err := crypto.HexToECDSA(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key with error %s", err.Error())
+		}
+
+		privateKeys = append(privateKeys, privateKey)
+	}
+
+	if len(c.KeystoreFiles) == 0 && len(c.KeystoreDir) == 0 {
+		return privateKeys, nil
+	}
+
+	passphrase, err := c.passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range c.KeystoreFiles {
+		privateKey, err := tx.DecryptKeystoreFile(file, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		privateKeys = append(privateKeys, privateKey)
+	}
+
+	if len(c.KeystoreDir) > 0 {
+		keys, err := tx.DecryptKeystoreDir(c.KeystoreDir, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		privateKeys = append(privateKeys, keys...)
+	}
+
+	return privateKeys, nil
+}
+
+func (c *WalletConfig) passphrase() (string, error) {
+	if len(c.PassphraseEnv) > 0 {
+		passphrase, ok := os.LookupEnv(c.PassphraseEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s set in eth.wallet.passphrase_env is not set", c.PassphraseEnv)
+		}
+
+		return passphrase, nil
+	}
+
+	data, err := ioutil.ReadFile(c.PassphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file with error %s", err.Error())
+	}
+
+	// only the first line is used as the passphrase, as go-ethereum
+	// does for its password files
+	line := strings.SplitN(string(data), "\n", 2)[0]
+	return strings.TrimRight(line, "\r"), nil
+}
+
+// TreasuryProps returns the tx.TreasuryProps defined by the configuration,
+// decrypting the keystore file of the treasury if one is set
+func (c *WalletConfig) TreasuryProps() (tx.TreasuryProps, error) {
+	t := &c.TreasuryConfig
+	if !t.Enabled {
+		return tx.TreasuryProps{}, nil
+	}
+
+	props := tx.TreasuryProps{
+		Threshold:   t.Threshold,
+		Target:      t.Target,
+		MaxTransfer: t.MaxTransfer,
+		DailyCap:    t.DailyCap,
+	}
+
+	if len(t.PrivateKey) > 0 {
+		privateKey, err := crypto.HexToECDSA(t.PrivateKey)
+		if err != nil {
+			return tx.TreasuryProps{}, fmt.Errorf("failed to read treasury private key with error %s", err.Error())
+		}
+
+		props.PrivateKey = privateKey
+		return props, nil
+	}
+
+	passphrase, err := c.passphrase()
+	if err != nil {
+		return tx.TreasuryProps{}, err
+	}
+
+	privateKey, err := tx.DecryptKeystoreFile(t.KeystoreFile, passphrase)
+	if err != nil {
+		return tx.TreasuryProps{}, err
+	}
+
+	props.PrivateKey = privateKey
+	return props, nil
+}
+
+// LoadHDWallet reads the mnemonic file if one is set and returns
+// the HDWallet for the path. The mnemonic is not kept in memory
+func (c *WalletConfig) LoadHDWallet() (*tx.HDWallet, error) {
+	if len(c.HDMnemonicFile) == 0 {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(c.HDMnemonicFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mnemonic file with error %s", err.Error())
+	}
+
+	wallet, err := tx.NewHDWallet(string(data), "", c.HDPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive HD wallet with error %s", err.Error())
+	}
+
+	return wallet, nil
+}
+
+// DialSigner connects to the remote signer if one is set
+// and returns the accounts of the signer to use
+func (c *WalletConfig) DialSigner(ctx context.Context) (tx.Signer, []common.Address, error) {
+	if len(c.SignerURL) == 0 {
+		return nil, nil, nil
+	}
+
+	signer, err := tx.DialRemoteSigner(ctx, tx.RemoteSignerProps{
+		URL:     c.SignerURL,
+		Timeout: c.SignerTimeout,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var accounts []common.Address
+	for _, account := range c.SignerAccounts {
+		accounts = append(accounts, common.HexToAddress(account))
+	}
+
+	return signer, accounts, nil
+}
+
+// SelectionProps returns the tx.SelectionProps defined by the configuration
+func (c *WalletConfig) SelectionProps() tx.SelectionProps {
+	props := tx.SelectionProps{Strategy: c.Selection}
+	if c.MinBalance > 0 {
+		props.MinBalance = new(big.Int).SetUint64(c.MinBalance)
+	}
+
+	return props
+}
+
+// TreasuryConfig holds the configuration of the treasury wallet that
+// tops up the wallets whose balance falls below the threshold
+type TreasuryConfig struct {
+	Enabled bool
+
+	// PrivateKey of the treasury wallet
+	PrivateKey string
+
+	// KeystoreFile is a keystore file with the private key of the
+	// treasury wallet, decrypted with the passphrase of the wallet
+	KeystoreFile string
+
+	// Threshold is the balance below which a wallet is topped up
+	Threshold *big.Int
+
+	// Target is the balance to which a wallet is topped up
+	Target *big.Int
+
+	// MaxTransfer is the maximum amount of a single transfer
+	MaxTransfer *big.Int
+
+	// DailyCap is the maximum amount transferred
+	// by the treasury in 24 hours
+	DailyCap *big.Int
+}
+
+func (c *TreasuryConfig) Log(fields log.Fields) {
+	fields.Add("eth.wallet.treasury.enabled", c.Enabled)
+	if !c.Enabled {
+		return
+	}
+
+	// do not log the private key itself
+	fields.Add("eth.wallet.treasury.keystore_file", c.KeystoreFile)
+	fields.Add("eth.wallet.treasury.threshold", c.Threshold.String())
+	fields.Add("eth.wallet.treasury.target", c.Target.String())
+	fields.Add("eth.wallet.treasury.max_transfer", c.MaxTransfer.String())
+	fields.Add("eth.wallet.treasury.daily_cap", c.DailyCap.String())
+}
+
+func (c *TreasuryConfig) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("eth.wallet.treasury.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	c.PrivateKey = v.GetString("eth.wallet.treasury.private_key")
+	c.KeystoreFile = v.GetString("eth.wallet.treasury.keystore_file")
+	if len(c.PrivateKey) == 0 && len(c.KeystoreFile) == 0 {
+		return errors.New("eth.wallet.treasury.private_key or eth.wallet.treasury.keystore_file must be set")
+	}
+	if len(c.PrivateKey) > 0 && len(c.KeystoreFile) > 0 {
+		return errors.New("only one of eth.wallet.treasury.private_key and eth.wallet.treasury.keystore_file can be set")
+	}
+
+	var err error
+	if c.Threshold, err = parseWei(v, "eth.wallet.treasury.threshold"); err != nil {
+		return err
+	}
+	if c.Threshold.Sign() == 0 {
+		return errors.New("eth.wallet.treasury.threshold must be greater than 0")
+	}
+
+	if c.Target, err = parseWei(v, "eth.wallet.treasury.target"); err != nil {
+		return err
+	}
+	if c.Target.Cmp(c.Threshold) <= 0 {
+		return errors.New("eth.wallet.treasury.target must be greater than eth.wallet.treasury.threshold")
+	}
+
+	if c.MaxTransfer, err = parseWei(v, "eth.wallet.treasury.max_transfer"); err != nil {
+		return err
+	}
+	if c.MaxTransfer.Sign() == 0 {
+		return errors.New("eth.wallet.treasury.max_transfer must be greater than 0")
+	}
+
+	if c.DailyCap, err = parseWei(v, "eth.wallet.treasury.daily_cap"); err != nil {
+		return err
+	}
+	if c.DailyCap.Cmp(c.MaxTransfer) < 0 {
+		return errors.New("eth.wallet.treasury.daily_cap must be at least eth.wallet.treasury.max_transfer")
+	}
+
 	return nil
 }
+
+func (c *TreasuryConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("eth.wallet.treasury.enabled", false,
+		"transfer funds from a treasury wallet to the wallets whose balance falls below eth.wallet.treasury.threshold")
+	cmd.PersistentFlags().String("eth.wallet.treasury.private_key", "",
+		"private key of the treasury wallet")
+	cmd.PersistentFlags().String("eth.wallet.treasury.keystore_file", "",
+		"go-ethereum encrypted JSON keystore file with the private key of the treasury wallet. "+
+			"It is decrypted with the passphrase of the wallet")
+	cmd.PersistentFlags().String("eth.wallet.treasury.threshold", "0",
+		"balance below which a wallet is topped up by the treasury")
+	cmd.PersistentFlags().String("eth.wallet.treasury.target", "0",
+		"balance to which a wallet is topped up by the treasury")
+	cmd.PersistentFlags().String("eth.wallet.treasury.max_transfer", "0",
+		"maximum amount of a single transfer of the treasury")
+	cmd.PersistentFlags().String("eth.wallet.treasury.daily_cap", "0",
+		"maximum amount transferred by the treasury in 24 hours")
+	return nil
+}
+
+// parseWei parses an amount of wei set as a base 10 integer, which
+// may be larger than what fits in a uint64
+func parseWei(v *viper.Viper, key string) (*big.Int, error) {
+	value := v.GetString(key)
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, config.ErrInvalidValue{
+			Key:          key,
+			InvalidValue: value,
+			Values:       []string{"a non negative integer amount of wei"},
+		}
+	}
+
+	return amount, nil
+}
+
+// GasConfig holds the configuration of the gas price
+// of the transactions sent by the wallets
+type GasConfig struct {
+	// Mode used to derive the gas price
+	Mode tx.GasPriceMode
+
+	// Price is the gas price of the fixed mode and the fallback
+	// of the percentile mode when recent blocks are empty
+	Price uint64
+
+	// Blocks is the number of recent blocks used by the
+	// percentile mode
+	Blocks uint64
+
+	// Percentile of the gas prices of the recent blocks
+	// used by the percentile mode
+	Percentile uint64
+
+	// MinPrice and MaxPrice bound the gas price. A value
+	// of 0 disables the bound
+	MinPrice uint64
+	MaxPrice uint64
+
+	// Multiplier applied to the derived gas price
+	Multiplier float64
+
+	// RefreshInterval is the time for which a derived
+	// gas price is reused
+	RefreshInterval time.Duration
+
+	// Type of the transactions sent by the wallets
+	Type tx.TransactionType
+
+	// FeeHistoryBlocks is the number of blocks requested from
+	// eth_feeHistory to derive the fees of dynamic fee transactions
+	FeeHistoryBlocks uint64
+
+	// PriorityFeePercentile is the percentile of the priority fees
+	// of each block used to derive the priority fee
+	PriorityFeePercentile float64
+
+	// BaseFeeMultiplier is applied to the base fee to derive the max fee
+	BaseFeeMultiplier float64
+
+	// MaxFee and MaxPriorityFee bound the fees of dynamic fee
+	// transactions. A value of 0 disables the bound
+	MaxFee         uint64
+	MaxPriorityFee uint64
+
+	// BumpConfig is the configuration of the rebroadcast of
+	// the transactions that are not mined
+	BumpConfig BumpConfig
+}
+
+func (c *GasConfig) Log(fields log.Fields) {
+	fields.Add("eth.gas.mode", c.Mode)
+	switch c.Mode {
+	case tx.GasPriceFixed:
+		fields.Add("eth.gas.price", c.Price)
+	case tx.GasPricePercentile:
+		fields.Add("eth.gas.price", c.Price)
+		fields.Add("eth.gas.blocks", c.Blocks)
+		fields.Add("eth.gas.percentile", c.Percentile)
+	}
+	fields.Add("eth.gas.min_price", c.MinPrice)
+	fields.Add("eth.gas.max_price", c.MaxPrice)
+	fields.Add("eth.gas.multiplier", c.Multiplier)
+	fields.Add("eth.gas.refresh_interval_ms", c.RefreshInterval.Milliseconds())
+	fields.Add("eth.gas.type", c.Type)
+	if c.Type == tx.TransactionDynamicFee {
+		fields.Add("eth.gas.fee_history_blocks", c.FeeHistoryBlocks)
+		fields.Add("eth.gas.priority_fee_percentile", c.PriorityFeePercentile)
+		fields.Add("eth.gas.base_fee_multiplier", c.BaseFeeMultiplier)
+		fields.Add("eth.gas.max_fee", c.MaxFee)
+		fields.Add("eth.gas.max_priority_fee", c.MaxPriorityFee)
+	}
+	c.BumpConfig.Log(fields)
+}
+
+func (c *GasConfig) Configure(v *viper.Viper) error {
+	c.Mode = tx.GasPriceMode(v.GetString("eth.gas.mode"))
+	switch c.Mode {
+	case tx.GasPriceFixed, tx.GasPriceNode, tx.GasPricePercentile:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "eth.gas.mode",
+			InvalidValue: string(c.Mode),
+			Values:       []string{string(tx.GasPriceFixed), string(tx.GasPriceNode), string(tx.GasPricePercentile)},
+		}
+	}
+
+	c.Price = v.GetUint64("eth.gas.price")
+	if c.Price == 0 && c.Mode != tx.GasPriceNode {
+		return errors.New("eth.gas.price must be set")
+	}
+
+	c.Blocks = v.GetUint64("eth.gas.blocks")
+	if c.Blocks == 0 && c.Mode == tx.GasPricePercentile {
+		return errors.New("eth.gas.blocks must be greater than 0")
+	}
+
+	c.Percentile = v.GetUint64("eth.gas.percentile")
+	if c.Percentile > 100 {
+		return errors.New("eth.gas.percentile must be between 0 and 100")
+	}
+
+	c.MinPrice = v.GetUint64("eth.gas.min_price")
+	c.MaxPrice = v.GetUint64("eth.gas.max_price")
+	if c.MaxPrice > 0 && c.MinPrice > c.MaxPrice {
+		return errors.New("eth.gas.min_price cannot be greater than eth.gas.max_price")
+	}
+
+	c.Multiplier = v.GetFloat64("eth.gas.multiplier")
+	if c.Multiplier <= 0 {
+		return errors.New("eth.gas.multiplier must be greater than 0")
+	}
+
+	refreshInterval := v.GetInt64("eth.gas.refresh_interval_ms")
+	if refreshInterval < 0 {
+		return errors.New("eth.gas.refresh_interval_ms cannot be negative")
+	}
+	c.RefreshInterval = time.Duration(refreshInterval) * time.Millisecond
+
+	c.Type = tx.TransactionType(v.GetString("eth.gas.type"))
+	switch c.Type {
+	case tx.TransactionLegacy, tx.TransactionDynamicFee:
+	default:
+		return config.ErrInvalidValue{
+			Key:          "eth.gas.type",
+			InvalidValue: string(c.Type),
+			Values:       []string{string(tx.TransactionLegacy), string(tx.TransactionDynamicFee)},
+		}
+	}
+
+	c.FeeHistoryBlocks = v.GetUint64("eth.gas.fee_history_blocks")
+	if c.FeeHistoryBlocks == 0 {
+		return errors.New("eth.gas.fee_history_blocks must be greater than 0")
+	}
+
+	c.PriorityFeePercentile = v.GetFloat64("eth.gas.priority_fee_percentile")
+	if c.PriorityFeePercentile < 0 || c.PriorityFeePercentile > 100 {
+		return errors.New("eth.gas.priority_fee_percentile must be between 0 and 100")
+	}
+
+	c.BaseFeeMultiplier = v.GetFloat64("eth.gas.base_fee_multiplier")
+	if c.BaseFeeMultiplier < 1 {
+		return errors.New("eth.gas.base_fee_multiplier cannot be less than 1")
+	}
+
+	c.MaxFee = v.GetUint64("eth.gas.max_fee")
+	c.MaxPriorityFee = v.GetUint64("eth.gas.max_priority_fee")
+	if c.MaxFee > 0 && c.MaxPriorityFee > c.MaxFee {
+		return errors.New("eth.gas.max_priority_fee cannot be greater than eth.gas.max_fee")
+	}
+
+	return c.BumpConfig.Configure(v)
+}
+
+func (c *GasConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().String("eth.gas.mode", string(tx.GasPriceFixed),
+		"mode used to derive the gas price of the transactions. Options are "+
+			string(tx.GasPriceFixed)+", "+string(tx.GasPriceNode)+", "+string(tx.GasPricePercentile)+".")
+	cmd.PersistentFlags().Uint64("eth.gas.price", uint64(tx.DefaultGasPrice),
+		"gas price used by the fixed mode and by the percentile mode when recent blocks have no transactions")
+	cmd.PersistentFlags().Uint64("eth.gas.blocks", 20,
+		"number of recent blocks inspected by the percentile mode")
+	cmd.PersistentFlags().Uint64("eth.gas.percentile", 60,
+		"percentile of the gas prices of the recent blocks used by the percentile mode")
+	cmd.PersistentFlags().Uint64("eth.gas.min_price", 0,
+		"lower bound of the gas price. 0 disables the bound")
+	cmd.PersistentFlags().Uint64("eth.gas.max_price", 0,
+		"upper bound of the gas price. 0 disables the bound")
+	cmd.PersistentFlags().Float64("eth.gas.multiplier", 1,
+		"multiplier applied to the derived gas price before the bounds")
+	cmd.PersistentFlags().Int64("eth.gas.refresh_interval_ms", 5000,
+		"time for which a derived gas price is reused before deriving it again")
+	cmd.PersistentFlags().String("eth.gas.type", string(tx.TransactionLegacy),
+		"type of the transactions. Options are "+string(tx.TransactionLegacy)+", "+string(tx.TransactionDynamicFee)+
+			". Dynamic fee transactions fall back to legacy transactions if the chain does not support base fees.")
+	cmd.PersistentFlags().Uint64("eth.gas.fee_history_blocks", 10,
+		"number of recent blocks requested from eth_feeHistory to derive the fees of dynamic fee transactions")
+	cmd.PersistentFlags().Float64("eth.gas.priority_fee_percentile", 50,
+		"percentile of the priority fees of each block used to derive the priority fee of dynamic fee transactions")
+	cmd.PersistentFlags().Float64("eth.gas.base_fee_multiplier", 2,
+		"multiplier applied to the base fee of the next block to derive the max fee of dynamic fee transactions")
+	cmd.PersistentFlags().Uint64("eth.gas.max_fee", 0,
+		"upper bound of the max fee of dynamic fee transactions. 0 disables the bound")
+	cmd.PersistentFlags().Uint64("eth.gas.max_priority_fee", 0,
+		"upper bound of the priority fee of dynamic fee transactions. 0 disables the bound")
+	return c.BumpConfig.Bind(v, cmd)
+}
+
+// Props returns the tx.GasPriceProps defined by the configuration
+func (c *GasConfig) Props() tx.GasPriceProps {
+	props := tx.GasPriceProps{
+		Mode:            c.Mode,
+		Price:           new(big.Int).SetUint64(c.Price),
+		Blocks:          c.Blocks,
+		Percentile:      c.Percentile,
+		Multiplier:      c.Multiplier,
+		RefreshInterval: c.RefreshInterval,
+	}
+
+	if c.MinPrice > 0 {
+		props.MinPrice = new(big.Int).SetUint64(c.MinPrice)
+	}
+	if c.MaxPrice > 0 {
+		props.MaxPrice = new(big.Int).SetUint64(c.MaxPrice)
+	}
+
+	return props
+}
+
+// FeeProps returns the tx.FeeProps defined by the configuration
+func (c *GasConfig) FeeProps() tx.FeeProps {
+	props := tx.FeeProps{
+		Type:              c.Type,
+		Blocks:            c.FeeHistoryBlocks,
+		Percentile:        c.PriorityFeePercentile,
+		BaseFeeMultiplier: c.BaseFeeMultiplier,
+	}
+
+	if c.MaxFee > 0 {
+		props.MaxFee = new(big.Int).SetUint64(c.MaxFee)
+	}
+	if c.MaxPriorityFee > 0 {
+		props.MaxPriorityFee = new(big.Int).SetUint64(c.MaxPriorityFee)
+	}
+
+	return props
+}
+
+// BumpConfig holds the configuration of the rebroadcast of the
+// transactions that are not mined. A transaction that is not mined
+// after the timeout is sent again with the same nonce and a bumped
+// gas price
+type BumpConfig struct {
+	Enabled bool
+
+	// Timeout is the time a transaction can be pending
+	// before it is sent again
+	Timeout time.Duration
+
+	// PollInterval is the time between requests for the
+	// receipt of an executed transaction
+	PollInterval time.Duration
+
+	// Percent by which the gas price is bumped
+	Percent uint64
+
+	// MaxPrice is the ceiling of the bumped gas price. A
+	// value of 0 disables the ceiling
+	MaxPrice uint64
+
+	// MaxBumps is the number of times a transaction is
+	// sent again before giving up
+	MaxBumps uint
+}
+
+func (c *BumpConfig) Log(fields log.Fields) {
+	fields.Add("eth.gas.bump.enabled", c.Enabled)
+	if !c.Enabled {
+		return
+	}
+
+	fields.Add("eth.gas.bump.timeout_ms", c.Timeout.Milliseconds())
+	fields.Add("eth.gas.bump.poll_interval_ms", c.PollInterval.Milliseconds())
+	fields.Add("eth.gas.bump.percent", c.Percent)
+	fields.Add("eth.gas.bump.max_price", c.MaxPrice)
+	fields.Add("eth.gas.bump.max_bumps", c.MaxBumps)
+}
+
+func (c *BumpConfig) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("eth.gas.bump.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	timeout := v.GetInt64("eth.gas.bump.timeout_ms")
+	if timeout <= 0 {
+		return errors.New("eth.gas.bump.timeout_ms must be greater than 0")
+	}
+	c.Timeout = time.Duration(timeout) * time.Millisecond
+
+	pollInterval := v.GetInt64("eth.gas.bump.poll_interval_ms")
+	if pollInterval <= 0 {
+		return errors.New("eth.gas.bump.poll_interval_ms must be greater than 0")
+	}
+	c.PollInterval = time.Duration(pollInterval) * time.Millisecond
+
+	c.Percent = v.GetUint64("eth.gas.bump.percent")
+	if c.Percent == 0 {
+		return errors.New("eth.gas.bump.percent must be greater than 0")
+	}
+
+	c.MaxPrice = v.GetUint64("eth.gas.bump.max_price")
+	c.MaxBumps = v.GetUint("eth.gas.bump.max_bumps")
+	return nil
+}
+
+func (c *BumpConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("eth.gas.bump.enabled", false,
+		"send again with a bumped gas price the transactions that are not mined before eth.gas.bump.timeout_ms")
+	cmd.PersistentFlags().Int64("eth.gas.bump.timeout_ms", 60000,
+		"time a transaction can be pending before it is sent again with a bumped gas price")
+	cmd.PersistentFlags().Int64("eth.gas.bump.poll_interval_ms", 1000,
+		"time between requests for the receipt of an executed transaction")
+	cmd.PersistentFlags().Uint64("eth.gas.bump.percent", 12,
+		"percent by which the gas price is bumped. Nodes usually require at least 10 to replace a transaction")
+	cmd.PersistentFlags().Uint64("eth.gas.bump.max_price", 0,
+		"ceiling of the bumped gas price, or of the max fee of dynamic fee transactions. 0 disables the ceiling")
+	cmd.PersistentFlags().Uint("eth.gas.bump.max_bumps", 5,
+		"number of times a transaction is sent again before giving up with an error")
+	return nil
+}
+
+// Props returns the tx.BumpProps defined by the configuration
+func (c *BumpConfig) Props() tx.BumpProps {
+	if !c.Enabled {
+		return tx.BumpProps{}
+	}
+
+	props := tx.BumpProps{
+		Timeout:      c.Timeout,
+		PollInterval: c.PollInterval,
+		Percent:      c.Percent,
+		MaxBumps:     c.MaxBumps,
+	}
+
+	if c.MaxPrice > 0 {
+		props.MaxPrice = new(big.Int).SetUint64(c.MaxPrice)
+	}
+
+	return props
+}
+
+// ConfirmationConfig holds the configuration of the number of blocks
+// that have to be mined on top of the block of a transaction before
+// its result is reported
+type ConfirmationConfig struct {
+	// Depth is the number of blocks mined after the block of a
+	// transaction. A value of 0 reports the result as soon as
+	// the transaction has a receipt
+	Depth uint64
+
+	// PollInterval is the time between checks of the
+	// block of a transaction
+	PollInterval time.Duration
+
+	// Timeout is the maximum time to wait for
+	// the confirmations of a transaction
+	Timeout time.Duration
+}
+
+func (c *ConfirmationConfig) Log(fields log.Fields) {
+	fields.Add("eth.confirmation.depth", c.Depth)
+	if c.Depth == 0 {
+		return
+	}
+
+	fields.Add("eth.confirmation.poll_interval_ms", c.PollInterval.Milliseconds())
+	fields.Add("eth.confirmation.timeout_ms", c.Timeout.Milliseconds())
+}
+
+func (c *ConfirmationConfig) Configure(v *viper.Viper) error {
+	c.Depth = v.GetUint64("eth.confirmation.depth")
+	if c.Depth == 0 {
+		return nil
+	}
+
+	pollInterval := v.GetInt64("eth.confirmation.poll_interval_ms")
+	if pollInterval <= 0 {
+		return errors.New("eth.confirmation.poll_interval_ms must be greater than 0")
+	}
+	c.PollInterval = time.Duration(pollInterval) * time.Millisecond
+
+	timeout := v.GetInt64("eth.confirmation.timeout_ms")
+	if timeout <= 0 {
+		return errors.New("eth.confirmation.timeout_ms must be greater than 0")
+	}
+	c.Timeout = time.Duration(timeout) * time.Millisecond
+
+	return nil
+}
+
+func (c *ConfirmationConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Uint64("eth.confirmation.depth", 0,
+		"number of blocks mined after the block of a transaction before its result is reported. 0 reports it on the receipt")
+	cmd.PersistentFlags().Int64("eth.confirmation.poll_interval_ms", 1000,
+		"time between checks of the block of a transaction that is waiting for confirmations")
+	cmd.PersistentFlags().Int64("eth.confirmation.timeout_ms", 600000,
+		"maximum time to wait for the confirmations of a transaction before failing with an error")
+	return nil
+}
+
+// Props returns the tx.ConfirmationProps defined by the configuration
+func (c *ConfirmationConfig) Props() tx.ConfirmationProps {
+	if c.Depth == 0 {
+		return tx.ConfirmationProps{}
+	}
+
+	return tx.ConfirmationProps{
+		Depth:        c.Depth,
+		PollInterval: c.PollInterval,
+		Timeout:      c.Timeout,
+	}
+}
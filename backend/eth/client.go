@@ -49,6 +49,29 @@ type executeTransactionResponse struct {
 type ClientProps struct {
 	PrivateKeys []*ecdsa.PrivateKey
 	URL         string
+	GasPrice    tx.GasPriceProps
+	Fee         tx.FeeProps
+	MaxInFlight int
+	Bump        tx.BumpProps
+	Selection   tx.SelectionProps
+
+	// Signer signs the transactions of the SignerAccounts,
+	// whose private keys are not known by the client
+	Signer         tx.Signer
+	SignerAccounts []common.Address
+
+	// HDWallet derives the keys of HDCount wallets at startup,
+	// and of more wallets through DeriveWallets
+	HDWallet *tx.HDWallet
+	HDCount  int
+
+	// Treasury tops up the wallets whose balance
+	// falls below a threshold
+	Treasury tx.TreasuryProps
+
+	// Confirmation is the number of blocks to wait for
+	// before the result of a transaction is reported
+	Confirmation tx.ConfirmationProps
 }
 
 type Client struct {
@@ -74,7 +97,13 @@ func (c *Client) Stats() stats.Metrics {
 }
 
 func (c *Client) Senders() []common.Address {
-	return c.executor.WalletAddresses
+	return c.executor.Addresses()
+}
+
+// DeriveWallets raises the number of wallets derived from
+// the HD wallet to count and returns the new wallets
+func (c *Client) DeriveWallets(ctx context.Context, count int) ([]common.Address, errors.Err) {
+	return c.executor.DeriveWallets(ctx, count)
 }
 
 func (c *Client) getCode(
@@ -509,7 +538,20 @@ func DialContext(ctx context.Context, services *ClientServices, props *ClientPro
 		Logger:    services.Logger,
 		Client:    client,
 		Callbacks: services.Callbacks,
-	}, &tx.ExecutorProps{PrivateKeys: props.PrivateKeys})
+	}, &tx.ExecutorProps{
+		PrivateKeys:    props.PrivateKeys,
+		GasPrice:       props.GasPrice,
+		Fee:            props.Fee,
+		MaxInFlight:    props.MaxInFlight,
+		Bump:           props.Bump,
+		Selection:      props.Selection,
+		Signer:         props.Signer,
+		SignerAccounts: props.SignerAccounts,
+		HDWallet:       props.HDWallet,
+		HDCount:        props.HDCount,
+		Treasury:       props.Treasury,
+		Confirmation:   props.Confirmation,
+	})
 	if err != nil {
 		return nil, err
 	}
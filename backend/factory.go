@@ -2,10 +2,8 @@ package backend
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/oasislabs/oasis-gateway/backend/core"
 	"github.com/oasislabs/oasis-gateway/backend/eth"
 	callback "github.com/oasislabs/oasis-gateway/callback/client"
@@ -71,20 +69,40 @@ func NewEthClientWithDeps(ctx context.Context, deps *eth.ClientDeps) (*eth.Clien
 }
 
 func NewEthClient(ctx context.Context, services *eth.ClientServices, config *EthereumConfig) (*eth.Client, error) {
-	var privateKeys []*ecdsa.PrivateKey
+	privateKeys, err := config.WalletConfig.LoadPrivateKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	hdWallet, err := config.WalletConfig.LoadHDWallet()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, key := range config.WalletConfig.PrivateKeys {
-		privateKey, err := crypto.HexToECDSA(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read private key with error %s", err.Error())
-		}
+	treasury, err := config.WalletConfig.TreasuryProps()
+	if err != nil {
+		return nil, err
+	}
 
-		privateKeys = append(privateKeys, privateKey)
+	signer, signerAccounts, err := config.WalletConfig.DialSigner(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	client, err := eth.DialContext(ctx, services, &eth.ClientProps{
-		PrivateKeys: privateKeys,
-		URL:         config.URL,
+		PrivateKeys:    privateKeys,
+		URL:            config.URL,
+		GasPrice:       config.GasConfig.Props(),
+		Fee:            config.GasConfig.FeeProps(),
+		MaxInFlight:    config.WalletConfig.MaxInFlight,
+		Bump:           config.GasConfig.BumpConfig.Props(),
+		Selection:      config.WalletConfig.SelectionProps(),
+		Signer:         signer,
+		SignerAccounts: signerAccounts,
+		HDWallet:       hdWallet,
+		HDCount:        config.WalletConfig.HDCount,
+		Treasury:       treasury,
+		Confirmation:   config.ConfirmationConfig.Props(),
 	})
 
 	if err != nil {
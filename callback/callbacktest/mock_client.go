@@ -32,8 +32,16 @@ func (c *MockClient) WalletReachedFundsThreshold(
 	_ = c.Called(ctx, body)
 }
 
+func (c *MockClient) WalletToppedUp(
+	ctx context.Context,
+	body callback.WalletToppedUpBody,
+) {
+	_ = c.Called(ctx, body)
+}
+
 func ImplementMock(client *MockClient) {
 	client.On("TransactionCommitted", mock.Anything, mock.Anything).Return()
 	client.On("WalletOutOfFunds", mock.Anything, mock.Anything).Return()
 	client.On("WalletReachedFundsThreshold", mock.Anything, mock.Anything).Return()
+	client.On("WalletToppedUp", mock.Anything, mock.Anything).Return()
 }
@@ -31,6 +31,7 @@ type Calls interface {
 	TransactionCommitted(ctx context.Context, body TransactionCommittedBody)
 	WalletOutOfFunds(ctx context.Context, body WalletOutOfFundsBody)
 	WalletReachedFundsThreshold(ctx context.Context, body WalletReachedFundsThresholdBody)
+	WalletToppedUp(ctx context.Context, body WalletToppedUpBody)
 }
 
 // HttpClient is the basic interface for the
@@ -51,6 +52,7 @@ type Callbacks struct {
 	TransactionCommitted        Callback
 	WalletOutOfFunds            Callback
 	WalletReachedFundsThreshold WalletReachedFundsThresholdCallback
+	WalletToppedUp              Callback
 }
 
 // Services are services required by the client
@@ -292,6 +294,20 @@ func (c *Client) WalletReachedFundsThreshold(ctx context.Context, body WalletRea
 	}
 }
 
+// WalletToppedUp sends a callback that is triggered when the treasury
+// transfers funds to a wallet
+func (c *Client) WalletToppedUp(ctx context.Context, body WalletToppedUpBody) {
+	_ = c.Callback(ctx, &c.callbacks.WalletToppedUp, &CallbackProps{
+		Body: WalletToppedUpRequest{
+			Address:  body.Address,
+			Treasury: body.Treasury,
+			Hash:     body.Hash,
+			Amount:   fmt.Sprintf("0x%x", body.Amount),
+			Before:   fmt.Sprintf("0x%x", body.Before),
+		},
+	})
+}
+
 // TransactionCommitted sends a callback that is triggered when a
 // transaction has been committed to the blockchain
 func (c *Client) TransactionCommitted(ctx context.Context, body TransactionCommittedBody) {
@@ -311,3 +311,39 @@ func TestClientWalletReachedFundsThresholdOKNoCalled(t *testing.T) {
 
 	mockclient.AssertNotCalled(t, "Do", mock.Anything)
 }
+
+func TestClientWalletToppedUpOK(t *testing.T) {
+	bodyTmpl, err := template.New("WalletToppedUpBody").Parse(
+		"{\"address\": \"{{.Address}}\", \"amount\": \"{{.Amount}}\", \"before\": \"{{.Before}}\"}")
+	assert.Nil(t, err)
+
+	client := newClient()
+	client.callbacks.WalletToppedUp = Callback{
+		Enabled:    true,
+		Method:     http.MethodPost,
+		URL:        "http://localhost:1234/",
+		BodyFormat: bodyTmpl,
+		Sync:       true,
+	}
+	mockclient := client.client.(*MockHttpClient)
+
+	mockclient.On("Do", mock.Anything).
+		Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	client.WalletToppedUp(Context, WalletToppedUpBody{
+		Address:  "myAddress",
+		Treasury: "treasury",
+		Hash:     "hash",
+		Amount:   big.NewInt(16),
+		Before:   big.NewInt(1),
+	})
+
+	mockclient.AssertCalled(t, "Do", mock.MatchedBy(func(req *http.Request) bool {
+		v, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return false
+		}
+
+		return string(v) == "{\"address\": \"myAddress\", \"amount\": \"0x10\", \"before\": \"0x1\"}"
+	}))
+}
@@ -76,6 +76,34 @@ type WalletReachedFundsThresholdRequest struct {
 	Threshold string
 }
 
+// WalletToppedUpBody is the body sent on a WalletToppedUp
+// callback to the required endpoint
+type WalletToppedUpBody struct {
+	// Address is the address of the wallet that received the funds
+	Address string
+
+	// Treasury is the address of the wallet that sent the funds
+	Treasury string
+
+	// Hash is the hash of the transfer transaction
+	Hash string
+
+	// Amount transferred to the wallet
+	Amount *big.Int
+
+	// Before is the balance of the wallet before the transfer
+	Before *big.Int
+}
+
+// WalletToppedUpRequest is the request sent on the callback
+type WalletToppedUpRequest struct {
+	Address  string
+	Treasury string
+	Hash     string
+	Amount   string
+	Before   string
+}
+
 // TransactionCommittedBody is the body sent on a TransactionCommitted
 // callback to the required endpoint
 type TransactionCommittedBody struct {
@@ -201,6 +201,63 @@ func (c *WalletReachedFundsThreshold) Log(fields log.Fields) {
 	fields.Add("callback.wallet_reached_funds_threshold.sync", c.Sync)
 }
 
+type WalletToppedUp struct {
+	Callback
+}
+
+func (c *WalletToppedUp) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("callback.wallet_topped_up.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	c.Method = v.GetString("callback.wallet_topped_up.method")
+	if len(c.Method) == 0 {
+		return config.ErrKeyNotSet{Key: "callback.wallet_topped_up.method"}
+	}
+
+	c.URL = v.GetString("callback.wallet_topped_up.url")
+	if len(c.URL) == 0 {
+		return config.ErrKeyNotSet{Key: "callback.wallet_topped_up.url"}
+	}
+
+	c.Body = v.GetString("callback.wallet_topped_up.body")
+	c.QueryURL = v.GetString("callback.wallet_topped_up.queryurl")
+	c.Headers = v.GetStringSlice("callback.wallet_topped_up.headers")
+	c.Sync = v.GetBool("callback.wallet_topped_up.sync")
+	return nil
+}
+
+func (c *WalletToppedUp) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("callback.wallet_topped_up.enabled", false,
+		"enables the wallet_topped_up callback. This callback will be sent by the "+
+			"gateway every time the treasury transfers funds to a wallet.")
+	cmd.PersistentFlags().String("callback.wallet_topped_up.method", "",
+		"http method on the request for the callback.")
+	cmd.PersistentFlags().String("callback.wallet_topped_up.url", "",
+		"http url for the callback.")
+	cmd.PersistentFlags().String("callback.wallet_topped_up.body", "",
+		"http body for the callback.")
+	cmd.PersistentFlags().String("callback.wallet_topped_up.queryurl", "",
+		"http query url for the callback.")
+	cmd.PersistentFlags().StringSlice("callback.wallet_topped_up.headers", nil,
+		"http headers for the callback.")
+	cmd.PersistentFlags().Bool("callback.wallet_topped_up.sync", false,
+		"whether to send the callback synchronously.")
+
+	return nil
+}
+
+func (c *WalletToppedUp) Log(fields log.Fields) {
+	fields.Add("callback.wallet_topped_up.enabled", c.Enabled)
+	fields.Add("callback.wallet_topped_up.method", c.Method)
+	fields.Add("callback.wallet_topped_up.url", c.URL)
+	fields.Add("callback.wallet_topped_up.body", c.Body)
+	fields.Add("callback.wallet_topped_up.queryurl", c.QueryURL)
+	fields.Add("callback.wallet_topped_up.headers", strings.Join(c.Headers, ","))
+	fields.Add("callback.wallet_topped_up.sync", c.Sync)
+}
+
 type Callback struct {
 	Enabled  bool
 	Sync     bool
@@ -215,6 +272,7 @@ type Config struct {
 	TransactionCommitted        TransactionCommitted
 	WalletOutOfFunds            WalletOutOfFunds
 	WalletReachedFundsThreshold WalletReachedFundsThreshold
+	WalletToppedUp              WalletToppedUp
 }
 
 func (c *Config) Configure(v *viper.Viper) error {
@@ -227,6 +285,9 @@ func (c *Config) Configure(v *viper.Viper) error {
 	if err := c.WalletReachedFundsThreshold.Configure(v); err != nil {
 		return err
 	}
+	if err := c.WalletToppedUp.Configure(v); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -240,6 +301,9 @@ func (c *Config) Bind(v *viper.Viper, cmd *cobra.Command) error {
 	if err := c.WalletReachedFundsThreshold.Bind(v, cmd); err != nil {
 		return err
 	}
+	if err := c.WalletToppedUp.Bind(v, cmd); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -247,4 +311,5 @@ func (c *Config) Log(fields log.Fields) {
 	c.TransactionCommitted.Log(fields)
 	c.WalletOutOfFunds.Log(fields)
 	c.WalletReachedFundsThreshold.Log(fields)
+	c.WalletToppedUp.Log(fields)
 }
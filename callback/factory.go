@@ -102,11 +102,19 @@ func NewClientWithDeps(ctx context.Context, deps *client.Deps, config *Config) (
 		return nil, err
 	}
 
+	walletToppedUp, err := parseCallback("WalletToppedUp", config.WalletToppedUp.Callback)
+	if err != nil {
+		return nil, err
+	}
+	// every transfer of the treasury is reported
+	walletToppedUp.PeriodLimit = 0
+
 	return client.NewClientWithDeps(deps, &client.Props{
 		Callbacks: client.Callbacks{
 			TransactionCommitted:        transactionCommitted,
 			WalletOutOfFunds:            walletOutOfFunds,
 			WalletReachedFundsThreshold: walletReachedFundsThreshold,
+			WalletToppedUp:              walletToppedUp,
 		},
 	}), nil
 }
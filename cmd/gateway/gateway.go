@@ -32,6 +32,18 @@ func publicServer(config *gateway.BindPublicConfig, router *rpc.HttpRouter) {
 	})
 
 	if config.HttpsEnabled {
+		tlsConfig, err := config.TlsConfig()
+		if err != nil {
+			gateway.RootLogger.Fatal(gateway.RootContext, "failed to load tls configuration", log.MapFields{
+				"call_type": "HttpPublicListenFailure",
+				"port":      httpPort,
+				"interface": httpInterface,
+				"err":       err.Error(),
+			})
+			os.Exit(1)
+		}
+		s.TLSConfig = tlsConfig
+
 		if err := s.ListenAndServeTLS(config.TlsCertificatePath, config.TlsPrivateKeyPath); err != nil {
 			gateway.RootLogger.Fatal(gateway.RootContext, "http server failed to listen", log.MapFields{
 				"call_type": "HttpPublicListenFailure",
@@ -74,6 +86,18 @@ func privateServer(config *gateway.BindPrivateConfig, router *rpc.HttpRouter) {
 	})
 
 	if config.HttpsEnabled {
+		tlsConfig, err := config.TlsConfig()
+		if err != nil {
+			gateway.RootLogger.Fatal(gateway.RootContext, "failed to load tls configuration", log.MapFields{
+				"call_type": "HttpPrivateListenFailure",
+				"port":      httpPort,
+				"interface": httpInterface,
+				"err":       err.Error(),
+			})
+			os.Exit(1)
+		}
+		s.TLSConfig = tlsConfig
+
 		if err := s.ListenAndServeTLS(config.TlsCertificatePath, config.TlsPrivateKeyPath); err != nil {
 			gateway.RootLogger.Fatal(gateway.RootContext, "http server failed to listen", log.MapFields{
 				"call_type": "HttpPrivateListenFailure",
@@ -104,16 +128,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	err = parser.Parse()
-	if err != nil {
-		fmt.Println("Failed to parse configuration: ", err.Error())
-		if err := parser.Usage(); err != nil {
-			panic("failed to print usage")
-		}
+	parser.AddCommand(newMQueueCommand())
+	if err := parser.Execute(func() error {
+		runGateway(parser.Config.(*gateway.Config))
+		return nil
+	}); err != nil {
 		os.Exit(1)
 	}
+}
 
-	config := parser.Config.(*gateway.Config)
+func runGateway(config *gateway.Config) {
 	gateway.InitLogger(&config.LoggingConfig)
 
 	gateway.RootLogger.Info(gateway.RootContext, "bind public configuration parsed", log.MapFields{
@@ -131,6 +155,9 @@ func main() {
 	gateway.RootLogger.Info(gateway.RootContext, "auth config configuration parsed", log.MapFields{
 		"callType": "AuthConfigParseSuccess",
 	}, &config.AuthConfig)
+	gateway.RootLogger.Info(gateway.RootContext, "admin config configuration parsed", log.MapFields{
+		"callType": "AdminConfigParseSuccess",
+	}, &config.AdminConfig)
 	gateway.RootLogger.Info(gateway.RootContext, "callback config configuration parsed", log.MapFields{
 		"callType": "CallbackConfigParseSuccess",
 	}, &config.CallbackConfig)
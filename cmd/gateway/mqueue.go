@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oasislabs/oasis-gateway/config"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue"
+	"github.com/oasislabs/oasis-gateway/mqueue/migrate"
+	"github.com/spf13/cobra"
+)
+
+type MigrateProps struct {
+	SourcePath      string
+	DestinationPath string
+	Prefix          string
+	DryRun          bool
+	Verify          bool
+}
+
+func loadMailboxConfig(path string) (mqueue.Config, error) {
+	var c mqueue.Config
+	if err := config.Load(path, &c); err != nil {
+		return c, fmt.Errorf("failed to load mailbox configuration from %s: %s", path, err.Error())
+	}
+
+	return c, nil
+}
+
+func runMigrate(props MigrateProps) error {
+	if len(props.SourcePath) == 0 || len(props.DestinationPath) == 0 {
+		return fmt.Errorf("source and destination must be set")
+	}
+
+	ctx := context.Background()
+	logger := log.NewLogrus(log.LogrusLoggerProperties{})
+
+	sourceConfig, err := loadMailboxConfig(props.SourcePath)
+	if err != nil {
+		return err
+	}
+
+	destinationConfig, err := loadMailboxConfig(props.DestinationPath)
+	if err != nil {
+		return err
+	}
+
+	source, err := mqueue.NewMailbox(ctx, mqueue.Services{Logger: logger}, &sourceConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create source mailbox: %s", err.Error())
+	}
+
+	destination, err := mqueue.NewMailbox(ctx, mqueue.Services{Logger: logger}, &destinationConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create destination mailbox: %s", err.Error())
+	}
+
+	report, err := migrate.NewMigrator(migrate.Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		Prefix:      props.Prefix,
+		DryRun:      props.DryRun,
+		Verify:      props.Verify,
+	}).Migrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Println("failed to serialize report to json: ", err)
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("failed to migrate %d queues", report.Failed)
+	}
+
+	return nil
+}
+
+func bindMigrate(cmd *cobra.Command) {
+	var props MigrateProps
+
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate queues between mailbox providers",
+		Long: "Copies all the queues along with their next offset and their " +
+			"elements from the source mailbox to the destination mailbox. " +
+			"Both mailboxes are configured with gateway configuration files.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(props)
+		},
+	}
+
+	migrateCmd.PersistentFlags().StringVar(
+		&props.SourcePath, "source", "", "configuration file with the mailbox to migrate from")
+	migrateCmd.PersistentFlags().StringVar(
+		&props.DestinationPath, "destination", "", "configuration file with the mailbox to migrate to")
+	migrateCmd.PersistentFlags().StringVar(
+		&props.Prefix, "prefix", "", "only migrate the queues whose key starts with prefix")
+	migrateCmd.PersistentFlags().BoolVar(
+		&props.DryRun, "dry-run", false, "report the queues to migrate without writing to the destination")
+	migrateCmd.PersistentFlags().BoolVar(
+		&props.Verify, "verify", false, "verify that the destination matches the source after migrating")
+
+	cmd.AddCommand(migrateCmd)
+}
+
+// newMQueueCommand creates the mqueue subcommands used to
+// operate on the mailboxes outside of the gateway service
+func newMQueueCommand() *cobra.Command {
+	var mqueueCmd = &cobra.Command{
+		Use:   "mqueue",
+		Short: "operations on the mailbox queues",
+	}
+
+	bindMigrate(mqueueCmd)
+	return mqueueCmd
+}
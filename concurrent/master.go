@@ -15,6 +15,10 @@ const (
 	stopping = 2
 )
 
+// ErrNoWorkers is returned when a request that needs to be handled
+// by any of the workers is issued and the master has no workers
+var ErrNoWorkers = stderr.New("no workers available to handle the execute request")
+
 func errorFromPanic(r interface{}) error {
 	switch x := r.(type) {
 	case string:
@@ -438,8 +442,9 @@ func (m *Master) handleWorkerRequest(req workerRequest) {
 
 func (m *Master) handleBroadcastRequest(req broadcastRequest) {
 	if len(m.workers) == 0 {
-		req.Out <- Response{Value: nil, Error: stderr.New("no workers available to handle the execute request")}
+		req.Out <- Response{Value: nil, Error: ErrNoWorkers}
 		close(req.Out)
+		return
 	}
 
 	count := int32(len(m.workers))
@@ -456,7 +461,7 @@ func (m *Master) handleBroadcastRequest(req broadcastRequest) {
 
 func (m *Master) handleExecuteRequest(req executeRequest) {
 	if len(m.workers) == 0 {
-		req.Out <- Response{Value: nil, Error: stderr.New("no workers available to handle the execute request")}
+		req.Out <- Response{Value: nil, Error: ErrNoWorkers}
 		close(req.Out)
 		return
 	}
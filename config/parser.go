@@ -33,6 +33,32 @@ func (p *Parser) Parse() error {
 		return ErrParseFlags{err}
 	}
 
+	return p.configure()
+}
+
+// AddCommand adds subcommands to the command line, which
+// are dispatched by Execute
+func (p *Parser) AddCommand(cmds ...*cobra.Command) {
+	p.cmd.AddCommand(cmds...)
+}
+
+// Execute parses the command line and runs the subcommand it selects.
+// If no subcommand is selected the configuration is parsed from the
+// flags and run is called
+func (p *Parser) Execute(run func() error) error {
+	p.cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := p.configure(); err != nil {
+			return err
+		}
+
+		return run()
+	}
+
+	p.cmd.SetArgs(os.Args[1:])
+	return p.cmd.Execute()
+}
+
+func (p *Parser) configure() error {
 	// keep file first so that any parameters read from the file are used
 	// as defaults for the other flags
 	var binders []Binder
@@ -80,3 +106,35 @@ func Generate(config Config) (*Parser, error) {
 
 	return &Parser{file: &file, Config: config, cmd: cmd, v: v}, nil
 }
+
+// Load configures the provided binders from the configuration file
+// found at path. Command line flags and environment variables are
+// ignored, so that multiple configurations can be loaded by the same
+// process, and keys not set in the file take their default values
+func Load(path string, binders ...Binder) error {
+	v := viper.New()
+	cmd := &cobra.Command{}
+
+	for _, c := range binders {
+		if err := c.Bind(v, cmd); err != nil {
+			return fmt.Errorf("failed to bind flags %s", err.Error())
+		}
+	}
+
+	if err := v.BindPFlags(cmd.PersistentFlags()); err != nil {
+		return fmt.Errorf("failed to bind flags %s", err.Error())
+	}
+
+	v.Set("config.path", path)
+	if err := (&ConfigFile{}).Configure(v); err != nil {
+		return err
+	}
+
+	for _, c := range binders {
+		if err := c.Configure(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -286,6 +286,54 @@ var (
 		desc:     "Internal Error. Please check the status of the service.",
 	}
 
+	ErrQueueKeys = ErrorCode{
+		category: InternalError,
+		code:     1045,
+		desc:     "Internal Error. Please check the status of the service.",
+	}
+
+	ErrQueueInspect = ErrorCode{
+		category: InternalError,
+		code:     1046,
+		desc:     "Internal Error. Please check the status of the service.",
+	}
+
+	ErrWalletBusy = ErrorCode{
+		category: InternalError,
+		code:     1047,
+		desc:     "Internal Error. Please check the status of the service.",
+	}
+
+	ErrTransactionStuck = ErrorCode{
+		category: InternalError,
+		code:     1048,
+		desc:     "Transaction was not mined after the maximum number of gas price bumps.",
+	}
+
+	ErrNoWalletAvailable = ErrorCode{
+		category: InternalError,
+		code:     1049,
+		desc:     "No wallet has enough balance to execute the transaction.",
+	}
+
+	ErrDeriveWallet = ErrorCode{
+		category: InternalError,
+		code:     1050,
+		desc:     "Internal Error. Please check the status of the service.",
+	}
+
+	ErrTransactionDropped = ErrorCode{
+		category: InternalError,
+		code:     1051,
+		desc:     "Transaction was dropped from the chain by a reorganization.",
+	}
+
+	ErrTransactionNotConfirmed = ErrorCode{
+		category: InternalError,
+		code:     1052,
+		desc:     "Transaction did not reach the required number of confirmations.",
+	}
+
 	ErrOutOfRange = ErrorCode{
 		category: InputError,
 		code:     2001,
@@ -424,6 +472,12 @@ var (
 		code:     7004,
 		desc:     "Failed to verify request.",
 	}
+
+	ErrAuthorizeRequest = ErrorCode{
+		category: AuthenticationError,
+		code:     7005,
+		desc:     "Request is not authorized to access the route.",
+	}
 )
 
 // Category defines error categories that logically group them. This classification
@@ -30,10 +30,16 @@ type Client interface {
 	GetPublicKey(context.Context, common.Address) (PublicKey, error)
 	NonceAt(context.Context, common.Address) (uint64, error)
 	SendTransaction(context.Context, *types.Transaction) (SendTransactionResponse, error)
+	SendRawTransaction(context.Context, []byte) (SendTransactionResponse, error)
 	SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error)
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionBlock(ctx context.Context, txHash common.Hash) (TransactionBlock, error)
 	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
 	GetCode(ctx context.Context, addr common.Address) (string, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*FeeHistory, error)
+	ChainID(ctx context.Context) (*big.Int, error)
 }
 
 type ethClient interface {
@@ -43,6 +49,8 @@ type ethClient interface {
 	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, c chan<- types.Log) (ethereum.Subscription, error)
 	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
 	CodeAt(ctx context.Context, addr common.Address, blockNumber *big.Int) ([]byte, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 	Close()
 }
 
@@ -188,6 +196,13 @@ func (c *PooledClient) SendTransaction(ctx context.Context, tx *types.Transactio
 		return SendTransactionResponse{}, stderr.Wrap(err, "Failed to encode transaction")
 	}
 
+	return c.SendRawTransaction(ctx, data)
+}
+
+// SendRawTransaction sends an already encoded and signed transaction.
+// It is used for the transaction types that cannot be represented
+// with types.Transaction
+func (c *PooledClient) SendRawTransaction(ctx context.Context, data []byte) (SendTransactionResponse, error) {
 	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
 		var res sendTransactionResponseDeserialize
 		if err := conn.rclient.CallContext(ctx, &res, "oasis_invoke", hexutil.Encode(data)); err != nil {
@@ -215,6 +230,40 @@ func (c *PooledClient) SendTransaction(ctx context.Context, tx *types.Transactio
 	}, err
 }
 
+// FeeHistory returns the base fees and the priority fees at the
+// provided percentiles of the latest blocks through eth_feeHistory
+func (c *PooledClient) FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*FeeHistory, error) {
+	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
+		var res feeHistoryDeserialize
+		err := conn.rclient.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint64(blocks), "latest", percentiles)
+		return res, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := v.(feeHistoryDeserialize)
+	return res.FeeHistory(), nil
+}
+
+// ChainID returns the chain ID used for replay protected
+// signatures through eth_chainId
+func (c *PooledClient) ChainID(ctx context.Context) (*big.Int, error) {
+	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
+		var id hexutil.Big
+		err := conn.rclient.CallContext(ctx, &id, "eth_chainId")
+		return id, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	id := v.(hexutil.Big)
+	return id.ToInt(), nil
+}
+
 func (c *PooledClient) GetCode(ctx context.Context, addr common.Address) (string, error) {
 	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
 		return conn.eclient.CodeAt(ctx, addr, nil)
@@ -227,6 +276,34 @@ func (c *PooledClient) GetCode(ctx context.Context, addr common.Address) (string
 	return hexutil.Encode(v.([]byte)), nil
 }
 
+// SuggestGasPrice returns the gas price suggested by the node
+// through eth_gasPrice
+func (c *PooledClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
+		return conn.eclient.SuggestGasPrice(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*big.Int), nil
+}
+
+// BlockByNumber returns the block with the provided number, or
+// the latest block if number is nil
+func (c *PooledClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
+		return conn.eclient.BlockByNumber(ctx, number)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*types.Block), nil
+}
+
 func (c *PooledClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
 		return conn.eclient.TransactionReceipt(ctx, txHash)
@@ -239,6 +316,34 @@ func (c *PooledClient) TransactionReceipt(ctx context.Context, txHash common.Has
 	return v.(*types.Receipt), nil
 }
 
+// TransactionBlock returns the block in which the transaction was
+// included through eth_getTransactionReceipt. It returns
+// ethereum.NotFound if the transaction is not in the chain
+func (c *PooledClient) TransactionBlock(ctx context.Context, txHash common.Hash) (TransactionBlock, error) {
+	v, err := c.request(ctx, func(conn *Conn) (interface{}, error) {
+		var res transactionBlockDeserialize
+		if err := conn.rclient.CallContext(ctx, &res, "eth_getTransactionReceipt", txHash); err != nil {
+			return nil, err
+		}
+
+		if res.BlockHash == nil || res.BlockNumber == nil {
+			return nil, concurrent.ErrCannotRecover{Cause: ethereum.NotFound}
+		}
+
+		return res, nil
+	})
+
+	if err != nil {
+		return TransactionBlock{}, err
+	}
+
+	res := v.(transactionBlockDeserialize)
+	return TransactionBlock{
+		Hash:   *res.BlockHash,
+		Number: uint64(*res.BlockNumber),
+	}, nil
+}
+
 func (c *PooledClient) SubscribeFilterLogs(
 	ctx context.Context,
 	q ethereum.FilterQuery,
@@ -14,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/oasislabs/oasis-gateway/concurrent"
+	stderr "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -100,6 +101,24 @@ func (c *mockEthClient) SubscribeFilterLogs(ctx context.Context, q ethereum.Filt
 	return args.Get(0).(ethereum.Subscription), nil
 }
 
+func (c *mockEthClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	args := c.Called(ctx)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*big.Int), nil
+}
+
+func (c *mockEthClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	args := c.Called(ctx, number)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*types.Block), nil
+}
+
 func (c *mockEthClient) Close() {
 	c.Called()
 }
@@ -209,3 +228,45 @@ func TestPooledClientSendTransactionCallErr(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "maximum number of attempts 10 reached; see cause for last error: error", err.Error())
 }
+
+func TestPooledClientTransactionBlockOK(t *testing.T) {
+	pool := mockPool{conn: &Conn{eclient: &mockEthClient{}, rclient: &mockRpcClient{}}}
+	c := NewPooledClient(PooledClientProps{
+		Pool:        pool,
+		RetryConfig: TestRetryConfig,
+	})
+
+	txHash := common.HexToHash("0x02")
+	blockHash := common.HexToHash("0x03")
+	blockNumber := hexutil.Uint64(16)
+
+	pool.conn.rclient.(*mockRpcClient).
+		On("CallContext", mock.Anything, mock.Anything, "eth_getTransactionReceipt", []interface{}{txHash}).
+		Run(func(args mock.Arguments) {
+			res := args[1].(*transactionBlockDeserialize)
+			res.BlockHash = &blockHash
+			res.BlockNumber = &blockNumber
+		}).
+		Return(nil)
+
+	block, err := c.TransactionBlock(context.Background(), txHash)
+	assert.Nil(t, err)
+	assert.Equal(t, TransactionBlock{Hash: blockHash, Number: 16}, block)
+}
+
+func TestPooledClientTransactionBlockNotFound(t *testing.T) {
+	pool := mockPool{conn: &Conn{eclient: &mockEthClient{}, rclient: &mockRpcClient{}}}
+	c := NewPooledClient(PooledClientProps{
+		Pool:        pool,
+		RetryConfig: TestRetryConfig,
+	})
+
+	pool.conn.rclient.(*mockRpcClient).
+		On("CallContext", mock.Anything, mock.Anything, "eth_getTransactionReceipt", mock.Anything).
+		Return(nil)
+
+	_, err := c.TransactionBlock(context.Background(), common.HexToHash("0x02"))
+	assert.Error(t, err)
+	assert.Equal(t, ethereum.NotFound, stderr.Cause(err))
+	pool.conn.rclient.(*mockRpcClient).AssertNumberOfCalls(t, "CallContext", 1)
+}
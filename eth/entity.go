@@ -1,5 +1,12 @@
 package eth
 
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
 type PublicKey struct {
 	Timestamp uint64 `json:"timestamp"`
 	PublicKey string `json:"public_key"`
@@ -17,3 +24,70 @@ type sendTransactionResponseDeserialize struct {
 	Status string `json:"status"`
 	Hash   string `json:"transactionHash"`
 }
+
+// FeeHistory is the response of eth_feeHistory
+type FeeHistory struct {
+	// OldestBlock is the number of the oldest block of the history
+	OldestBlock *big.Int
+
+	// BaseFee has the base fee of each block of the history and
+	// the base fee of the next block. It is empty if the chain
+	// does not support base fees
+	BaseFee []*big.Int
+
+	// GasUsedRatio is the ratio of gas used to the gas limit
+	// of each block of the history
+	GasUsedRatio []float64
+
+	// Reward has the priority fees at the requested
+	// percentiles for each block of the history
+	Reward [][]*big.Int
+}
+
+type feeHistoryDeserialize struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	Reward       [][]*hexutil.Big `json:"reward"`
+}
+
+func (h *feeHistoryDeserialize) FeeHistory() *FeeHistory {
+	history := &FeeHistory{
+		OldestBlock:  (*big.Int)(h.OldestBlock),
+		BaseFee:      make([]*big.Int, 0, len(h.BaseFee)),
+		GasUsedRatio: h.GasUsedRatio,
+		Reward:       make([][]*big.Int, 0, len(h.Reward)),
+	}
+
+	for _, fee := range h.BaseFee {
+		history.BaseFee = append(history.BaseFee, (*big.Int)(fee))
+	}
+
+	for _, rewards := range h.Reward {
+		values := make([]*big.Int, 0, len(rewards))
+		for _, reward := range rewards {
+			values = append(values, (*big.Int)(reward))
+		}
+		history.Reward = append(history.Reward, values)
+	}
+
+	return history
+}
+
+// TransactionBlock identifies the block in which
+// a transaction was included
+type TransactionBlock struct {
+	// Hash of the block
+	Hash common.Hash
+
+	// Number of the block
+	Number uint64
+}
+
+// transactionBlockDeserialize has the fields of a receipt that
+// identify the block of a transaction, which are not kept by
+// types.Receipt
+type transactionBlockDeserialize struct {
+	BlockHash   *common.Hash    `json:"blockHash"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+}
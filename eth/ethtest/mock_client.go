@@ -45,6 +45,15 @@ var DefaultMockMethods = map[string]MockMethod{
 			}, nil,
 		},
 	},
+	"TransactionBlock": {
+		Arguments: []interface{}{mock.Anything, mock.Anything},
+		Return: []interface{}{
+			eth.TransactionBlock{
+				Hash:   common.HexToHash("0x01"),
+				Number: 1,
+			}, nil,
+		},
+	},
 	"GetExpiry": {
 		Arguments: []interface{}{mock.Anything, mock.Anything},
 		Return:    []interface{}{uint64(123456789), nil},
@@ -69,6 +78,41 @@ var DefaultMockMethods = map[string]MockMethod{
 			}, nil,
 		},
 	},
+	"SuggestGasPrice": {
+		Arguments: []interface{}{mock.Anything},
+		Return:    []interface{}{big.NewInt(1000000000), nil},
+	},
+	"BlockByNumber": {
+		Arguments: []interface{}{mock.Anything, mock.Anything},
+		Return: []interface{}{
+			types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}), nil,
+		},
+	},
+	"SendRawTransaction": {
+		Arguments: []interface{}{mock.Anything, mock.Anything},
+		Return: []interface{}{
+			eth.SendTransactionResponse{
+				Status: 1,
+				Output: "0x73756363657373",
+				Hash:   "0x00000000000000000000000000000000000000000000000000000000000000000",
+			}, nil,
+		},
+	},
+	"FeeHistory": {
+		Arguments: []interface{}{mock.Anything, mock.Anything, mock.Anything},
+		Return: []interface{}{
+			&eth.FeeHistory{
+				OldestBlock:  big.NewInt(1),
+				BaseFee:      []*big.Int{big.NewInt(1000000000), big.NewInt(1000000000)},
+				GasUsedRatio: []float64{0.5},
+				Reward:       [][]*big.Int{{big.NewInt(100000000)}},
+			}, nil,
+		},
+	},
+	"ChainID": {
+		Arguments: []interface{}{mock.Anything},
+		Return:    []interface{}{big.NewInt(1), nil},
+	},
 	"SubscribeFilterLogs": {
 		Arguments: []interface{}{mock.Anything, mock.Anything, mock.Anything},
 		Return: []interface{}{
@@ -192,3 +236,45 @@ func (m *MockClient) TransactionReceipt(ctx context.Context, txHash common.Hash)
 	args := m.Called(ctx, txHash)
 	return args.Get(0).(*types.Receipt), args.Error(1)
 }
+
+func (m *MockClient) TransactionBlock(ctx context.Context, txHash common.Hash) (eth.TransactionBlock, error) {
+	args := m.Called(ctx, txHash)
+	return args.Get(0).(eth.TransactionBlock), args.Error(1)
+}
+
+func (m *MockClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	args := m.Called(ctx)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*big.Int), nil
+}
+
+func (m *MockClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	args := m.Called(ctx, number)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.Block), nil
+}
+
+func (m *MockClient) SendRawTransaction(ctx context.Context, data []byte) (eth.SendTransactionResponse, error) {
+	args := m.Called(ctx, data)
+	return args.Get(0).(eth.SendTransactionResponse), args.Error(1)
+}
+
+func (m *MockClient) FeeHistory(ctx context.Context, blocks uint64, percentiles []float64) (*eth.FeeHistory, error) {
+	args := m.Called(ctx, blocks, percentiles)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*eth.FeeHistory), nil
+}
+
+func (m *MockClient) ChainID(ctx context.Context) (*big.Int, error) {
+	args := m.Called(ctx)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*big.Int), nil
+}
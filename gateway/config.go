@@ -1,10 +1,14 @@
 package gateway
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"io/ioutil"
 	"math"
 
 	"github.com/oasislabs/oasis-gateway/auth"
+	"github.com/oasislabs/oasis-gateway/auth/admin"
 	"github.com/oasislabs/oasis-gateway/backend"
 	"github.com/oasislabs/oasis-gateway/callback"
 	"github.com/oasislabs/oasis-gateway/config"
@@ -22,6 +26,7 @@ type Config struct {
 	BackendConfig     backend.Config
 	MailboxConfig     mqueue.Config
 	AuthConfig        auth.Config
+	AdminConfig       admin.Config
 	CallbackConfig    callback.Config
 	LoggingConfig     LoggingConfig
 }
@@ -41,6 +46,7 @@ func (c *Config) Binders() []config.Binder {
 		&c.BackendConfig,
 		&c.MailboxConfig,
 		&c.AuthConfig,
+		&c.AdminConfig,
 		&c.CallbackConfig,
 		&c.LoggingConfig,
 	}
@@ -52,6 +58,7 @@ func (c *Config) Log(fields log.Fields) {
 	c.BackendConfig.Log(fields)
 	c.MailboxConfig.Log(fields)
 	c.AuthConfig.Log(fields)
+	c.AdminConfig.Log(fields)
 	c.CallbackConfig.Log(fields)
 	c.LoggingConfig.Log(fields)
 }
@@ -67,9 +74,23 @@ type BindConfig struct {
 	HttpsEnabled       bool
 	TlsCertificatePath string
 	TlsPrivateKeyPath  string
+	TlsClientCAPath    string
+	TlsClientAuth      string
 	MaxBodyBytes       uint
 }
 
+const (
+	// TlsClientAuthNone does not request a client certificate
+	TlsClientAuthNone = "none"
+
+	// TlsClientAuthRequest requests a client certificate and
+	// verifies it if the client provides one
+	TlsClientAuthRequest = "request"
+
+	// TlsClientAuthRequire requires a valid client certificate
+	TlsClientAuthRequire = "require"
+)
+
 func (c *BindConfig) Configure(prefix string, v *viper.Viper) error {
 	c.HttpInterface = v.GetString(prefix + ".http_interface")
 	if len(c.HttpInterface) == 0 {
@@ -112,9 +133,54 @@ func (c *BindConfig) Configure(prefix string, v *viper.Viper) error {
 		}
 	}
 
+	c.TlsClientCAPath = v.GetString(prefix + ".tls_client_ca_path")
+	c.TlsClientAuth = v.GetString(prefix + ".tls_client_auth")
+	switch c.TlsClientAuth {
+	case "", TlsClientAuthNone:
+		c.TlsClientAuth = TlsClientAuthNone
+	case TlsClientAuthRequest, TlsClientAuthRequire:
+		if !c.HttpsEnabled || len(c.TlsClientCAPath) == 0 {
+			return errors.New(prefix + ".https_enabled and " + prefix + ".tls_client_ca_path " +
+				"must be set if " + prefix + ".tls_client_auth is " + c.TlsClientAuth)
+		}
+	default:
+		return errors.New(prefix + ".tls_client_auth must be one of " +
+			TlsClientAuthNone + ", " + TlsClientAuthRequest + ", " + TlsClientAuthRequire)
+	}
+
 	return nil
 }
 
+// TlsConfig returns the TLS configuration for the server. The server
+// certificate is not part of the configuration. It returns nil if
+// client certificates are not verified
+func (c *BindConfig) TlsConfig() (*tls.Config, error) {
+	var clientAuth tls.ClientAuthType
+	switch c.TlsClientAuth {
+	case TlsClientAuthRequest:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case TlsClientAuthRequire:
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, nil
+	}
+
+	p, err := ioutil.ReadFile(c.TlsClientCAPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(p) {
+		return nil, errors.New("no certificates found in " + c.TlsClientCAPath)
+	}
+
+	return &tls.Config{
+		ClientAuth: clientAuth,
+		ClientCAs:  pool,
+	}, nil
+}
+
 func (c *BindConfig) Bind(prefix string, v *viper.Viper, cmd *cobra.Command) error {
 	cmd.PersistentFlags().String(prefix+".http_interface", "127.0.0.1",
 		"interface to bind for http")
@@ -137,6 +203,11 @@ func (c *BindConfig) Bind(prefix string, v *viper.Viper, cmd *cobra.Command) err
 		"", "path to the tls certificate for https")
 	cmd.PersistentFlags().String(prefix+".tls_private_key_path",
 		"", "path to the private key for https")
+	cmd.PersistentFlags().String(prefix+".tls_client_ca_path",
+		"", "path to the PEM encoded certificates of the CAs that issue client certificates")
+	cmd.PersistentFlags().String(prefix+".tls_client_auth", TlsClientAuthNone,
+		"whether client certificates are verified. Options are "+TlsClientAuthNone+
+			", "+TlsClientAuthRequest+" (verified if provided), "+TlsClientAuthRequire+".")
 
 	return nil
 }
@@ -156,6 +227,8 @@ func (c *BindPublicConfig) Log(fields log.Fields) {
 	fields.Add("bind_public.max_body_bytes", c.BindConfig.MaxBodyBytes)
 	fields.Add("bind_public.tls_certificate_path", c.BindConfig.TlsCertificatePath)
 	fields.Add("bind_public.tls_private_key_path", c.BindConfig.TlsPrivateKeyPath)
+	fields.Add("bind_public.tls_client_ca_path", c.BindConfig.TlsClientCAPath)
+	fields.Add("bind_public.tls_client_auth", c.BindConfig.TlsClientAuth)
 	fields.Add("bind_public.http_cors.enabled", c.HttpCorsPreProcessorProps.Enabled)
 	fields.Add("bind_public.http_cors.allowed_origins", c.HttpCorsPreProcessorProps.AllowedOrigins)
 	fields.Add("bind_public.http_cors.allowed_methods", c.HttpCorsPreProcessorProps.AllowedMethods)
@@ -206,6 +279,10 @@ func (c *BindPublicConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
 
 type BindPrivateConfig struct {
 	BindConfig
+
+	// MailboxInspection if set the private router serves the
+	// endpoints that return the contents of the mailbox
+	MailboxInspection bool
 }
 
 func (c *BindPrivateConfig) Log(fields log.Fields) {
@@ -218,6 +295,9 @@ func (c *BindPrivateConfig) Log(fields log.Fields) {
 	fields.Add("bind_private.https_enabled", c.BindConfig.HttpsEnabled)
 	fields.Add("bind_private.tls_certificate_path", c.BindConfig.TlsCertificatePath)
 	fields.Add("bind_private.tls_private_key_path", c.BindConfig.TlsPrivateKeyPath)
+	fields.Add("bind_private.tls_client_ca_path", c.BindConfig.TlsClientCAPath)
+	fields.Add("bind_private.tls_client_auth", c.BindConfig.TlsClientAuth)
+	fields.Add("bind_private.mailbox_inspection", c.MailboxInspection)
 }
 
 func (c *BindPrivateConfig) Name() string {
@@ -225,11 +305,22 @@ func (c *BindPrivateConfig) Name() string {
 }
 
 func (c *BindPrivateConfig) Configure(v *viper.Viper) error {
-	return c.BindConfig.Configure("bind_private", v)
+	if err := c.BindConfig.Configure("bind_private", v); err != nil {
+		return err
+	}
+
+	c.MailboxInspection = v.GetBool("bind_private.mailbox_inspection")
+	return nil
 }
 
 func (c *BindPrivateConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
-	return c.BindConfig.Bind("bind_private", v, cmd)
+	if err := c.BindConfig.Bind("bind_private", v, cmd); err != nil {
+		return err
+	}
+
+	cmd.PersistentFlags().Bool("bind_private.mailbox_inspection", false,
+		"if set the private router serves the endpoints that return the contents of the mailbox queues")
+	return nil
 }
 
 type LoggingConfig struct {
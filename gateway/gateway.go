@@ -6,8 +6,11 @@ import (
 	"github.com/oasislabs/oasis-gateway/api/v0/event"
 	"github.com/oasislabs/oasis-gateway/api/v0/health"
 	"github.com/oasislabs/oasis-gateway/api/v0/info"
+	"github.com/oasislabs/oasis-gateway/api/v0/mailbox"
 	"github.com/oasislabs/oasis-gateway/api/v0/service"
+	"github.com/oasislabs/oasis-gateway/api/v0/wallet"
 	"github.com/oasislabs/oasis-gateway/auth"
+	"github.com/oasislabs/oasis-gateway/auth/admin"
 	authcore "github.com/oasislabs/oasis-gateway/auth/core"
 	"github.com/oasislabs/oasis-gateway/backend"
 	backendcore "github.com/oasislabs/oasis-gateway/backend/core"
@@ -37,6 +40,10 @@ type ServiceGroup struct {
 	Request       *backendcore.RequestManager
 	Backend       backendcore.Client
 	Authenticator authcore.Auth
+
+	// Admin authenticates the requests to the private router. If
+	// nil the requests to the private router are not authenticated
+	Admin authcore.Auth
 }
 
 type ServiceFactories struct {
@@ -123,18 +130,27 @@ func NewServiceGroupWithFactories(ctx context.Context, config *Config, factories
 		return nil, err
 	}
 
-	authenticator, err := factories.AuthFactory.New(&config.AuthConfig)
+	authenticator, err := factories.AuthFactory.New(ctx, auth.Services{
+		Logger: RootLogger,
+		MQueue: mqueue,
+	}, &config.AuthConfig)
 	if err != nil {
 		return nil, err
 	}
 	authenticator.SetLogger(RootLogger)
 
+	adminAuth := admin.NewAuth(&config.AdminConfig)
+	if adminAuth != nil {
+		adminAuth.SetLogger(RootLogger)
+	}
+
 	return &ServiceGroup{
 		Mailbox:       mqueue,
 		Request:       request,
 		Backend:       client,
 		Authenticator: authenticator,
 		Callback:      callbacks,
+		Admin:         adminAuth,
 	}, nil
 }
 
@@ -170,22 +186,63 @@ func NewRouters(config *Config, group *ServiceGroup) *Routers {
 }
 
 func NewPrivateRouter(config *Config, services Services, group *ServiceGroup) *rpc.HttpRouter {
+	// without authentication the admin routes are only
+	// served if explicitly requested
+	adminRoutes := group.Admin != nil || config.AdminConfig.Insecure
+	if group.Admin == nil && adminRoutes {
+		RootLogger.Warn(RootContext, "requests to the private router are not authenticated", log.MapFields{
+			"call_type": "PrivateRouterAuthDisabled",
+		})
+	} else if group.Admin == nil {
+		RootLogger.Warn(RootContext, "admin routes of the private router are disabled because admin.provider is none", log.MapFields{
+			"call_type": "PrivateRouterAdminRoutesDisabled",
+		})
+	}
+
 	binder := rpc.NewHttpBinder(rpc.HttpBinderProperties{
 		Encoder: rpc.JsonEncoder{},
 		Logger:  RootLogger,
 		HandlerFactory: rpc.HttpHandlerFactoryFunc(func(factory rpc.EntityFactory, handler rpc.Handler) rpc.HttpMiddleware {
-			// TODO(stan): we may want to add some authentication mechanism
-			// to the private router
-			return rpc.NewHttpJsonHandler(rpc.HttpJsonHandlerProperties{
+			jsonHandler := rpc.NewHttpJsonHandler(rpc.HttpJsonHandlerProperties{
 				Limit:   config.BindPrivateConfig.MaxBodyBytes,
 				Handler: handler,
 				Logger:  RootLogger,
 				Factory: factory,
 			})
+
+			if group.Admin == nil {
+				return jsonHandler
+			}
+
+			return admin.NewHttpMiddlewareAdmin(admin.HttpMiddlewareAdminProps{
+				Auth:   group.Admin,
+				Roles:  config.AdminConfig.Roles,
+				Logger: RootLogger,
+			}, jsonHandler)
 		}),
 	})
 
 	health.BindHandler(&health.Deps{Collector: services}, binder)
+	if !adminRoutes {
+		return binder.Build()
+	}
+
+	// the mailbox contents are only exposed on request, since
+	// they are returned decrypted
+	if config.BindPrivateConfig.MailboxInspection {
+		mailbox.BindHandler(mailbox.Services{
+			Logger: RootLogger,
+			Client: group.Mailbox,
+		}, binder)
+	}
+
+	// only backends that manage their own wallets can derive new ones
+	if client, ok := group.Backend.(wallet.Client); ok {
+		wallet.BindHandler(wallet.Services{
+			Logger: RootLogger,
+			Client: client,
+		}, binder)
+	}
 
 	return binder.Build()
 }
@@ -202,7 +259,8 @@ func NewPublicRouter(config *Config, group *ServiceGroup) *rpc.HttpRouter {
 				Factory: factory,
 			})
 
-			return authcore.NewHttpMiddlewareAuth(group.Authenticator, RootLogger, jsonHandler)
+			return authcore.NewHttpMiddlewareAuthWithProps(group.Authenticator, RootLogger, jsonHandler,
+				authcore.HttpMiddlewareAuthProps{TenantPrefix: config.AuthConfig.TenantPrefix})
 		}),
 	})
 
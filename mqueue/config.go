@@ -2,10 +2,14 @@ package mqueue
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"os"
 	"strings"
 
 	"github.com/oasislabs/oasis-gateway/config"
 	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/encrypt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -23,16 +27,23 @@ func (m MailboxProvider) String() string {
 }
 
 type Config struct {
-	Provider      MailboxProvider
-	MailboxConfig MailboxConfig
+	Provider         MailboxProvider
+	Namespace        string
+	MailboxConfig    MailboxConfig
+	InstrumentConfig InstrumentConfig
+	EncryptConfig    EncryptConfig
 }
 
 func (c *Config) Log(fields log.Fields) {
 	fields.Add("mailbox.provider", c.Provider)
+	fields.Add("mailbox.namespace", c.Namespace)
 
 	if c.MailboxConfig != nil {
 		c.MailboxConfig.Log(fields)
 	}
+
+	c.InstrumentConfig.Log(fields)
+	c.EncryptConfig.Log(fields)
 }
 
 func (c *Config) Configure(v *viper.Viper) error {
@@ -41,6 +52,16 @@ func (c *Config) Configure(v *viper.Viper) error {
 		return config.ErrKeyNotSet{Key: "mailbox.provider"}
 	}
 
+	c.Namespace = v.GetString("mailbox.namespace")
+
+	if err := c.InstrumentConfig.Configure(v); err != nil {
+		return err
+	}
+
+	if err := c.EncryptConfig.Configure(v); err != nil {
+		return err
+	}
+
 	switch c.Provider {
 	case MailboxMem:
 		c.MailboxConfig = &MailboxMemConfig{}
@@ -70,6 +91,9 @@ func (c *Config) Bind(v *viper.Viper, cmd *cobra.Command) error {
 			"Options are "+string(MailboxMem)+
 			", "+string(MailboxRedisSingle)+
 			", "+string(MailboxRedisCluster)+".")
+	cmd.PersistentFlags().String("mailbox.namespace", "",
+		"namespace prepended to all the mailbox keys so that multiple "+
+			"deployments can share the same storage")
 
 	if err := (&MailboxRedisSingleConfig{}).Bind(v, cmd); err != nil {
 		return err
@@ -80,7 +104,132 @@ func (c *Config) Bind(v *viper.Viper, cmd *cobra.Command) error {
 	if err := (&MailboxMemConfig{}).Bind(v, cmd); err != nil {
 		return err
 	}
+	if err := (&InstrumentConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+	if err := (&EncryptConfig{}).Bind(v, cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InstrumentConfig defines whether the mailbox is wrapped so that
+// metrics are collected for all its operations, regardless
+// of the provider used
+type InstrumentConfig struct {
+	Enabled    bool
+	WindowSize uint32
+}
+
+func (c *InstrumentConfig) Log(fields log.Fields) {
+	fields.Add("mailbox.instrument.enabled", c.Enabled)
+	fields.Add("mailbox.instrument.window_size", c.WindowSize)
+}
+
+func (c *InstrumentConfig) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("mailbox.instrument.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	windowSize := v.GetInt64("mailbox.instrument.window_size")
+	if windowSize <= 0 || windowSize > math.MaxUint32 {
+		return config.ErrInvalidValue{
+			Key:          "mailbox.instrument.window_size",
+			InvalidValue: fmt.Sprintf("%d", windowSize),
+			Values:       []string{},
+		}
+	}
+
+	c.WindowSize = uint32(windowSize)
+	return nil
+}
+
+func (c *InstrumentConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("mailbox.instrument.enabled", false,
+		"if set the mailbox collects latencies and result counts for all "+
+			"its operations along with counts of reserved offsets and inserted elements, and queue depth gauges")
+	cmd.PersistentFlags().Uint32("mailbox.instrument.window_size", 64,
+		"number of samples kept to calculate the mailbox latencies and retrieved elements")
+	return nil
+}
+
+// EncryptConfig defines whether the values of the elements stored
+// in the mailbox are encrypted, regardless of the provider used
+type EncryptConfig struct {
+	Enabled bool
+	KeyID   string
+	Keys    map[string][]byte
+}
+
+func (c *EncryptConfig) Log(fields log.Fields) {
+	// do not log the keys themselves
+	fields.Add("mailbox.encrypt.enabled", c.Enabled)
+	fields.Add("mailbox.encrypt.key_id", c.KeyID)
+	fields.Add("mailbox.encrypt.keys", len(c.Keys))
+}
+
+func (c *EncryptConfig) Configure(v *viper.Viper) error {
+	c.Enabled = v.GetBool("mailbox.encrypt.enabled")
+	if !c.Enabled {
+		return nil
+	}
+
+	c.KeyID = v.GetString("mailbox.encrypt.key_id")
+	if len(c.KeyID) == 0 {
+		return config.ErrKeyNotSet{Key: "mailbox.encrypt.key_id"}
+	}
+
+	c.Keys = make(map[string][]byte)
+	for _, s := range v.GetStringSlice("mailbox.encrypt.keys") {
+		id, key, err := encrypt.ParseKey(s)
+		if err != nil {
+			return fmt.Errorf("mailbox.encrypt.keys has invalid key: %s", err.Error())
+		}
+		c.Keys[id] = key
+	}
+
+	if path := v.GetString("mailbox.encrypt.key_file"); len(path) > 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open mailbox.encrypt.key_file: %s", err.Error())
+		}
+		defer func() { _ = file.Close() }()
+
+		keys, err := encrypt.ReadKeys(file)
+		if err != nil {
+			return fmt.Errorf("failed to read mailbox.encrypt.key_file: %s", err.Error())
+		}
+
+		for id, key := range keys {
+			if _, ok := c.Keys[id]; ok {
+				return fmt.Errorf("mailbox.encrypt key id %s is defined more than once", id)
+			}
+			c.Keys[id] = key
+		}
+	}
+
+	if _, ok := c.Keys[c.KeyID]; !ok {
+		return config.ErrInvalidValue{
+			Key:          "mailbox.encrypt.key_id",
+			InvalidValue: c.KeyID,
+			Values:       []string{},
+		}
+	}
+
+	return nil
+}
 
+func (c *EncryptConfig) Bind(v *viper.Viper, cmd *cobra.Command) error {
+	cmd.PersistentFlags().Bool("mailbox.encrypt.enabled", false,
+		"if set the values of the elements stored in the mailbox are encrypted with AES-GCM")
+	cmd.PersistentFlags().String("mailbox.encrypt.key_id", "",
+		"id of the key used to encrypt new elements")
+	cmd.PersistentFlags().StringSlice("mailbox.encrypt.keys", []string{},
+		"keys used to encrypt and decrypt elements with the form <key id>:<base64 key>")
+	cmd.PersistentFlags().String("mailbox.encrypt.key_file", "",
+		"path to a file with a key per line with the form <key id>:<base64 key>")
 	return nil
 }
 
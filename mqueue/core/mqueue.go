@@ -102,6 +102,89 @@ type ExistsRequest struct {
 	Key string
 }
 
+// KeysRequest to request the keys of all the queues
+// that are currently stored
+type KeysRequest struct {
+	// Prefix if set only the keys that start with
+	// Prefix are returned
+	Prefix string
+}
+
+// RebaseRequest to create an empty queue whose
+// offsets start at the provided offset
+type RebaseRequest struct {
+	// Key unique identifier of the queue
+	Key string
+
+	// Next is the offset returned by the first
+	// call to Next on the queue
+	Next uint64
+}
+
+// KeyInfo describes a queue stored in the MQueue
+type KeyInfo struct {
+	// Key unique identifier of the queue
+	Key string
+
+	// Offset is the base offset of the window of elements
+	// kept by the queue
+	Offset uint64
+
+	// Next is the offset that will be returned by the next
+	// call to Next on the queue
+	Next uint64
+}
+
+// InspectRequest to request the state of a queue without
+// modifying it
+type InspectRequest struct {
+	// Key unique identifier of the queue
+	Key string
+
+	// Offset at which the returned slots start
+	Offset uint64
+
+	// Count is the maximum number of slots returned. If it is
+	// 0 only the summary of the queue is returned
+	Count uint
+}
+
+// Slot is the state of an offset in the window of a queue
+type Slot struct {
+	// Offset of the slot within the queue
+	Offset uint64
+
+	// Set is true if an element has been inserted at the offset
+	Set bool
+
+	// Discarded is true if the element has been discarded but
+	// the window has not slided past it yet
+	Discarded bool
+
+	// Element is the element inserted at the offset if Set is true
+	Element Element
+}
+
+// Inspection is the state of a queue
+type Inspection struct {
+	KeyInfo
+
+	// Elements is the number of slots in the window that are
+	// set and have not been discarded
+	Elements uint64
+
+	// Unset is the number of slots in the window that have been
+	// reserved but are neither set nor discarded
+	Unset uint64
+
+	// Discarded is the number of slots in the window that have
+	// been discarded
+	Discarded uint64
+
+	// Slots are the slots requested starting at the requested offset
+	Slots []Slot
+}
+
 // MQueue is an interface to a messaging queue service that
 // provides the basic operations for a simple publish
 // subscribe mechanism in which the clients manage the offsets
@@ -132,4 +215,15 @@ type MQueue interface {
 
 	// Exists returns true if the key exists
 	Exists(context.Context, ExistsRequest) (bool, error)
+
+	// Keys returns information about all the queues stored
+	Keys(context.Context, KeysRequest) ([]KeyInfo, error)
+
+	// Inspect returns the state of the queue without modifying it
+	Inspect(context.Context, InspectRequest) (Inspection, error)
+
+	// Rebase creates an empty queue whose offsets start at the
+	// provided offset, so that a queue can be recreated with the
+	// same offsets. It fails if the queue has reserved offsets
+	Rebase(context.Context, RebaseRequest) error
 }
@@ -0,0 +1,13 @@
+package core
+
+// NamespacePrefix returns the prefix that providers prepend to
+// the keys of the queues that belong to the namespace, so that
+// multiple deployments can share the same storage without
+// their keys colliding. An empty namespace has no prefix
+func NamespacePrefix(namespace string) string {
+	if len(namespace) == 0 {
+		return ""
+	}
+
+	return namespace + ":"
+}
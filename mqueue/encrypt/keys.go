@@ -0,0 +1,61 @@
+package encrypt
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseKey parses a key definition of the form <key id>:<base64 key>
+func ParseKey(s string) (string, []byte, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return "", nil, fmt.Errorf("key must have the form <key id>:<base64 key>")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode key %s: %s", parts[0], err.Error())
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+		return parts[0], key, nil
+	default:
+		return "", nil, fmt.Errorf("key %s must be 16, 24 or 32 bytes long", parts[0])
+	}
+}
+
+// ReadKeys reads the key definitions from r, one per line with
+// the form <key id>:<base64 key>. Empty lines and lines that
+// start with # are ignored
+func ReadKeys(r io.Reader) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, key, err := ParseKey(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("key id %s is defined more than once", id)
+		}
+
+		keys[id] = key
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,262 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/stats"
+	stderr "github.com/pkg/errors"
+)
+
+// envelopePrefix identifies the values that have been encrypted by
+// an MQueue. An encrypted value has the form
+// enc:v1:<key id>:<base64(nonce || ciphertext)>
+const envelopePrefix = "enc:v1:"
+
+var (
+	// ErrUnknownKeyID is returned when an element has been encrypted
+	// with a key that is not available
+	ErrUnknownKeyID = stderr.New("element encrypted with unknown key id")
+
+	// ErrMalformedEnvelope is returned when an encrypted element
+	// cannot be parsed
+	ErrMalformedEnvelope = stderr.New("malformed encrypted element")
+
+	// ErrDecrypt is returned when an element fails to be decrypted
+	// or authenticated
+	ErrDecrypt = stderr.New("failed to decrypt element")
+)
+
+// Props are the properties used to define the behaviour
+// of an encrypted MQueue
+type Props struct {
+	// Keys is the set of AES keys indexed by their key id that can
+	// be used to decrypt elements. Keys must be 16, 24 or 32 bytes long
+	Keys map[string][]byte
+
+	// KeyID is the id of the key used to encrypt new elements. It
+	// must be present in Keys
+	KeyID string
+}
+
+// MQueue is a decorator for any core.MQueue that encrypts the
+// value of the elements inserted with AES-GCM, and decrypts
+// them when they are retrieved. The key id is stored along
+// with each element so that the encryption key can be rotated
+// while keeping the older keys available for decryption.
+//
+// Values that were stored without encryption are returned
+// as they are, so that encryption can be enabled on a
+// queue that already holds elements
+type MQueue struct {
+	mqueue core.MQueue
+	keyID  string
+	aeads  map[string]cipher.AEAD
+
+	encrypted stats.Counter
+	decrypted stats.Counter
+	plaintext stats.Counter
+	failed    stats.Counter
+}
+
+// NewMQueue creates a new encrypted MQueue that wraps the
+// provided queue
+func NewMQueue(mqueue core.MQueue, props Props) (*MQueue, error) {
+	if mqueue == nil {
+		panic("mqueue must be set")
+	}
+
+	if _, ok := props.Keys[props.KeyID]; !ok {
+		return nil, stderr.Wrap(ErrUnknownKeyID, props.KeyID)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(props.Keys))
+	for id, key := range props.Keys {
+		if strings.Contains(id, ":") {
+			return nil, fmt.Errorf("key id %s cannot contain ':'", id)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher for key id %s: %s", id, err.Error())
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gcm for key id %s: %s", id, err.Error())
+		}
+
+		aeads[id] = aead
+	}
+
+	return &MQueue{
+		mqueue: mqueue,
+		keyID:  props.KeyID,
+		aeads:  aeads,
+	}, nil
+}
+
+// Name is the implementation of core.MQueue.Name for MQueue
+func (m *MQueue) Name() string {
+	return "mqueue.encrypt.MQueue"
+}
+
+// Stats is the implementation of core.MQueue.Stats for MQueue. It
+// includes the stats of the underlying queue
+func (m *MQueue) Stats() stats.Metrics {
+	return stats.Metrics{
+		"keyId":         m.keyID,
+		"encrypted":     m.encrypted.Value(),
+		"decrypted":     m.decrypted.Value(),
+		"plaintext":     m.plaintext.Value(),
+		"failed":        m.failed.Value(),
+		m.mqueue.Name(): m.mqueue.Stats(),
+	}
+}
+
+// additionalData binds the ciphertext to the queue and the
+// position of the element so that encrypted values cannot be
+// moved between queues or offsets
+func additionalData(key string, el core.Element) []byte {
+	return []byte(fmt.Sprintf("%s:%d:%s", key, el.Offset, el.Type))
+}
+
+func (m *MQueue) encrypt(key string, el core.Element) (string, error) {
+	aead := m.aeads[m.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(el.Value), additionalData(key, el))
+	return envelopePrefix + m.keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (m *MQueue) decrypt(key string, el core.Element) (string, error) {
+	if !strings.HasPrefix(el.Value, envelopePrefix) {
+		m.plaintext.Incr()
+		return el.Value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(el.Value, envelopePrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", ErrMalformedEnvelope
+	}
+
+	aead, ok := m.aeads[parts[0]]
+	if !ok {
+		return "", stderr.Wrap(ErrUnknownKeyID, parts[0])
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedEnvelope
+	}
+
+	nonce := sealed[:aead.NonceSize()]
+	p, err := aead.Open(nil, nonce, sealed[aead.NonceSize():], additionalData(key, el))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	m.decrypted.Incr()
+	return string(p), nil
+}
+
+// Insert is the implementation of core.MQueue.Insert for MQueue
+func (m *MQueue) Insert(ctx context.Context, req core.InsertRequest) error {
+	value, err := m.encrypt(req.Key, req.Element)
+	if err != nil {
+		m.failed.Incr()
+		return err
+	}
+
+	req.Element.Value = value
+	if err := m.mqueue.Insert(ctx, req); err != nil {
+		return err
+	}
+
+	m.encrypted.Incr()
+	return nil
+}
+
+// Retrieve is the implementation of core.MQueue.Retrieve for MQueue
+func (m *MQueue) Retrieve(ctx context.Context, req core.RetrieveRequest) (core.Elements, error) {
+	els, err := m.mqueue.Retrieve(ctx, req)
+	if err != nil {
+		return core.Elements{}, err
+	}
+
+	for i := range els.Elements {
+		value, err := m.decrypt(req.Key, els.Elements[i])
+		if err != nil {
+			m.failed.Incr()
+			return core.Elements{}, err
+		}
+
+		els.Elements[i].Value = value
+	}
+
+	return els, nil
+}
+
+// Discard is the implementation of core.MQueue.Discard for MQueue
+func (m *MQueue) Discard(ctx context.Context, req core.DiscardRequest) error {
+	return m.mqueue.Discard(ctx, req)
+}
+
+// Next is the implementation of core.MQueue.Next for MQueue
+func (m *MQueue) Next(ctx context.Context, req core.NextRequest) (uint64, error) {
+	return m.mqueue.Next(ctx, req)
+}
+
+// Remove is the implementation of core.MQueue.Remove for MQueue
+func (m *MQueue) Remove(ctx context.Context, req core.RemoveRequest) error {
+	return m.mqueue.Remove(ctx, req)
+}
+
+// Exists is the implementation of core.MQueue.Exists for MQueue
+func (m *MQueue) Exists(ctx context.Context, req core.ExistsRequest) (bool, error) {
+	return m.mqueue.Exists(ctx, req)
+}
+
+// Keys is the implementation of core.MQueue.Keys for MQueue
+func (m *MQueue) Keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	return m.mqueue.Keys(ctx, req)
+}
+
+// Inspect is the implementation of core.MQueue.Inspect for MQueue
+func (m *MQueue) Inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	inspection, err := m.mqueue.Inspect(ctx, req)
+	if err != nil {
+		return core.Inspection{}, err
+	}
+
+	for i := range inspection.Slots {
+		if !inspection.Slots[i].Set {
+			continue
+		}
+
+		value, err := m.decrypt(req.Key, inspection.Slots[i].Element)
+		if err != nil {
+			m.failed.Incr()
+			return core.Inspection{}, err
+		}
+
+		inspection.Slots[i].Element.Value = value
+	}
+
+	return inspection, nil
+}
+
+// Rebase is the implementation of core.MQueue.Rebase for MQueue
+func (m *MQueue) Rebase(ctx context.Context, req core.RebaseRequest) error {
+	return m.mqueue.Rebase(ctx, req)
+}
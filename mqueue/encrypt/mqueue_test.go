@@ -0,0 +1,131 @@
+package encrypt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/mqueue/mailboxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var ctx = context.Background()
+
+var (
+	key1 = []byte("0123456789abcdef")
+	key2 = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func newMQueue(t *testing.T, mailbox core.MQueue, keyID string) *MQueue {
+	m, err := NewMQueue(mailbox, Props{
+		Keys:  map[string][]byte{"key1": key1, "key2": key2},
+		KeyID: keyID,
+	})
+	assert.Nil(t, err)
+	return m
+}
+
+// insertAndCapture inserts an element and returns the element
+// that was passed to the underlying queue
+func insertAndCapture(t *testing.T, m *MQueue, mailbox *mailboxtest.Mailbox, el core.Element) core.Element {
+	var stored core.Element
+	mailbox.On("Insert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(core.InsertRequest).Element
+	}).Return(nil).Once()
+
+	assert.Nil(t, m.Insert(ctx, core.InsertRequest{Key: "key", Element: el}))
+	return stored
+}
+
+func TestNewMQueueUnknownKeyID(t *testing.T) {
+	_, err := NewMQueue(&mailboxtest.Mailbox{}, Props{
+		Keys:  map[string][]byte{"key1": key1},
+		KeyID: "key2",
+	})
+	assert.Error(t, err)
+}
+
+func TestNewMQueueInvalidKey(t *testing.T) {
+	_, err := NewMQueue(&mailboxtest.Mailbox{}, Props{
+		Keys:  map[string][]byte{"key1": []byte("short")},
+		KeyID: "key1",
+	})
+	assert.Error(t, err)
+}
+
+func TestMQueueInsertRetrieve(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	m := newMQueue(t, mailbox, "key1")
+
+	el := core.Element{Offset: 1, Type: "type", Value: "secret"}
+	stored := insertAndCapture(t, m, mailbox, el)
+	assert.True(t, strings.HasPrefix(stored.Value, "enc:v1:key1:"))
+	assert.NotContains(t, stored.Value, "secret")
+
+	mailbox.On("Retrieve", mock.Anything, mock.Anything).Return(core.Elements{
+		Offset:   1,
+		Elements: []core.Element{stored},
+	}, nil)
+
+	els, err := m.Retrieve(ctx, core.RetrieveRequest{Key: "key", Offset: 1, Count: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.Element{el}, els.Elements)
+}
+
+func TestMQueueRetrieveRotatedKey(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	el := core.Element{Offset: 1, Type: "type", Value: "secret"}
+	stored := insertAndCapture(t, newMQueue(t, mailbox, "key1"), mailbox, el)
+
+	mailbox.On("Retrieve", mock.Anything, mock.Anything).Return(core.Elements{
+		Offset:   1,
+		Elements: []core.Element{stored},
+	}, nil)
+
+	els, err := newMQueue(t, mailbox, "key2").Retrieve(ctx, core.RetrieveRequest{Key: "key", Offset: 1, Count: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.Element{el}, els.Elements)
+}
+
+func TestMQueueRetrievePlaintext(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	m := newMQueue(t, mailbox, "key1")
+	el := core.Element{Offset: 1, Type: "type", Value: "plain"}
+
+	mailbox.On("Retrieve", mock.Anything, mock.Anything).Return(core.Elements{
+		Offset:   1,
+		Elements: []core.Element{el},
+	}, nil)
+
+	els, err := m.Retrieve(ctx, core.RetrieveRequest{Key: "key", Offset: 1, Count: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.Element{el}, els.Elements)
+}
+
+func TestMQueueRetrieveOtherQueue(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	m := newMQueue(t, mailbox, "key1")
+	stored := insertAndCapture(t, m, mailbox, core.Element{Offset: 1, Type: "type", Value: "secret"})
+
+	mailbox.On("Retrieve", mock.Anything, mock.Anything).Return(core.Elements{
+		Offset:   1,
+		Elements: []core.Element{stored},
+	}, nil)
+
+	_, err := m.Retrieve(ctx, core.RetrieveRequest{Key: "other", Offset: 1, Count: 1})
+	assert.Equal(t, ErrDecrypt, err)
+}
+
+func TestReadKeys(t *testing.T) {
+	keys, err := ReadKeys(strings.NewReader(
+		"# current key\nkey1:MDEyMzQ1Njc4OWFiY2RlZg==\n\nkey2:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"key1": key1, "key2": key2}, keys)
+}
+
+func TestReadKeysInvalidLength(t *testing.T) {
+	_, err := ReadKeys(strings.NewReader("key1:c2hvcnQ=\n"))
+	assert.Error(t, err)
+}
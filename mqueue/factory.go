@@ -6,6 +6,8 @@ import (
 
 	"github.com/oasislabs/oasis-gateway/log"
 	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/mqueue/encrypt"
+	"github.com/oasislabs/oasis-gateway/mqueue/instrument"
 	"github.com/oasislabs/oasis-gateway/mqueue/mem"
 	"github.com/oasislabs/oasis-gateway/mqueue/redis"
 )
@@ -25,33 +27,62 @@ func (f MailboxFactoryFunc) New(ctx context.Context, services Services, config *
 }
 
 var NewMailbox = MailboxFactoryFunc(func(ctx context.Context, services Services, config *Config) (core.MQueue, error) {
+	m, err := newMailbox(ctx, services, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.EncryptConfig.Enabled {
+		m, err = encrypt.NewMQueue(m, encrypt.Props{
+			Keys:  config.EncryptConfig.Keys,
+			KeyID: config.EncryptConfig.KeyID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to start encrypted mqueue %s", err.Error())
+		}
+	}
+
+	if config.InstrumentConfig.Enabled {
+		return instrument.NewMQueue(m, instrument.Props{
+			WindowSize: config.InstrumentConfig.WindowSize,
+		}), nil
+	}
+
+	return m, nil
+})
+
+func newMailbox(ctx context.Context, services Services, config *Config) (core.MQueue, error) {
 	if config.MailboxConfig.ID() != config.Provider {
 		return nil, ErrBackendConfigConflict
 	}
 
 	switch config.MailboxConfig.ID() {
 	case MailboxRedisSingle:
-		return NewRedisSingleMailbox(ctx, services, config.MailboxConfig.(*MailboxRedisSingleConfig))
+		return NewRedisSingleMailbox(ctx, services, config.Namespace, config.MailboxConfig.(*MailboxRedisSingleConfig))
 	case MailboxRedisCluster:
-		return NewRedisClusterMailbox(ctx, services, config.MailboxConfig.(*MailboxRedisClusterConfig))
+		return NewRedisClusterMailbox(ctx, services, config.Namespace, config.MailboxConfig.(*MailboxRedisClusterConfig))
 	case MailboxMem:
 		return mem.NewServer(ctx, mem.Services{
 			Logger: services.Logger,
+		}, mem.Props{
+			Namespace: config.Namespace,
 		}), nil
 	default:
 		return nil, ErrUnknownBackend{Backend: config.MailboxConfig.ID().String()}
 	}
-})
+}
 
 func NewRedisSingleMailbox(
 	ctx context.Context,
 	services Services,
+	namespace string,
 	config *MailboxRedisSingleConfig,
 ) (core.MQueue, error) {
 	m, err := redis.NewSingleMQueue(redis.SingleInstanceProps{
 		Props: redis.Props{
-			Context: ctx,
-			Logger:  services.Logger,
+			Context:   ctx,
+			Logger:    services.Logger,
+			Namespace: namespace,
 		},
 		Addr: config.Addr,
 	})
@@ -64,12 +95,14 @@ func NewRedisSingleMailbox(
 func NewRedisClusterMailbox(
 	ctx context.Context,
 	services Services,
+	namespace string,
 	config *MailboxRedisClusterConfig,
 ) (core.MQueue, error) {
 	m, err := redis.NewClusterMQueue(redis.ClusterProps{
 		Props: redis.Props{
-			Context: ctx,
-			Logger:  services.Logger,
+			Context:   ctx,
+			Logger:    services.Logger,
+			Namespace: namespace,
 		},
 		Addrs: config.Addrs,
 	})
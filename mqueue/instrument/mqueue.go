@@ -0,0 +1,288 @@
+package instrument
+
+import (
+	"context"
+	"sync"
+
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+const (
+	insert   string = "insert"
+	retrieve string = "retrieve"
+	discard  string = "discard"
+	next     string = "next"
+	remove   string = "remove"
+	exists   string = "exists"
+	keys     string = "keys"
+	inspect  string = "inspect"
+	rebase   string = "rebase"
+)
+
+// Props are the properties used to define the behaviour
+// of an instrumented MQueue
+type Props struct {
+	// WindowSize is the number of samples kept to calculate
+	// latencies and the number of elements retrieved
+	WindowSize uint32
+}
+
+// MQueue is a decorator for any core.MQueue that keeps track
+// of the latencies and results of all the operations executed
+// on the underlying queue, along with the number of offsets
+// reserved, the elements inserted and the depth of the queues.
+// The depth of a queue is the number of slots in its window that
+// are reserved or set and have not been discarded. It is
+// incremented on Next and read back from the underlying queue on
+// Discard, so that changes made by other gateway instances are
+// picked up
+type MQueue struct {
+	mqueue  core.MQueue
+	tracker *stats.MethodTracker
+
+	// reserved counts the offsets returned by Next
+	reserved stats.Counter
+
+	// inserted counts the elements successfully inserted
+	inserted stats.Counter
+
+	// retrieved keeps a window of the number of elements returned
+	// on each call to Retrieve
+	retrieved *stats.IntWindow
+
+	// depths keeps a gauge with the depth of each queue that is
+	// not empty
+	mu     sync.Mutex
+	depths map[string]*stats.Gauge
+}
+
+// NewMQueue creates a new instrumented MQueue that wraps the
+// provided queue
+func NewMQueue(mqueue core.MQueue, props Props) *MQueue {
+	if mqueue == nil {
+		panic("mqueue must be set")
+	}
+
+	if props.WindowSize == 0 {
+		props.WindowSize = 64
+	}
+
+	return &MQueue{
+		mqueue: mqueue,
+		tracker: stats.NewMethodTrackerWithResult(&stats.MethodTrackerProps{
+			Methods:    []string{insert, retrieve, discard, next, remove, exists, keys, inspect, rebase},
+			Results:    []string{"ok", "error"},
+			WindowSize: props.WindowSize,
+		}),
+		retrieved: stats.NewIntWindow(props.WindowSize),
+		depths:    make(map[string]*stats.Gauge),
+	}
+}
+
+// Name is the implementation of core.MQueue.Name for MQueue
+func (m *MQueue) Name() string {
+	return "mqueue.instrument.MQueue"
+}
+
+// Stats is the implementation of core.MQueue.Stats for MQueue. It
+// includes the stats of the underlying queue
+func (m *MQueue) Stats() stats.Metrics {
+	return stats.Metrics{
+		"methods": m.tracker.Stats(),
+		"offsets": stats.Metrics{
+			"reserved": m.reserved.Value(),
+			"inserted": m.inserted.Value(),
+		},
+		"retrieved":     m.retrieved.Stats(),
+		"depth":         m.depthStats(),
+		m.mqueue.Name(): m.mqueue.Stats(),
+	}
+}
+
+// depthStats aggregates the depth of all the tracked queues. The
+// keys of the queues are not reported, since they are derived
+// from the session keys of the clients
+func (m *MQueue) depthStats() stats.Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total, max int64
+	for _, gauge := range m.depths {
+		depth := gauge.Value()
+		total += depth
+		if depth > max {
+			max = depth
+		}
+	}
+
+	return stats.Metrics{
+		"queues": len(m.depths),
+		"total":  total,
+		"max":    max,
+	}
+}
+
+// Depth returns the depth of the queue and true if the
+// queue is tracked. Empty queues are not tracked
+func (m *MQueue) Depth(key string) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gauge, ok := m.depths[key]
+	if !ok {
+		return 0, false
+	}
+
+	return gauge.Value(), true
+}
+
+// refreshDepth reads back the depth of the queue from the
+// underlying queue. A queue that is empty or cannot be
+// inspected stops being tracked until it is used again
+func (m *MQueue) refreshDepth(ctx context.Context, key string) {
+	inspection, err := m.mqueue.Inspect(ctx, core.InspectRequest{Key: key})
+	depth := int64(inspection.Elements + inspection.Unset)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil || depth == 0 {
+		delete(m.depths, key)
+		return
+	}
+
+	gauge, ok := m.depths[key]
+	if !ok {
+		gauge = &stats.Gauge{}
+		m.depths[key] = gauge
+	}
+	gauge.Set(depth)
+}
+
+// Insert is the implementation of core.MQueue.Insert for MQueue
+func (m *MQueue) Insert(ctx context.Context, req core.InsertRequest) error {
+	_, err := m.tracker.Instrument(insert, func() (interface{}, error) {
+		return nil, m.mqueue.Insert(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.inserted.Incr()
+	return nil
+}
+
+// Retrieve is the implementation of core.MQueue.Retrieve for MQueue
+func (m *MQueue) Retrieve(ctx context.Context, req core.RetrieveRequest) (core.Elements, error) {
+	v, err := m.tracker.Instrument(retrieve, func() (interface{}, error) {
+		return m.mqueue.Retrieve(ctx, req)
+	})
+	if err != nil {
+		return core.Elements{}, err
+	}
+
+	els := v.(core.Elements)
+	m.retrieved.Add(int64(len(els.Elements)))
+	return els, nil
+}
+
+// Discard is the implementation of core.MQueue.Discard for MQueue
+func (m *MQueue) Discard(ctx context.Context, req core.DiscardRequest) error {
+	_, err := m.tracker.Instrument(discard, func() (interface{}, error) {
+		return nil, m.mqueue.Discard(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.refreshDepth(ctx, req.Key)
+	return nil
+}
+
+// Next is the implementation of core.MQueue.Next for MQueue
+func (m *MQueue) Next(ctx context.Context, req core.NextRequest) (uint64, error) {
+	v, err := m.tracker.Instrument(next, func() (interface{}, error) {
+		return m.mqueue.Next(ctx, req)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.reserved.Incr()
+
+	// a reserved offset adds a slot to the window, so the depth
+	// only needs to be read back if the queue was not tracked
+	m.mu.Lock()
+	gauge, ok := m.depths[req.Key]
+	if ok {
+		gauge.Add(1)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		m.refreshDepth(ctx, req.Key)
+	}
+
+	return v.(uint64), nil
+}
+
+// Remove is the implementation of core.MQueue.Remove for MQueue
+func (m *MQueue) Remove(ctx context.Context, req core.RemoveRequest) error {
+	_, err := m.tracker.Instrument(remove, func() (interface{}, error) {
+		return nil, m.mqueue.Remove(ctx, req)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.depths, req.Key)
+	m.mu.Unlock()
+	return nil
+}
+
+// Exists is the implementation of core.MQueue.Exists for MQueue
+func (m *MQueue) Exists(ctx context.Context, req core.ExistsRequest) (bool, error) {
+	v, err := m.tracker.Instrument(exists, func() (interface{}, error) {
+		return m.mqueue.Exists(ctx, req)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return v.(bool), nil
+}
+
+// Keys is the implementation of core.MQueue.Keys for MQueue
+func (m *MQueue) Keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	v, err := m.tracker.Instrument(keys, func() (interface{}, error) {
+		return m.mqueue.Keys(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]core.KeyInfo), nil
+}
+
+// Inspect is the implementation of core.MQueue.Inspect for MQueue
+func (m *MQueue) Inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	v, err := m.tracker.Instrument(inspect, func() (interface{}, error) {
+		return m.mqueue.Inspect(ctx, req)
+	})
+	if err != nil {
+		return core.Inspection{}, err
+	}
+
+	return v.(core.Inspection), nil
+}
+
+// Rebase is the implementation of core.MQueue.Rebase for MQueue
+func (m *MQueue) Rebase(ctx context.Context, req core.RebaseRequest) error {
+	_, err := m.tracker.Instrument(rebase, func() (interface{}, error) {
+		return nil, m.mqueue.Rebase(ctx, req)
+	})
+
+	return err
+}
@@ -0,0 +1,146 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/mqueue/mailboxtest"
+	"github.com/oasislabs/oasis-gateway/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var ctx = context.Background()
+
+func methodCount(m *MQueue, method string) map[string]interface{} {
+	return m.Stats()["methods"].(stats.Metrics)[method].(stats.Metrics)["count"].(map[string]interface{})
+}
+
+func TestMQueueNextInsert(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Next", mock.Anything, mock.Anything).Return(uint64(1), nil)
+	mailbox.On("Insert", mock.Anything, mock.Anything).Return(nil)
+	mailbox.On("Inspect", mock.Anything, mock.Anything).Return(core.Inspection{Unset: 1}, nil)
+	m := NewMQueue(mailbox, Props{})
+
+	offset, err := m.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), offset)
+	assert.Equal(t, uint64(1), m.Stats()["offsets"].(stats.Metrics)["reserved"])
+	assert.Equal(t, uint64(0), m.Stats()["offsets"].(stats.Metrics)["inserted"])
+
+	err = m.Insert(ctx, core.InsertRequest{Key: "key", Element: core.Element{Offset: offset}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), m.Stats()["offsets"].(stats.Metrics)["reserved"])
+	assert.Equal(t, uint64(1), m.Stats()["offsets"].(stats.Metrics)["inserted"])
+
+	assert.Equal(t, uint64(1), methodCount(m, next)["ok"])
+	assert.Equal(t, uint64(1), methodCount(m, insert)["ok"])
+}
+
+func TestMQueueInsertErr(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Insert", mock.Anything, mock.Anything).Return(errors.New("error"))
+	m := NewMQueue(mailbox, Props{})
+
+	err := m.Insert(ctx, core.InsertRequest{Key: "key"})
+	assert.Error(t, err)
+	assert.Equal(t, uint64(0), m.Stats()["offsets"].(stats.Metrics)["inserted"])
+	assert.Equal(t, uint64(1), methodCount(m, insert)["error"])
+}
+
+func TestMQueueRetrieve(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Retrieve", mock.Anything, mock.Anything).Return(core.Elements{
+		Offset:   0,
+		Elements: []core.Element{{Offset: 0}, {Offset: 1}},
+	}, nil)
+	m := NewMQueue(mailbox, Props{})
+
+	els, err := m.Retrieve(ctx, core.RetrieveRequest{Key: "key", Count: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(els.Elements))
+	assert.Equal(t, stats.Metrics{"avg": float64(2)},
+		m.Stats()["retrieved"])
+	assert.Equal(t, uint64(1), methodCount(m, retrieve)["ok"])
+}
+
+func TestMQueueExists(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+	m := NewMQueue(mailbox, Props{})
+
+	ok, err := m.Exists(ctx, core.ExistsRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), methodCount(m, exists)["ok"])
+	assert.Equal(t, uint64(0), methodCount(m, remove)["ok"])
+}
+
+func TestMQueueStatsIncludesUnderlying(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	m := NewMQueue(mailbox, Props{})
+
+	metrics := m.Stats()
+	_, ok := metrics[mailbox.Name()]
+	assert.True(t, ok)
+}
+
+func TestMQueueDepth(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Next", mock.Anything, mock.Anything).Return(uint64(1), nil)
+	mailbox.On("Discard", mock.Anything, mock.Anything).Return(nil)
+	mailbox.On("Inspect", mock.Anything, mock.Anything).
+		Return(core.Inspection{Elements: 2, Unset: 1}, nil).Once()
+	mailbox.On("Inspect", mock.Anything, mock.Anything).
+		Return(core.Inspection{Unset: 1, Discarded: 3}, nil).Once()
+	mailbox.On("Inspect", mock.Anything, mock.Anything).
+		Return(core.Inspection{}, nil).Once()
+	m := NewMQueue(mailbox, Props{})
+
+	// the depth is read back the first time the queue is used
+	// and incremented on every reserved offset after that
+	_, err := m.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	_, err = m.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	depth, ok := m.Depth("key")
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), depth)
+	mailbox.AssertNumberOfCalls(t, "Inspect", 1)
+
+	err = m.Discard(ctx, core.DiscardRequest{Key: "key", Offset: 3})
+	assert.Nil(t, err)
+	depth, ok = m.Depth("key")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), depth)
+	assert.Equal(t, stats.Metrics{"queues": 1, "total": int64(1), "max": int64(1)},
+		m.Stats()["depth"])
+
+	// empty queues stop being tracked
+	err = m.Discard(ctx, core.DiscardRequest{Key: "key", Offset: 4})
+	assert.Nil(t, err)
+	_, ok = m.Depth("key")
+	assert.False(t, ok)
+	assert.Equal(t, stats.Metrics{"queues": 0, "total": int64(0), "max": int64(0)},
+		m.Stats()["depth"])
+}
+
+func TestMQueueRemoveDepth(t *testing.T) {
+	mailbox := &mailboxtest.Mailbox{}
+	mailbox.On("Next", mock.Anything, mock.Anything).Return(uint64(1), nil)
+	mailbox.On("Remove", mock.Anything, mock.Anything).Return(nil)
+	mailbox.On("Inspect", mock.Anything, mock.Anything).Return(core.Inspection{Unset: 1}, nil)
+	m := NewMQueue(mailbox, Props{})
+
+	_, err := m.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	_, ok := m.Depth("key")
+	assert.True(t, ok)
+
+	assert.Nil(t, m.Remove(ctx, core.RemoveRequest{Key: "key"}))
+	_, ok = m.Depth("key")
+	assert.False(t, ok)
+}
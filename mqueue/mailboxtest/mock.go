@@ -49,3 +49,18 @@ func (m *Mailbox) Remove(ctx context.Context, req core.RemoveRequest) error {
 	args := m.Called(ctx, req)
 	return args.Error(0)
 }
+
+func (m *Mailbox) Keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]core.KeyInfo), args.Error(1)
+}
+
+func (m *Mailbox) Inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(core.Inspection), args.Error(1)
+}
+
+func (m *Mailbox) Rebase(ctx context.Context, req core.RebaseRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
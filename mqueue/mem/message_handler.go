@@ -28,6 +28,17 @@ type discardRequest struct {
 
 type nextRequest struct{}
 
+type keyInfoRequest struct{}
+
+type inspectRequest struct {
+	Offset uint64
+	Count  uint
+}
+
+type rebaseRequest struct {
+	Next uint64
+}
+
 // MessageHandler implements a very simple messaging queue-like
 // functionality serving requests for a single queue.
 type MessageHandler struct {
@@ -68,6 +79,13 @@ func (w *MessageHandler) handleRequestEvent(ctx context.Context, ev concurrent.R
 		return nil, err
 	case nextRequest:
 		return w.next(req)
+	case keyInfoRequest:
+		return w.keyInfo(req)
+	case inspectRequest:
+		return w.inspect(req)
+	case rebaseRequest:
+		err := w.rebase(req)
+		return nil, err
 	default:
 		panic("invalid request received for worker")
 	}
@@ -102,3 +120,21 @@ func (w *MessageHandler) discard(req discardRequest) error {
 func (w *MessageHandler) next(req nextRequest) (uint64, error) {
 	return w.window.ReserveNext()
 }
+
+func (w *MessageHandler) keyInfo(req keyInfoRequest) (core.KeyInfo, error) {
+	return core.KeyInfo{
+		Key:    w.key,
+		Offset: w.window.Offset(),
+		Next:   w.window.Next(),
+	}, nil
+}
+
+func (w *MessageHandler) inspect(req inspectRequest) (core.Inspection, error) {
+	inspection := w.window.Inspect(req.Offset, req.Count)
+	inspection.Key = w.key
+	return inspection, nil
+}
+
+func (w *MessageHandler) rebase(req rebaseRequest) error {
+	return w.window.Rebase(req.Next)
+}
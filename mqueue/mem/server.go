@@ -2,9 +2,11 @@ package mem
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/oasislabs/oasis-gateway/concurrent"
+	"github.com/oasislabs/oasis-gateway/errors"
 	"github.com/oasislabs/oasis-gateway/log"
 	"github.com/oasislabs/oasis-gateway/mqueue/core"
 	"github.com/oasislabs/oasis-gateway/stats"
@@ -13,17 +15,27 @@ import (
 const maxInactivityTimeout = time.Duration(10) * time.Minute
 
 type Server struct {
-	master *concurrent.Master
-	logger log.Logger
+	master    *concurrent.Master
+	logger    log.Logger
+	namespace string
 }
 
 type Services struct {
 	Logger log.Logger
 }
 
-func NewServer(ctx context.Context, services Services) *Server {
+// Props are the properties used to define the behaviour
+// of a Server
+type Props struct {
+	// Namespace if set is prepended to all the keys of the
+	// queues handled by the server
+	Namespace string
+}
+
+func NewServer(ctx context.Context, services Services, props Props) *Server {
 	s := &Server{
-		logger: services.Logger.ForClass("mqueue/mem", "Server"),
+		logger:    services.Logger.ForClass("mqueue/mem", "Server"),
+		namespace: core.NamespacePrefix(props.Namespace),
 	}
 
 	s.master = concurrent.NewMaster(concurrent.MasterProps{
@@ -67,14 +79,14 @@ func (s *Server) destroy(ctx context.Context, ev concurrent.DestroyWorkerEvent)
 
 // Insert inserts the element to the provided offset.
 func (s *Server) Insert(ctx context.Context, req core.InsertRequest) error {
-	_, err := s.master.Request(ctx, req.Key, insertRequest{Element: req.Element})
+	_, err := s.master.Request(ctx, s.namespace+req.Key, insertRequest{Element: req.Element})
 	return err
 }
 
 // Retrieve all available elements from the
 // messaging queue after the provided offset
 func (s *Server) Retrieve(ctx context.Context, req core.RetrieveRequest) (core.Elements, error) {
-	v, err := s.master.Request(ctx, req.Key, retrieveRequest{Offset: req.Offset, Count: req.Count})
+	v, err := s.master.Request(ctx, s.namespace+req.Key, retrieveRequest{Offset: req.Offset, Count: req.Count})
 	if err != nil {
 		return core.Elements{}, err
 	}
@@ -85,7 +97,7 @@ func (s *Server) Retrieve(ctx context.Context, req core.RetrieveRequest) (core.E
 // Discard all elements that have a prior or equal
 // offset to the provided offset
 func (s *Server) Discard(ctx context.Context, req core.DiscardRequest) error {
-	_, err := s.master.Request(ctx, req.Key, discardRequest{
+	_, err := s.master.Request(ctx, s.namespace+req.Key, discardRequest{
 		KeepPrevious: req.KeepPrevious,
 		Count:        req.Count,
 		Offset:       req.Offset,
@@ -95,7 +107,7 @@ func (s *Server) Discard(ctx context.Context, req core.DiscardRequest) error {
 
 // Next element offset that can be used for the queue.
 func (s *Server) Next(ctx context.Context, req core.NextRequest) (uint64, error) {
-	v, err := s.master.Request(ctx, req.Key, nextRequest{})
+	v, err := s.master.Request(ctx, s.namespace+req.Key, nextRequest{})
 	if err != nil {
 		return 0, err
 	}
@@ -105,13 +117,70 @@ func (s *Server) Next(ctx context.Context, req core.NextRequest) (uint64, error)
 
 // Remove the key's queue and it's associated resources
 func (s *Server) Remove(ctx context.Context, req core.RemoveRequest) error {
-	return s.master.Destroy(ctx, req.Key)
+	return s.master.Destroy(ctx, s.namespace+req.Key)
 }
 
 // Exists returns true if there is a queue allocated with the
 // provided key
 func (s *Server) Exists(ctx context.Context, req core.ExistsRequest) (bool, error) {
-	return s.master.Exists(ctx, req.Key)
+	return s.master.Exists(ctx, s.namespace+req.Key)
+}
+
+// Keys returns information about all the queues that are
+// allocated and match the provided prefix
+func (s *Server) Keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	responses, err := s.master.Broadcast(ctx, keyInfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]core.KeyInfo, 0, len(responses))
+	for _, res := range responses {
+		if res.Error == concurrent.ErrNoWorkers {
+			continue
+		}
+
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		info := res.Value.(core.KeyInfo)
+		if strings.HasPrefix(info.Key, s.namespace+req.Prefix) {
+			info.Key = strings.TrimPrefix(info.Key, s.namespace)
+			infos = append(infos, info)
+		}
+	}
+
+	return infos, nil
+}
+
+// Inspect returns the state of the queue without modifying it
+func (s *Server) Inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	// the master creates a worker on request, so it needs to be checked
+	// first that the queue exists to not allocate a new one
+	ok, err := s.master.Exists(ctx, s.namespace+req.Key)
+	if err != nil {
+		return core.Inspection{}, err
+	}
+	if !ok {
+		return core.Inspection{}, errors.New(errors.ErrQueueNotFound, nil)
+	}
+
+	v, err := s.master.Request(ctx, s.namespace+req.Key, inspectRequest{Offset: req.Offset, Count: req.Count})
+	if err != nil {
+		return core.Inspection{}, err
+	}
+
+	inspection := v.(core.Inspection)
+	inspection.Key = req.Key
+	return inspection, nil
+}
+
+// Rebase creates an empty queue whose offsets start at the
+// provided offset
+func (s *Server) Rebase(ctx context.Context, req core.RebaseRequest) error {
+	_, err := s.master.Request(ctx, s.namespace+req.Key, rebaseRequest{Next: req.Next})
+	return err
 }
 
 func (s *Server) Name() string {
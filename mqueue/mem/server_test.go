@@ -20,7 +20,7 @@ var (
 )
 
 func TestServerInsert(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	offset, err := s.Next(ctx, core.NextRequest{Key: "key"})
 	assert.Nil(t, err)
@@ -33,7 +33,7 @@ func TestServerInsert(t *testing.T) {
 }
 
 func TestServerRetrieve(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	els, err := s.Retrieve(ctx, core.RetrieveRequest{Key: "key", Offset: uint64(1), Count: uint(1)})
 	assert.Nil(t, err)
@@ -66,7 +66,7 @@ func TestServerRetrieve(t *testing.T) {
 }
 
 func TestServerDiscardKeepPreviousFalse(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	var offset uint64
 	var err error
@@ -103,7 +103,7 @@ func TestServerDiscardKeepPreviousFalse(t *testing.T) {
 }
 
 func TestServerDiscardKeepPreviousTrue(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	var offset uint64
 	var err error
@@ -145,7 +145,7 @@ func TestServerDiscardKeepPreviousTrue(t *testing.T) {
 }
 
 func TestServerNext(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	offset, err := s.Next(ctx, core.NextRequest{Key: "key"})
 	assert.Nil(t, err)
@@ -157,7 +157,7 @@ func TestServerNext(t *testing.T) {
 }
 
 func TestServerRemove(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	_, err := s.Next(ctx, core.NextRequest{Key: "key"})
 	assert.Nil(t, err)
@@ -167,7 +167,7 @@ func TestServerRemove(t *testing.T) {
 }
 
 func TestServerNextErrLimitReached(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	var (
 		err error
@@ -183,12 +183,82 @@ func TestServerNextErrLimitReached(t *testing.T) {
 }
 
 func TestServerName(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 	assert.Equal(t, "mqueue.mem.Server", s.Name())
 }
 
 func TestServerStats(t *testing.T) {
-	s := NewServer(context.TODO(), Services{Logger: logger})
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
 
 	assert.Nil(t, s.Stats())
 }
+
+func TestServerKeys(t *testing.T) {
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
+
+	infos, err := s.Keys(ctx, core.KeysRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.KeyInfo{}, infos)
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Next(ctx, core.NextRequest{Key: "key1"})
+		assert.Nil(t, err)
+	}
+	_, err = s.Next(ctx, core.NextRequest{Key: "other"})
+	assert.Nil(t, err)
+
+	err = s.Discard(ctx, core.DiscardRequest{Key: "key1", Offset: 0, Count: 1})
+	assert.Nil(t, err)
+
+	infos, err = s.Keys(ctx, core.KeysRequest{Prefix: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.KeyInfo{{Key: "key1", Offset: 1, Next: 3}}, infos)
+}
+
+func TestServerNamespace(t *testing.T) {
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{Namespace: "ns"})
+
+	_, err := s.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+
+	ok, err := s.master.Exists(ctx, "ns:key")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	infos, err := s.Keys(ctx, core.KeysRequest{Prefix: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.KeyInfo{{Key: "key", Offset: 0, Next: 1}}, infos)
+}
+
+func TestServerInspect(t *testing.T) {
+	s := NewServer(context.TODO(), Services{Logger: logger}, Props{})
+
+	_, err := s.Inspect(ctx, core.InspectRequest{Key: "key"})
+	assert.Error(t, err)
+
+	ok, err := s.Exists(ctx, core.ExistsRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	for i := 0; i < 4; i++ {
+		_, err = s.Next(ctx, core.NextRequest{Key: "key"})
+		assert.Nil(t, err)
+	}
+	err = s.Insert(ctx, core.InsertRequest{Key: "key", Element: core.Element{Offset: 1, Value: "value"}})
+	assert.Nil(t, err)
+	err = s.Discard(ctx, core.DiscardRequest{Key: "key", KeepPrevious: true, Offset: 2, Count: 1})
+	assert.Nil(t, err)
+
+	inspection, err := s.Inspect(ctx, core.InspectRequest{Key: "key", Offset: 1, Count: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, core.Inspection{
+		KeyInfo:   core.KeyInfo{Key: "key", Offset: 0, Next: 4},
+		Elements:  1,
+		Unset:     2,
+		Discarded: 1,
+		Slots: []core.Slot{
+			{Offset: 1, Set: true, Element: core.Element{Offset: 1, Value: "value"}},
+			{Offset: 2, Discarded: true},
+		},
+	}, inspection)
+}
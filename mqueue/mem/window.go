@@ -22,6 +22,7 @@ var (
 	ErrOffsetOutOfWindow = stderr.New("offset is out of the window's range")
 	ErrOffsetNotReserved = stderr.New("offset is not reserved")
 	ErrOffsetAlreadySet  = stderr.New("offset is already set")
+	ErrWindowInUse       = stderr.New("window has reserved offsets")
 )
 
 // SlidingWindow is a sliding window of elements that keep track of
@@ -145,6 +146,66 @@ func (w *SlidingWindow) Offset() uint64 {
 	return w.offset
 }
 
+// Next returns the offset that will be reserved by the next
+// call to ReserveNext if there is room in the window
+func (w *SlidingWindow) Next() uint64 {
+	return w.offset + uint64(w.nextUnreservedIndex)
+}
+
+// Rebase moves the base offset of an empty window, so that offset
+// is the next offset reserved. It fails if an offset has already
+// been reserved in the window
+func (w *SlidingWindow) Rebase(offset uint64) errors.Err {
+	if w.nextUnreservedIndex > 0 {
+		return errors.New(errors.ErrInvalidStateChangeError, ErrWindowInUse)
+	}
+
+	w.offset = offset
+	return nil
+}
+
+// Inspect returns the state of the window along with the state of
+// at most count slots starting at offset. The window is not modified
+func (w *SlidingWindow) Inspect(offset uint64, count uint) core.Inspection {
+	res := core.Inspection{
+		KeyInfo: core.KeyInfo{Offset: w.Offset(), Next: w.Next()},
+		Slots:   make([]core.Slot, 0),
+	}
+
+	for i := uint(0); i < w.nextUnreservedIndex; i++ {
+		element := &w.elements[i]
+		switch {
+		case element.Discarded:
+			res.Discarded++
+		case element.Set:
+			res.Elements++
+		default:
+			res.Unset++
+		}
+
+		if element.Offset < offset || uint(len(res.Slots)) >= count {
+			continue
+		}
+
+		slot := core.Slot{
+			Offset:    element.Offset,
+			Set:       element.Set,
+			Discarded: element.Discarded,
+		}
+		if element.Set {
+			slot.Element = core.Element{
+				Offset: element.Offset,
+				Value:  element.Value,
+				Type:   element.Type,
+			}
+		}
+
+		res.Slots = append(res.Slots, slot)
+	}
+
+	return res
+}
+
 // Set sets the value for the element at offset `offset`. If the
 // offset is not in the window's range or the element's state is not
 // reserved or already set an error will be returned
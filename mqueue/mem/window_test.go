@@ -255,3 +255,19 @@ func TestGetDiscarded(t *testing.T) {
 		{Offset: 0x8, Value: "8", Type: ""},
 		{Offset: 0x9, Value: "9", Type: ""}}, els.Elements)
 }
+
+func TestSlidingWindowRebase(t *testing.T) {
+	w := NewSlidingWindow(SlidingWindowProps{InitialSize: 4, MaxSize: 4})
+
+	err := w.Rebase(10)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), w.Offset())
+
+	offset, err := w.ReserveNext()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), offset)
+
+	err = w.Rebase(20)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(11), w.Next())
+}
@@ -0,0 +1,344 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	stderr "github.com/pkg/errors"
+)
+
+var (
+	// ErrDestinationExists is returned when a queue that is being
+	// migrated already exists in the destination
+	ErrDestinationExists = stderr.New("queue already exists in destination")
+
+	// ErrOffsetMismatch is returned when the destination returns an
+	// offset different from the one expected to migrate an element
+	ErrOffsetMismatch = stderr.New("destination returned an unexpected offset")
+)
+
+// Props are the properties used to define the behaviour
+// of a Migrator
+type Props struct {
+	// Source is the queue from which queues are read
+	Source core.MQueue
+
+	// Destination is the queue to which queues are written
+	Destination core.MQueue
+
+	// Logger used by the migrator
+	Logger log.Logger
+
+	// Prefix if set only the queues whose key starts with
+	// Prefix are migrated
+	Prefix string
+
+	// DryRun if set the migrator only reports what would be
+	// migrated without writing to the destination
+	DryRun bool
+
+	// Verify if set the migrator checks that the contents
+	// of the destination match those of the source once the
+	// queues have been migrated. If it is set along with DryRun
+	// no queue is copied and only the verification is run
+	Verify bool
+}
+
+// QueueReport is the result of migrating a single queue
+type QueueReport struct {
+	// Key unique identifier of the queue
+	Key string `json:"key"`
+
+	// Offset is the base offset of the queue in the source
+	Offset uint64 `json:"offset"`
+
+	// Next is the next offset of the queue in the source
+	Next uint64 `json:"next"`
+
+	// Elements is the number of elements that are set in the
+	// source window
+	Elements int `json:"elements"`
+
+	// Unset is the number of offsets in the source window that
+	// were reserved but not set, or discarded. These offsets are
+	// discarded in the destination
+	Unset int `json:"unset"`
+
+	// Copied is true if the queue was written to the destination
+	Copied bool `json:"copied"`
+
+	// Verified is true if the destination was checked to match
+	// the source
+	Verified bool `json:"verified"`
+
+	// Error is set if the migration or the verification failed
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the result of a migration
+type Report struct {
+	DryRun bool          `json:"dryRun"`
+	Verify bool          `json:"verify"`
+	Failed int           `json:"failed"`
+	Queues []QueueReport `json:"queues"`
+}
+
+// Migrator copies queues along with their offsets and elements
+// from one core.MQueue to another. It only relies on the core.MQueue
+// interface so it can migrate between any two providers
+type Migrator struct {
+	source      core.MQueue
+	destination core.MQueue
+	logger      log.Logger
+	prefix      string
+	dryRun      bool
+	verify      bool
+}
+
+// NewMigrator creates a new instance of a Migrator
+func NewMigrator(props Props) *Migrator {
+	if props.Source == nil {
+		panic("source must be set")
+	}
+
+	if props.Destination == nil {
+		panic("destination must be set")
+	}
+
+	if props.Logger == nil {
+		panic("logger must be set")
+	}
+
+	return &Migrator{
+		source:      props.Source,
+		destination: props.Destination,
+		logger:      props.Logger.ForClass("mqueue/migrate", "Migrator"),
+		prefix:      props.Prefix,
+		dryRun:      props.DryRun,
+		verify:      props.Verify,
+	}
+}
+
+// Migrate migrates all the queues that match the configured
+// prefix. An error is only returned if the source queues cannot
+// be listed, failures on individual queues are part of the report
+func (m *Migrator) Migrate(ctx context.Context) (Report, error) {
+	infos, err := m.source.Keys(ctx, core.KeysRequest{Prefix: m.prefix})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		DryRun: m.dryRun,
+		Verify: m.verify,
+		Queues: make([]QueueReport, 0, len(infos)),
+	}
+
+	for _, info := range infos {
+		queue := m.migrateQueue(ctx, info)
+		if len(queue.Error) > 0 {
+			report.Failed++
+		}
+		report.Queues = append(report.Queues, queue)
+	}
+
+	return report, nil
+}
+
+func (m *Migrator) migrateQueue(ctx context.Context, info core.KeyInfo) QueueReport {
+	queue := QueueReport{Key: info.Key, Offset: info.Offset, Next: info.Next}
+
+	slots, err := m.inspect(ctx, m.source, info)
+	if err != nil {
+		return m.fail(ctx, queue, "failed to inspect source queue", err)
+	}
+
+	for _, slot := range slots {
+		if isLive(slot) {
+			queue.Elements++
+		}
+	}
+	queue.Unset = int(info.Next-info.Offset) - queue.Elements
+
+	if !m.dryRun {
+		if err := m.copy(ctx, info, slots); err != nil {
+			return m.fail(ctx, queue, "failed to copy queue", err)
+		}
+		queue.Copied = true
+	}
+
+	if m.verify {
+		if err := m.verifyQueue(ctx, info, slots); err != nil {
+			return m.fail(ctx, queue, "failed to verify queue", err)
+		}
+		queue.Verified = true
+	}
+
+	m.logger.Info(ctx, "queue migrated", log.MapFields{
+		"call_type": "MigrateQueueSuccess",
+		"key":       info.Key,
+		"offset":    info.Offset,
+		"next":      info.Next,
+		"elements":  queue.Elements,
+		"dry_run":   m.dryRun,
+	})
+
+	return queue
+}
+
+// inspect returns the slots of the window of the queue in q indexed
+// by offset. Slots carry whether an element was discarded, which is
+// lost when elements are read with Retrieve
+func (m *Migrator) inspect(ctx context.Context, q core.MQueue, info core.KeyInfo) (map[uint64]core.Slot, error) {
+	inspection, err := q.Inspect(ctx, core.InspectRequest{
+		Key:    info.Key,
+		Offset: info.Offset,
+		Count:  uint(info.Next - info.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make(map[uint64]core.Slot, len(inspection.Slots))
+	for _, slot := range inspection.Slots {
+		slots[slot.Offset] = slot
+	}
+
+	return slots, nil
+}
+
+// isLive returns true if the slot holds an element that
+// can still be retrieved from the queue
+func isLive(slot core.Slot) bool {
+	return slot.Set && !slot.Discarded
+}
+
+func (m *Migrator) fail(ctx context.Context, queue QueueReport, msg string, err error) QueueReport {
+	m.logger.Warn(ctx, msg, log.MapFields{
+		"call_type": "MigrateQueueFailure",
+		"key":       queue.Key,
+		"err":       err.Error(),
+	})
+
+	queue.Error = err.Error()
+	return queue
+}
+
+// copy writes the queue to the destination. The destination queue
+// is first rebased to the source base offset, so that only the
+// offsets in the source window need to be reserved. Offsets in the
+// window that are not set in the source are discarded, so that the
+// destination window can keep sliding once the requests that
+// reserved them are lost with the source. Elements that are
+// discarded in the source are inserted and discarded again, so
+// that the destination slot is in the same state
+func (m *Migrator) copy(ctx context.Context, info core.KeyInfo, slots map[uint64]core.Slot) error {
+	ok, err := m.destination.Exists(ctx, core.ExistsRequest{Key: info.Key})
+	if err != nil {
+		return err
+	}
+	if ok {
+		return ErrDestinationExists
+	}
+
+	if info.Offset > 0 {
+		if err := m.destination.Rebase(ctx, core.RebaseRequest{
+			Key:  info.Key,
+			Next: info.Offset,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for expected := info.Offset; expected < info.Next; expected++ {
+		offset, err := m.destination.Next(ctx, core.NextRequest{Key: info.Key})
+		if err != nil {
+			return err
+		}
+
+		if offset != expected {
+			return stderr.Wrap(ErrOffsetMismatch, fmt.Sprintf("offset %d", offset))
+		}
+
+		slot, ok := slots[offset]
+		if ok && slot.Set {
+			if err := m.destination.Insert(ctx, core.InsertRequest{
+				Key:     info.Key,
+				Element: slot.Element,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if !ok || !isLive(slot) {
+			if err := m.destination.Discard(ctx, core.DiscardRequest{
+				KeepPrevious: true,
+				Key:          info.Key,
+				Offset:       offset,
+				Count:        1,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyQueue checks that the destination holds the same next
+// offset and the same live elements as the source, and that the
+// offsets that are not live in the source are not live in the
+// destination either. The base offset is not compared because
+// discarding elements may slide the window differently depending
+// on the provider
+func (m *Migrator) verifyQueue(ctx context.Context, info core.KeyInfo, slots map[uint64]core.Slot) error {
+	if info.Next == 0 {
+		// nothing is copied for queues that have never
+		// reserved an offset
+		return nil
+	}
+
+	dests, err := m.destination.Keys(ctx, core.KeysRequest{Prefix: info.Key})
+	if err != nil {
+		return err
+	}
+
+	var dest *core.KeyInfo
+	for i := range dests {
+		if dests[i].Key == info.Key {
+			dest = &dests[i]
+			break
+		}
+	}
+
+	if dest == nil {
+		return stderr.New("queue not found in destination")
+	}
+
+	if dest.Next != info.Next {
+		return stderr.Errorf("destination next offset %d does not match source %d", dest.Next, info.Next)
+	}
+
+	destSlots, err := m.inspect(ctx, m.destination, info)
+	if err != nil {
+		return err
+	}
+
+	for offset := info.Offset; offset < info.Next; offset++ {
+		// slots that the destination window slided past
+		// are discarded, so they are not live
+		slot, destSlot := slots[offset], destSlots[offset]
+		if isLive(slot) != isLive(destSlot) {
+			return stderr.Errorf("destination element at offset %d is live %t but source is live %t",
+				offset, isLive(destSlot), isLive(slot))
+		}
+
+		if isLive(slot) && slot.Element != destSlot.Element {
+			return stderr.Errorf("destination element at offset %d does not match source", offset)
+		}
+	}
+
+	return nil
+}
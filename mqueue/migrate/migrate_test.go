@@ -0,0 +1,193 @@
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/mqueue/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx    = context.Background()
+	logger = log.NewLogrus(log.LogrusLoggerProperties{
+		Level:  logrus.DebugLevel,
+		Output: ioutil.Discard,
+	})
+)
+
+// newSource creates a queue with offsets [0, 6) reserved, where
+// offsets 0 and 1 have been discarded, 2, 3 and 5 are set and
+// 4 is still pending
+func newSource(t *testing.T) core.MQueue {
+	source := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+
+	for i := 0; i < 6; i++ {
+		offset, err := source.Next(ctx, core.NextRequest{Key: "key"})
+		assert.Nil(t, err)
+		if offset == 4 {
+			continue
+		}
+
+		assert.Nil(t, source.Insert(ctx, core.InsertRequest{Key: "key", Element: core.Element{
+			Offset: offset,
+			Value:  "value",
+			Type:   "type",
+		}}))
+	}
+
+	assert.Nil(t, source.Discard(ctx, core.DiscardRequest{Key: "key", Offset: 1, Count: 1}))
+	return source
+}
+
+func TestMigrate(t *testing.T) {
+	source := newSource(t)
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		Verify:      true,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, Report{
+		Verify: true,
+		Queues: []QueueReport{{
+			Key:      "key",
+			Offset:   2,
+			Next:     6,
+			Elements: 3,
+			Unset:    1,
+			Copied:   true,
+			Verified: true,
+		}},
+	}, report)
+
+	next, err := destination.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(6), next)
+}
+
+func TestMigrateRebase(t *testing.T) {
+	source := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+	assert.Nil(t, source.Rebase(ctx, core.RebaseRequest{Key: "key", Next: 1 << 40}))
+
+	offset, err := source.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.Nil(t, source.Insert(ctx, core.InsertRequest{Key: "key", Element: core.Element{
+		Offset: offset,
+		Value:  "value",
+		Type:   "type",
+	}}))
+
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		Verify:      true,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.Failed)
+
+	next, err := destination.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1<<40+1), next)
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	source := newSource(t)
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		DryRun:      true,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(report.Queues))
+	assert.False(t, report.Queues[0].Copied)
+
+	ok, err := destination.Exists(ctx, core.ExistsRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestMigrateVerifyMismatch(t *testing.T) {
+	source := newSource(t)
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+	_, err := destination.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		DryRun:      true,
+		Verify:      true,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "destination next offset 1 does not match source 6", report.Queues[0].Error)
+}
+
+func TestMigrateDestinationExists(t *testing.T) {
+	source := newSource(t)
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+	_, err := destination.Next(ctx, core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, ErrDestinationExists.Error(), report.Queues[0].Error)
+}
+
+func TestMigrateDiscardedElement(t *testing.T) {
+	source := newSource(t)
+
+	// offset 3 is discarded without sliding the window, so its
+	// element is still kept in the source window
+	assert.Nil(t, source.Discard(ctx, core.DiscardRequest{
+		KeepPrevious: true,
+		Key:          "key",
+		Offset:       3,
+		Count:        1,
+	}))
+	destination := mem.NewServer(ctx, mem.Services{Logger: logger}, mem.Props{})
+
+	report, err := NewMigrator(Props{
+		Source:      source,
+		Destination: destination,
+		Logger:      logger,
+		Verify:      true,
+	}).Migrate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, 2, report.Queues[0].Elements)
+	assert.Equal(t, 2, report.Queues[0].Unset)
+
+	inspection, err := destination.Inspect(ctx, core.InspectRequest{Key: "key", Offset: 3, Count: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.Slot{{
+		Offset:    3,
+		Set:       true,
+		Discarded: true,
+		Element:   core.Element{Offset: 3, Value: "value", Type: "type"},
+	}}, inspection.Slots)
+
+	els, err := destination.Retrieve(ctx, core.RetrieveRequest{Key: "key", Offset: 2, Count: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(els.Elements))
+	assert.Equal(t, []uint64{2, 5}, []uint64{els.Elements[0].Offset, els.Elements[1].Offset})
+}
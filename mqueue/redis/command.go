@@ -14,6 +14,9 @@ const (
 	mqretrieve op = "return mqretrieve(KEYS[1], ARGV[1], ARGV[2])"
 	mqdiscard  op = "return mqdiscard(KEYS[1], ARGV[1], ARGV[2], ARGV[3])"
 	mqremove   op = "return mqremove(KEYS[1])"
+	mqwindow   op = "return mqwindow(KEYS[1])"
+	mqinspect  op = "return mqinspect(KEYS[1])"
+	mqrebase   op = "return mqrebase(KEYS[1], ARGV[1])"
 )
 
 type nextRequest struct {
@@ -103,3 +106,52 @@ func (r removeRequest) Keys() []string {
 func (r removeRequest) Args() []interface{} {
 	return nil
 }
+
+type windowRequest struct {
+	Key string
+}
+
+func (r windowRequest) Op() op {
+	return mqwindow
+}
+
+func (r windowRequest) Keys() []string {
+	return []string{r.Key}
+}
+
+func (r windowRequest) Args() []interface{} {
+	return nil
+}
+
+type inspectRequest struct {
+	Key string
+}
+
+func (r inspectRequest) Op() op {
+	return mqinspect
+}
+
+func (r inspectRequest) Keys() []string {
+	return []string{r.Key}
+}
+
+func (r inspectRequest) Args() []interface{} {
+	return nil
+}
+
+type rebaseRequest struct {
+	Key  string
+	Next uint64
+}
+
+func (r rebaseRequest) Op() op {
+	return mqrebase
+}
+
+func (r rebaseRequest) Keys() []string {
+	return []string{r.Key}
+}
+
+func (r rebaseRequest) Args() []interface{} {
+	return []interface{}{r.Next}
+}
@@ -67,3 +67,19 @@ func TestRemoveRequest(t *testing.T) {
 	assert.Equal(t, []string{"key"}, req.Keys())
 	assert.Equal(t, []interface{}(nil), req.Args())
 }
+
+func TestWindowRequest(t *testing.T) {
+	req := windowRequest{Key: "key"}
+
+	assert.Equal(t, mqwindow, req.Op())
+	assert.Equal(t, []string{"key"}, req.Keys())
+	assert.Equal(t, []interface{}(nil), req.Args())
+}
+
+func TestRebaseRequest(t *testing.T) {
+	req := rebaseRequest{Key: "key", Next: 10}
+
+	assert.Equal(t, mqrebase, req.Op())
+	assert.Equal(t, []string{"key"}, req.Keys())
+	assert.Equal(t, []interface{}{uint64(10)}, req.Args())
+}
@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
 
 	"github.com/go-redis/redis"
 	"github.com/oasislabs/oasis-gateway/log"
@@ -17,18 +19,34 @@ const (
 	next     string = "next"
 	remove   string = "remove"
 	exists   string = "exists"
+	keys     string = "keys"
+	inspect  string = "inspect"
+	rebase   string = "rebase"
 )
 
+// scanCount is the hint provided to redis on the number of
+// keys to return on each iteration of a SCAN
+const scanCount int64 = 256
+
 // Client is the interface to the redis client used implementing
 // the methods used by the MQueue implementation
 type Client interface {
 	Eval(script string, keys []string, args ...interface{}) *redis.Cmd
 	Exists(key ...string) *redis.IntCmd
+	Scan(cursor uint64, match string, count int64) *redis.ScanCmd
 }
 
+// forEachNodeFunc calls fn for each of the redis instances
+// that hold a subset of the keys stored
+type forEachNodeFunc func(fn func(Client) error) error
+
 type Props struct {
 	Context context.Context
 	Logger  log.Logger
+
+	// Namespace if set is prepended to all the keys of the
+	// queues stored in redis
+	Namespace string
 }
 
 type ClusterProps struct {
@@ -49,9 +67,11 @@ type SingleInstanceProps struct {
 // MQueue implements the messaging queue functionality required
 // from the mqueue package using Redis as a backend
 type MQueue struct {
-	client  Client
-	logger  log.Logger
-	tracker *stats.MethodTracker
+	client      Client
+	forEachNode forEachNodeFunc
+	logger      log.Logger
+	tracker     *stats.MethodTracker
+	namespace   string
 }
 
 // NewClusterMQueue creates a new instance of a redis client
@@ -63,9 +83,15 @@ func NewClusterMQueue(props ClusterProps) (*MQueue, error) {
 	})
 
 	return &MQueue{
-		client:  c,
-		logger:  logger,
-		tracker: stats.NewMethodTracker(insert, retrieve, discard, next, remove, exists),
+		client: c,
+		forEachNode: func(fn func(Client) error) error {
+			return c.ForEachMaster(func(client *redis.Client) error {
+				return fn(client)
+			})
+		},
+		logger:    logger,
+		tracker:   stats.NewMethodTracker(insert, retrieve, discard, next, remove, exists, keys, inspect, rebase),
+		namespace: core.NamespacePrefix(props.Namespace),
 	}, nil
 }
 
@@ -78,9 +104,13 @@ func NewSingleMQueue(props SingleInstanceProps) (*MQueue, error) {
 	})
 
 	return &MQueue{
-		client:  c,
-		logger:  logger,
-		tracker: stats.NewMethodTracker(insert, retrieve, discard, next, remove),
+		client: c,
+		forEachNode: func(fn func(Client) error) error {
+			return fn(c)
+		},
+		logger:    logger,
+		tracker:   stats.NewMethodTracker(insert, retrieve, discard, next, remove, exists, keys, inspect, rebase),
+		namespace: core.NamespacePrefix(props.Namespace),
 	}, nil
 }
 
@@ -92,8 +122,16 @@ func (m *MQueue) Stats() stats.Metrics {
 	return m.tracker.Stats()
 }
 
+// exec runs the command with the namespace applied to its keys. The
+// scripts only access the keys they receive, so this is the only
+// place in which the namespace needs to be applied for commands
 func (m *MQueue) exec(ctx context.Context, cmd command) (interface{}, error) {
-	return m.client.Eval(string(cmd.Op()), cmd.Keys(), cmd.Args()...).Result()
+	keys := cmd.Keys()
+	for i := range keys {
+		keys[i] = m.namespace + keys[i]
+	}
+
+	return m.client.Eval(string(cmd.Op()), keys, cmd.Args()...).Result()
 }
 
 func (m *MQueue) Insert(ctx context.Context, req core.InsertRequest) error {
@@ -260,7 +298,7 @@ func (m *MQueue) Exists(ctx context.Context, req core.ExistsRequest) (bool, erro
 }
 
 func (m *MQueue) exists(ctx context.Context, req core.ExistsRequest) (bool, error) {
-	v, err := m.client.Exists(req.Key).Result()
+	v, err := m.client.Exists(m.namespace + req.Key).Result()
 	return v == 1, err
 }
 
@@ -279,3 +317,192 @@ func (m *MQueue) remove(ctx context.Context, req core.RemoveRequest) error {
 
 	return nil
 }
+
+func (m *MQueue) Keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	v, err := m.tracker.Instrument(keys, func() (interface{}, error) {
+		return m.keys(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]core.KeyInfo), nil
+}
+
+func (m *MQueue) keys(ctx context.Context, req core.KeysRequest) ([]core.KeyInfo, error) {
+	var mu sync.Mutex
+	infos := make([]core.KeyInfo, 0)
+	match := escapePattern(m.namespace+req.Prefix) + "*"
+
+	// in the case of a cluster the callback is called concurrently
+	// for each master
+	err := m.forEachNode(func(client Client) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(cursor, match, scanCount).Result()
+			if err != nil {
+				return ErrRedisExec{Cause: err}
+			}
+
+			for _, key := range keys {
+				info, ok, err := m.keyInfo(ctx, strings.TrimPrefix(key, m.namespace))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+
+				mu.Lock()
+				infos = append(infos, info)
+				mu.Unlock()
+			}
+
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// keyInfo returns the information of the queue stored at key. If
+// the key does not hold a queue false is returned
+func (m *MQueue) keyInfo(ctx context.Context, key string) (core.KeyInfo, bool, error) {
+	v, err := m.exec(ctx, windowRequest{Key: key})
+	if err != nil {
+		return core.KeyInfo{}, false, ErrRedisExec{Cause: err}
+	}
+
+	window := v.([]interface{})
+	if len(window) != 2 {
+		return core.KeyInfo{}, false, nil
+	}
+
+	base := uint64(window[0].(int64))
+	length := uint64(window[1].(int64))
+	return core.KeyInfo{
+		Key:    key,
+		Offset: base,
+		Next:   base + length,
+	}, true, nil
+}
+
+func (m *MQueue) Inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	v, err := m.tracker.Instrument(inspect, func() (interface{}, error) {
+		return m.inspect(ctx, req)
+	})
+	if err != nil {
+		return core.Inspection{}, err
+	}
+
+	return v.(core.Inspection), nil
+}
+
+func (m *MQueue) inspect(ctx context.Context, req core.InspectRequest) (core.Inspection, error) {
+	v, err := m.exec(ctx, inspectRequest{Key: req.Key})
+	if err != nil {
+		return core.Inspection{}, ErrRedisExec{Cause: err}
+	}
+
+	els := v.([]interface{})
+	if len(els) == 0 {
+		return core.Inspection{}, ErrQueueNotFound
+	}
+
+	res := core.Inspection{
+		KeyInfo: core.KeyInfo{Key: req.Key},
+		Slots:   make([]core.Slot, 0),
+	}
+
+	for i, el := range els {
+		var decoded redisElement
+		if err := json.Unmarshal([]byte(el.(string)), &decoded); err != nil {
+			return core.Inspection{}, ErrDeserialize{Cause: err}
+		}
+
+		if i == 0 {
+			res.Offset = decoded.Offset
+		}
+
+		switch {
+		case decoded.Discarded:
+			res.Discarded++
+		case decoded.Set:
+			res.Elements++
+		default:
+			res.Unset++
+		}
+
+		if decoded.Offset < req.Offset || uint(len(res.Slots)) >= req.Count {
+			continue
+		}
+
+		slot := core.Slot{
+			Offset:    decoded.Offset,
+			Set:       decoded.Set,
+			Discarded: decoded.Discarded,
+		}
+
+		if decoded.Set {
+			// value is serialized in our redis script as a string, so we need to deserialize
+			// the contents of the value as a string
+			var value string
+			if err := json.Unmarshal([]byte(decoded.Value), &value); err != nil {
+				return core.Inspection{}, ErrDeserialize{Cause: err}
+			}
+
+			slot.Element = core.Element{
+				Offset: decoded.Offset,
+				Type:   decoded.Type,
+				Value:  value,
+			}
+		}
+
+		res.Slots = append(res.Slots, slot)
+	}
+
+	res.Next = res.Offset + uint64(len(els))
+	return res, nil
+}
+
+func (m *MQueue) Rebase(ctx context.Context, req core.RebaseRequest) error {
+	_, err := m.tracker.Instrument(rebase, func() (interface{}, error) {
+		return nil, m.rebase(ctx, req)
+	})
+
+	return err
+}
+
+func (m *MQueue) rebase(ctx context.Context, req core.RebaseRequest) error {
+	v, err := m.exec(ctx, rebaseRequest{Key: req.Key, Next: req.Next})
+	if err != nil {
+		return ErrRedisExec{Cause: err}
+	}
+
+	if v.(string) != "OK" {
+		return ErrOpNotOk
+	}
+
+	return nil
+}
+
+// escapePattern escapes the characters that have a special
+// meaning in a redis glob-style pattern
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -1,5 +1,9 @@
 local expire_time = 600 -- in seconds
 
+-- all the functions only access the keys they receive as arguments,
+-- so that the namespace of the keys is applied by the client
+-- before the scripts are called
+
 local mqbasenlen = function(key)
   local len = redis.call('llen', key)
   if len > 0 then
@@ -159,6 +163,49 @@ local mqdiscard = function(key, offset, count, keep_previous)
   return "OK"
 end
 
+-- mqwindow returns the base offset and the length of the window
+-- of the queue. If the key does not hold a queue an empty table
+-- is returned instead
+local mqwindow = function(key)
+  if redis.call('type', key)['ok'] ~= 'list' then
+    return {}
+  end
+
+  return mqbasenlen(key)
+end
+
+-- mqinspect returns all the elements in the window of the queue
+-- without modifying it or its expiration. If the key does not
+-- hold a queue an empty table is returned instead
+local mqinspect = function(key)
+  if redis.call('type', key)['ok'] ~= 'list' then
+    return {}
+  end
+
+  return redis.call('lrange', key, 0, -1)
+end
+
+-- mqrebase creates an empty queue whose next offset is next. A window
+-- should never be empty, so the queue keeps a discarded element at
+-- the offset prior to next, as it does when all its elements are
+-- discarded. It fails if the key already exists
+local mqrebase = function(key, next)
+  next = tonumber(next)
+  if redis.call('exists', key) == 1 then
+    return redis.error_reply('queue already exists')
+  end
+
+  if next == 0 then
+    -- a queue that does not exist already starts at 0
+    return "OK"
+  end
+
+  local payload = cjson.encode({offset = next - 1, set = false, discarded = true})
+  redis.call('rpush', key, payload)
+  redis.call('expire', key, expire_time)
+  return "OK"
+end
+
 -- remove the key and all associated resources
 local mqremove = function(key)
   return redis.call('del', key)
@@ -171,6 +218,9 @@ rawset(_G, "mqdiscard", mqdiscard)
 rawset(_G, "mqretrieve", mqretrieve)
 rawset(_G, "mqinsert", mqinsert)
 rawset(_G, "mqnext", mqnext)
+rawset(_G, "mqwindow", mqwindow)
+rawset(_G, "mqinspect", mqinspect)
+rawset(_G, "mqrebase", mqrebase)
 
 -- test the basic functionality of the script
 local test = function()
@@ -218,11 +268,33 @@ local test = function()
   local t = mqretrieve('example', 0, 10)
   assert(table.getn(t) == 1)
 
+  local w = mqwindow('example')
+  assert(w[1] == 10)
+  assert(w[2] == 1)
+
+  redis.call('set', 'notqueue', 'value')
+  assert(table.getn(mqwindow('notqueue')) == 0)
+  assert(table.getn(mqinspect('notqueue')) == 0)
+  redis.call('del', 'notqueue')
+
+  local t = mqinspect('example')
+  assert(table.getn(t) == 1)
+  assert(cjson.decode(t[1])['offset'] == 10)
+
   local ttl = redis.call('ttl', 'example')
   assert(ttl <= 600 and ttl > 100)
 
   mqremove('example')
   assert(redis.call('exists', 'example') == 0)
+
+  mqrebase('example', 100)
+  assert(mqnext('example') == 100)
+  assert(mqrebase('example', 200)['err'] ~= nil)
+  mqdiscard('example', 100, 0, false)
+  local w = mqwindow('example')
+  assert(w[1] == 100)
+  assert(w[2] == 1)
+  mqremove('example')
 end
 
 if ARGV[1] == "test" then
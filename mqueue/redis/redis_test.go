@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/mqueue/core"
+	"github.com/oasislabs/oasis-gateway/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var logger = log.NewLogrus(log.LogrusLoggerProperties{
+	Level:  logrus.DebugLevel,
+	Output: ioutil.Discard,
+})
+
+type mockClient struct {
+	mock.Mock
+}
+
+func (c *mockClient) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	ret := c.Called(script, keys)
+	return redis.NewCmdResult(ret.Get(0), ret.Error(1))
+}
+
+func (c *mockClient) Exists(keys ...string) *redis.IntCmd {
+	ret := c.Called(keys)
+	return redis.NewIntResult(ret.Get(0).(int64), ret.Error(1))
+}
+
+func (c *mockClient) Scan(cursor uint64, match string, count int64) *redis.ScanCmd {
+	ret := c.Called(cursor, match)
+	return redis.NewScanCmdResult(ret.Get(0).([]string), ret.Get(1).(uint64), ret.Error(2))
+}
+
+func newMockMQueue(client Client) *MQueue {
+	return &MQueue{
+		client: client,
+		forEachNode: func(fn func(Client) error) error {
+			return fn(client)
+		},
+		logger:  logger,
+		tracker: stats.NewMethodTracker(keys),
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, "abc:\\*\\?\\[x\\]\\\\", escapePattern("abc:*?[x]\\"))
+}
+
+func TestKeys(t *testing.T) {
+	client := &mockClient{}
+	client.On("Scan", uint64(0), "prefix*").Return([]string{"prefix1"}, uint64(5), nil)
+	client.On("Scan", uint64(5), "prefix*").Return([]string{"prefix2", "prefix3"}, uint64(0), nil)
+	client.On("Eval", string(mqwindow), []string{"prefix1"}).Return([]interface{}{int64(2), int64(3)}, nil)
+	client.On("Eval", string(mqwindow), []string{"prefix2"}).Return([]interface{}{}, nil)
+	client.On("Eval", string(mqwindow), []string{"prefix3"}).Return([]interface{}{int64(0), int64(1)}, nil)
+
+	m := newMockMQueue(client)
+	infos, err := m.Keys(context.Background(), core.KeysRequest{Prefix: "prefix"})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.KeyInfo{
+		{Key: "prefix1", Offset: 2, Next: 5},
+		{Key: "prefix3", Offset: 0, Next: 1},
+	}, infos)
+}
+
+func TestNextNamespace(t *testing.T) {
+	client := &mockClient{}
+	client.On("Eval", string(mqnext), []string{"ns:key"}).Return(int64(3), nil)
+
+	m := newMockMQueue(client)
+	m.namespace = core.NamespacePrefix("ns")
+	offset, err := m.next(context.Background(), core.NextRequest{Key: "key"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), offset)
+}
+
+func TestRebase(t *testing.T) {
+	client := &mockClient{}
+	client.On("Eval", string(mqrebase), []string{"ns:key"}).Return("OK", nil).Once()
+	client.On("Eval", string(mqrebase), []string{"ns:key"}).Return(nil, errors.New("queue already exists"))
+
+	m := newMockMQueue(client)
+	m.namespace = core.NamespacePrefix("ns")
+	err := m.rebase(context.Background(), core.RebaseRequest{Key: "key", Next: 10})
+	assert.Nil(t, err)
+
+	err = m.rebase(context.Background(), core.RebaseRequest{Key: "key", Next: 10})
+	assert.Error(t, err)
+	assert.True(t, IsErrRedisExec(err))
+}
+
+func TestKeysNamespace(t *testing.T) {
+	client := &mockClient{}
+	client.On("Scan", uint64(0), "ns:prefix*").Return([]string{"ns:prefix1"}, uint64(0), nil)
+	client.On("Eval", string(mqwindow), []string{"ns:prefix1"}).Return([]interface{}{int64(0), int64(1)}, nil)
+
+	m := newMockMQueue(client)
+	m.namespace = core.NamespacePrefix("ns")
+	infos, err := m.Keys(context.Background(), core.KeysRequest{Prefix: "prefix"})
+	assert.Nil(t, err)
+	assert.Equal(t, []core.KeyInfo{{Key: "prefix1", Offset: 0, Next: 1}}, infos)
+}
+
+func TestInspect(t *testing.T) {
+	client := &mockClient{}
+	client.On("Eval", string(mqinspect), []string{"key"}).Return([]interface{}{
+		`{"offset":2,"set":true,"discarded":true,"value_type":"type","value":"\"a\""}`,
+		`{"offset":3,"set":true,"discarded":false,"value_type":"type","value":"\"b\""}`,
+		`{"offset":4,"set":false,"discarded":false}`,
+	}, nil)
+
+	m := newMockMQueue(client)
+	m.tracker = stats.NewMethodTracker(inspect)
+	inspection, err := m.Inspect(context.Background(), core.InspectRequest{Key: "key", Offset: 3, Count: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, core.Inspection{
+		KeyInfo:   core.KeyInfo{Key: "key", Offset: 2, Next: 5},
+		Elements:  1,
+		Unset:     1,
+		Discarded: 1,
+		Slots: []core.Slot{{
+			Offset:  3,
+			Set:     true,
+			Element: core.Element{Offset: 3, Type: "type", Value: "b"},
+		}},
+	}, inspection)
+}
+
+func TestInspectNotFound(t *testing.T) {
+	client := &mockClient{}
+	client.On("Eval", string(mqinspect), []string{"key"}).Return([]interface{}{}, nil)
+
+	m := newMockMQueue(client)
+	m.tracker = stats.NewMethodTracker(inspect)
+	_, err := m.Inspect(context.Background(), core.InspectRequest{Key: "key"})
+	assert.Equal(t, ErrQueueNotFound, err)
+}
@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"sync/atomic"
+)
+
+// Gauge is used to keep track of a value that can
+// go up and down, such as the number of elements in
+// a queue
+type Gauge struct {
+	value int64
+}
+
+// Set sets the gauge to the provided value
+func (g *Gauge) Set(value int64) {
+	atomic.StoreInt64(&g.value, value)
+}
+
+// Add adds delta to the gauge, which may be negative,
+// and returns the new value
+func (g *Gauge) Add(delta int64) int64 {
+	return atomic.AddInt64(&g.value, delta)
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() int64 {
+	return atomic.LoadInt64(&g.value)
+}
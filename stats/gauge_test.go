@@ -0,0 +1,30 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGaugeInit(t *testing.T) {
+	g := Gauge{}
+
+	assert.Equal(t, int64(0), g.Value())
+}
+
+func TestGaugeAdd(t *testing.T) {
+	g := Gauge{}
+
+	assert.Equal(t, int64(2), g.Add(2))
+	assert.Equal(t, int64(-1), g.Add(-3))
+	assert.Equal(t, int64(-1), g.Value())
+}
+
+func TestGaugeSet(t *testing.T) {
+	g := Gauge{}
+
+	g.Add(5)
+	g.Set(3)
+
+	assert.Equal(t, int64(3), g.Value())
+}
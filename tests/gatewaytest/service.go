@@ -2,12 +2,10 @@ package gatewaytest
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"reflect"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/oasislabs/oasis-gateway/auth"
 	authcore "github.com/oasislabs/oasis-gateway/auth/core"
 	"github.com/oasislabs/oasis-gateway/backend"
@@ -53,13 +51,9 @@ func NewServices(ctx context.Context, config *gateway.Config) (*Provider, error)
 	}
 	provider.MustAdd(mqueue)
 
-	var privateKeys []*ecdsa.PrivateKey
-	for _, key := range config.BackendConfig.BackendConfig.(*backend.EthereumConfig).WalletConfig.PrivateKeys {
-		privateKey, err := crypto.HexToECDSA(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read private key with error %s", err.Error())
-		}
-		privateKeys = append(privateKeys, privateKey)
+	privateKeys, err := config.BackendConfig.BackendConfig.(*backend.EthereumConfig).WalletConfig.LoadPrivateKeys()
+	if err != nil {
+		return nil, err
 	}
 
 	executor, err := tx.NewExecutor(ctx, &tx.ExecutorServices{
@@ -94,7 +88,10 @@ func NewServices(ctx context.Context, config *gateway.Config) (*Provider, error)
 	}
 	provider.MustAdd(request)
 
-	authenticator, err := auth.NewAuth(&config.AuthConfig)
+	authenticator, err := auth.NewAuth(ctx, auth.Services{
+		Logger: gateway.RootLogger,
+		MQueue: mqueue,
+	}, &config.AuthConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,245 @@
+package tx
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	stderr "github.com/pkg/errors"
+
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/log"
+)
+
+// BumpProps configures how the wallet owners deal with transactions
+// that are not mined. A transaction that is not executed within Timeout
+// is sent again with the same nonce and a bumped gas price
+type BumpProps struct {
+	// Timeout is the time a transaction can be pending before it is
+	// considered stuck. If not set stuck transactions are not detected
+	// and the receipt of a transaction is only requested once
+	Timeout time.Duration
+
+	// PollInterval is the time between requests for the
+	// receipt of an executed transaction
+	PollInterval time.Duration
+
+	// Percent by which the gas price is increased on every bump. Nodes
+	// usually require at least 10% to replace a pending transaction
+	Percent uint64
+
+	// MaxPrice is the ceiling of the bumped gas price, or of the
+	// bumped max fee for dynamic fee transactions. If not set the
+	// gas price is not bounded
+	MaxPrice *big.Int
+
+	// MaxBumps is the number of times a transaction is sent again
+	// before the owner gives up with ErrTransactionStuck
+	MaxBumps uint
+}
+
+// Enabled returns true if stuck transactions are detected
+func (p BumpProps) Enabled() bool {
+	return p.Timeout > 0
+}
+
+// bumpPrice increases the price by the configured percent and bounds it
+// by the ceiling. It returns false if the price cannot be increased
+func (p BumpProps) bumpPrice(price *big.Int) (*big.Int, bool) {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+p.Percent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(price) <= 0 {
+		bumped.Add(price, big.NewInt(1))
+	}
+
+	if p.MaxPrice != nil && bumped.Cmp(p.MaxPrice) > 0 {
+		bumped = new(big.Int).Set(p.MaxPrice)
+	}
+
+	return bumped, bumped.Cmp(price) > 0
+}
+
+// sendResult is the outcome of one of the transactions
+// broadcast with the same nonce
+type sendResult struct {
+	Response eth.SendTransactionResponse
+	Err      error
+}
+
+// sendWithBump sends a transaction and waits for the node to execute it.
+// The node only responds to a send once the transaction is executed, so
+// the bump timeout applies to the send itself. If the node does not
+// respond before the timeout the transaction is signed again with a
+// bumped gas price and broadcast, while the previous sends keep waiting,
+// until one of the broadcast transactions is executed or the maximum
+// number of bumps is reached
+func (e *WalletOwner) sendWithBump(ctx context.Context, prepared preparedTransaction) transactionResult {
+	// the sends still pending are abandoned once one of
+	// the transactions is executed or the owner gives up
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan sendResult, e.bump.MaxBumps+1)
+	send := func(tx signedTransaction) {
+		go func() {
+			res, err := e.sendSignedTransaction(ctx, tx)
+			results <- sendResult{Response: res, Err: err}
+		}()
+	}
+
+	tx := prepared.Tx
+	send(tx)
+	sent, pending := 1, 1
+
+	timer := time.NewTimer(e.bump.Timeout)
+	defer timer.Stop()
+
+	for bumps := uint(0); ; {
+		select {
+		case res := <-results:
+			pending--
+			if res.Err == nil {
+				return transactionResult{Response: res.Response}
+			}
+			if sent == 1 {
+				// the transaction was never broadcast again, so the
+				// node did not accept it
+				return transactionResult{SendErr: res.Err}
+			}
+
+			// the node may reject a transaction if another one with the
+			// same nonce has just been executed, so the error is only
+			// logged while other transactions are pending
+			e.logger.Debug(ctx, "failed to send transaction", log.MapFields{
+				"call_type": "BumpTransactionFailure",
+				"id":        prepared.Request.ID,
+				"address":   e.wallet.Address().Hex(),
+				"nonce":     tx.Nonce(),
+				"err":       res.Err.Error(),
+			})
+			if pending == 0 {
+				return transactionResult{Err: errors.New(errors.ErrSendTransaction, res.Err)}
+			}
+
+		case <-ctx.Done():
+			return transactionResult{Err: errors.New(errors.ErrTransactionReceipt, ctx.Err())}
+
+		case <-timer.C:
+			if bumps >= e.bump.MaxBumps {
+				e.stuck.Incr()
+				err := errors.New(errors.ErrTransactionStuck, stderr.Errorf(
+					"transaction with nonce %d was not mined after %d gas price bumps", tx.Nonce(), bumps))
+				e.logger.Warn(ctx, "giving up on stuck transaction", log.MapFields{
+					"call_type": "StuckTransactionFailure",
+					"id":        prepared.Request.ID,
+					"address":   e.wallet.Address().Hex(),
+					"nonce":     tx.Nonce(),
+				}, err)
+				return transactionResult{Err: err}
+			}
+			bumps++
+			timer.Reset(e.bump.Timeout)
+
+			bumped, ok, err := e.bumpTransaction(tx)
+			if err != nil {
+				return transactionResult{Err: errors.New(errors.ErrSignedTx, err)}
+			}
+			if !ok {
+				// the gas price already reached the ceiling so there is
+				// nothing to gain from sending the transaction again
+				continue
+			}
+
+			hash := bumped.Hash()
+			send(bumped)
+			sent++
+			pending++
+			e.bumps.Incr()
+			e.logger.Info(ctx, "transaction stuck, sent again with bumped gas price", log.MapFields{
+				"call_type": "BumpTransactionSuccess",
+				"id":        prepared.Request.ID,
+				"address":   e.wallet.Address().Hex(),
+				"nonce":     tx.Nonce(),
+				"hash":      hash.Hex(),
+			})
+			tx = bumped
+		}
+	}
+}
+
+// awaitReceipt retrieves the receipt of an executed transaction. If
+// stuck transactions are detected the receipt is polled until the bump
+// timeout, since the node may not have stored the receipt yet
+func (e *WalletOwner) awaitReceipt(ctx context.Context, hash string) (*types.Receipt, errors.Err) {
+	if !e.bump.Enabled() {
+		return e.transactionReceipt(ctx, hash)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.bump.Timeout)
+	defer cancel()
+
+	for {
+		pctx, pcancel := context.WithTimeout(ctx, e.bump.PollInterval)
+		receipt, err := e.client.TransactionReceipt(pctx, common.HexToHash(hash))
+		pcancel()
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New(errors.ErrTransactionReceipt, ctx.Err())
+		case <-time.After(e.bump.PollInterval):
+		}
+	}
+}
+
+// bumpTransaction signs the transaction again with the same nonce and
+// a bumped gas price. It returns false if the price cannot be bumped
+func (e *WalletOwner) bumpTransaction(tx signedTransaction) (signedTransaction, bool, error) {
+	switch tx := tx.(type) {
+	case *types.Transaction:
+		price, ok := e.bump.bumpPrice(tx.GasPrice())
+		if !ok {
+			return nil, false, nil
+		}
+
+		var bumped *types.Transaction
+		if tx.To() == nil {
+			bumped = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), price, tx.Data())
+		} else {
+			bumped = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), price, tx.Data())
+		}
+
+		signed, err := e.wallet.SignTransaction(bumped)
+		if err != nil {
+			return nil, false, err
+		}
+		return signed, true, nil
+
+	case *DynamicFeeTransaction:
+		feeCap, ok := e.bump.bumpPrice(tx.GasFeeCap())
+		if !ok {
+			return nil, false, nil
+		}
+
+		tip, _ := BumpProps{Percent: e.bump.Percent}.bumpPrice(tx.GasTipCap())
+		if tip.Cmp(feeCap) > 0 {
+			tip = feeCap
+		}
+
+		bumped := NewDynamicFeeTransaction(tx.ChainID(), tx.Nonce(), tx.To(),
+			tx.Value(), tx.Gas(), feeCap, tip, tx.Data())
+		signed, err := e.wallet.SignDynamicFeeTransaction(bumped)
+		if err != nil {
+			return nil, false, err
+		}
+		return signed, true, nil
+
+	default:
+		panic("received unexpected transaction type")
+	}
+}
@@ -0,0 +1,159 @@
+package tx
+
+import (
+	"context"
+	stderr "errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newBumpOwner creates an owner for which the sends of the transactions
+// accepted by the stuck function block until they are abandoned, as
+// oasis_invoke does for a transaction that is not executed
+func newBumpOwner(t *testing.T, props BumpProps, stuck func(*types.Transaction) bool) (*WalletOwner, *ethtest.MockClient) {
+	mockclient := &ethtest.MockClient{}
+	methods := ethtest.OverwriteDefaults(nil)
+	delete(methods, "SendTransaction")
+	ethtest.ImplementMockWithMethods(mockclient, methods)
+	mockclient.On("SendTransaction", mock.Anything, mock.MatchedBy(stuck)).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(eth.SendTransactionResponse{}, stderr.New("context canceled"))
+	mockclient.On("SendTransaction", mock.Anything, mock.Anything).
+		Return(eth.SendTransactionResponse{Status: 1, Output: "0x01", Hash: "0x01"}, nil)
+
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	owner, err := NewWalletOwner(
+		context.TODO(),
+		&WalletOwnerServices{
+			Client:    mockclient,
+			Callbacks: callbackclient,
+			Logger:    Logger,
+		},
+		&WalletOwnerProps{
+			PrivateKey: GetPrivateKey(),
+			Signer:     types.FrontierSigner{},
+			Bump:       props,
+		})
+	assert.Nil(t, err)
+
+	return owner, mockclient
+}
+
+func sentGasPrices(client *ethtest.MockClient) []*big.Int {
+	var prices []*big.Int
+	for _, call := range client.Calls {
+		if call.Method == "SendTransaction" {
+			prices = append(prices, call.Arguments.Get(1).(*types.Transaction).GasPrice())
+		}
+	}
+	return prices
+}
+
+func TestBumpPrice(t *testing.T) {
+	props := BumpProps{Percent: 10, MaxPrice: big.NewInt(120)}
+
+	price, ok := props.bumpPrice(big.NewInt(100))
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(110), price)
+
+	price, ok = props.bumpPrice(big.NewInt(115))
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(120), price)
+
+	_, ok = props.bumpPrice(big.NewInt(120))
+	assert.False(t, ok)
+
+	price, ok = BumpProps{Percent: 10}.bumpPrice(big.NewInt(1))
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(2), price)
+}
+
+func TestOwnerBumpStuckTransaction(t *testing.T) {
+	// the send of the first transaction blocks, so only
+	// the bumped transaction is executed
+	owner, mockclient := newBumpOwner(t, BumpProps{
+		Timeout:      10 * time.Millisecond,
+		PollInterval: time.Millisecond,
+		Percent:      10,
+		MaxBumps:     3,
+	}, func(tx *types.Transaction) bool { return tx.GasPrice().Cmp(big.NewInt(1000000000)) == 0 })
+
+	res, err := owner.executeTransaction(context.TODO(), ExecuteRequest{
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", res.Hash)
+
+	prices := sentGasPrices(mockclient)
+	assert.Equal(t, 2, len(prices))
+	assert.Equal(t, big.NewInt(1100000000), prices[1])
+
+	metrics := owner.getStats(context.TODO())
+	assert.Equal(t, uint64(1), metrics["gasPriceBumps"])
+	assert.Equal(t, uint64(0), metrics["stuckTransactions"])
+	assert.Equal(t, 0, metrics["inFlight"])
+}
+
+func TestOwnerBumpSendFailure(t *testing.T) {
+	owner, mockclient := newBumpOwner(t, BumpProps{
+		Timeout:      10 * time.Millisecond,
+		PollInterval: time.Millisecond,
+		Percent:      10,
+		MaxBumps:     3,
+	}, func(tx *types.Transaction) bool { return false })
+	mockclient.ExpectedCalls = nil
+	mockclient.On("SendTransaction", mock.Anything, mock.Anything).
+		Return(eth.SendTransactionResponse{}, stderr.New("rejected"))
+
+	// a transaction that is not accepted by the node is not bumped
+	prepared, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+
+	result := owner.submitTransaction(context.TODO(), prepared)
+	assert.Equal(t, stderr.New("rejected"), result.SendErr)
+	assert.Equal(t, 1, len(sentGasPrices(mockclient)))
+}
+
+func TestOwnerBumpGivesUp(t *testing.T) {
+	owner, mockclient := newBumpOwner(t, BumpProps{
+		Timeout:      5 * time.Millisecond,
+		PollInterval: time.Millisecond,
+		Percent:      10,
+		MaxPrice:     big.NewInt(1150000000),
+		MaxBumps:     3,
+	}, func(tx *types.Transaction) bool { return true })
+
+	_, err := owner.executeTransaction(context.TODO(), ExecuteRequest{
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, errors.ErrTransactionStuck, err.ErrorCode())
+
+	// the second bump reaches the ceiling and the
+	// third one is not sent
+	prices := sentGasPrices(mockclient)
+	assert.Equal(t, []*big.Int{
+		big.NewInt(1000000000),
+		big.NewInt(1100000000),
+		big.NewInt(1150000000),
+	}, prices)
+
+	metrics := owner.getStats(context.TODO())
+	assert.Equal(t, uint64(2), metrics["gasPriceBumps"])
+	assert.Equal(t, uint64(1), metrics["stuckTransactions"])
+}
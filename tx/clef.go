@@ -0,0 +1,255 @@
+package tx
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	stderr "github.com/pkg/errors"
+)
+
+// DefaultSignerTimeout is the default time the RemoteSigner
+// waits for a response from the signing service
+const DefaultSignerTimeout = 10 * time.Second
+
+// SignTxArgs are the arguments of the Clef account_signTransaction
+// method. Dynamic fee transactions set MaxFeePerGas and
+// MaxPriorityFeePerGas instead of GasPrice
+type SignTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// SignTxResult is the result of the Clef account_signTransaction
+// method. Raw is the encoding of the signed transaction
+type SignTxResult struct {
+	Raw hexutil.Bytes   `json:"raw"`
+	Tx  json.RawMessage `json:"tx,omitempty"`
+}
+
+type rpcCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	Close()
+}
+
+// RemoteSignerProps are the properties used to
+// connect to a remote signing service
+type RemoteSignerProps struct {
+	// URL of the JSON-RPC endpoint of the signing service
+	URL string
+
+	// Timeout for each request to the signing service. If
+	// not set DefaultSignerTimeout is used
+	Timeout time.Duration
+}
+
+// RemoteSigner is a Signer that delegates the signing of transactions
+// to a signing service that implements the account_list and
+// account_signTransaction methods of the Clef JSON-RPC API
+type RemoteSigner struct {
+	client  rpcCaller
+	timeout time.Duration
+}
+
+// DialRemoteSigner connects to the signing service
+func DialRemoteSigner(ctx context.Context, props RemoteSignerProps) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, props.URL)
+	if err != nil {
+		return nil, stderr.Wrapf(err, "failed to connect to signer")
+	}
+
+	return NewRemoteSigner(client, props.Timeout), nil
+}
+
+// NewRemoteSigner creates a new RemoteSigner that uses
+// the provided client to reach the signing service
+func NewRemoteSigner(client *rpc.Client, timeout time.Duration) *RemoteSigner {
+	if timeout <= 0 {
+		timeout = DefaultSignerTimeout
+	}
+
+	return &RemoteSigner{client: client, timeout: timeout}
+}
+
+// Close closes the connection to the signing service
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// Accounts implementation of Signer
+func (s *RemoteSigner) Accounts(ctx context.Context) ([]common.Address, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var accounts []common.Address
+	if err := s.client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		return nil, stderr.Wrap(err, "failed to list signer accounts")
+	}
+
+	return accounts, nil
+}
+
+// SignTransaction implementation of Signer
+func (s *RemoteSigner) SignTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *types.Transaction,
+	chainID *big.Int,
+) (*types.Transaction, error) {
+	args := SignTxArgs{
+		From:     account,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+	if chainID != nil {
+		args.ChainID = (*hexutil.Big)(chainID)
+	}
+
+	res, err := s.signTransaction(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var signed types.Transaction
+	if err := rlp.DecodeBytes(res.Raw, &signed); err != nil {
+		return nil, stderr.Wrap(err, "failed to decode signed transaction")
+	}
+
+	return &signed, nil
+}
+
+// SignDynamicFeeTransaction implementation of Signer
+func (s *RemoteSigner) SignDynamicFeeTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *DynamicFeeTransaction,
+) (*DynamicFeeTransaction, error) {
+	res, err := s.signTransaction(ctx, SignTxArgs{
+		From:                 account,
+		To:                   tx.To(),
+		Gas:                  hexutil.Uint64(tx.Gas()),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+		Value:                hexutil.Big(*tx.Value()),
+		Nonce:                hexutil.Uint64(tx.Nonce()),
+		Data:                 tx.Data(),
+		ChainID:              (*hexutil.Big)(tx.ChainID()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var signed DynamicFeeTransaction
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, stderr.Wrap(err, "failed to decode signed transaction")
+	}
+
+	return &signed, nil
+}
+
+func (s *RemoteSigner) signTransaction(ctx context.Context, args SignTxArgs) (SignTxResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var res SignTxResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return SignTxResult{}, stderr.Wrap(err, "signer failed to sign transaction")
+	}
+
+	return res, nil
+}
+
+// SignerService exposes a Signer through the account_list and
+// account_signTransaction methods of the Clef JSON-RPC API. With a
+// LocalSigner it is an in-process stand-in for a remote signer
+type SignerService struct {
+	signer Signer
+}
+
+// NewSignerServer creates an rpc.Server that serves the
+// Clef JSON-RPC API backed by the provided signer
+func NewSignerServer(signer Signer) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &SignerService{signer: signer}); err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+// List returns the accounts managed by the signer
+func (s *SignerService) List(ctx context.Context) ([]common.Address, error) {
+	return s.signer.Accounts(ctx)
+}
+
+// SignTransaction signs the transaction described by the arguments
+func (s *SignerService) SignTransaction(ctx context.Context, args SignTxArgs) (*SignTxResult, error) {
+	if args.MaxFeePerGas != nil {
+		if args.ChainID == nil || args.MaxPriorityFeePerGas == nil {
+			return nil, stderr.New("chainId and maxPriorityFeePerGas must be set for dynamic fee transactions")
+		}
+
+		tx := NewDynamicFeeTransaction((*big.Int)(args.ChainID), uint64(args.Nonce), args.To,
+			args.Value.ToInt(), uint64(args.Gas), (*big.Int)(args.MaxFeePerGas),
+			(*big.Int)(args.MaxPriorityFeePerGas), args.Data)
+		signed, err := s.signer.SignDynamicFeeTransaction(ctx, args.From, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &SignTxResult{Raw: raw}, nil
+	}
+
+	if args.GasPrice == nil {
+		return nil, stderr.New("gasPrice must be set for legacy transactions")
+	}
+
+	var tx *types.Transaction
+	if args.To == nil {
+		tx = types.NewContractCreation(uint64(args.Nonce), args.Value.ToInt(),
+			uint64(args.Gas), (*big.Int)(args.GasPrice), args.Data)
+	} else {
+		tx = types.NewTransaction(uint64(args.Nonce), *args.To, args.Value.ToInt(),
+			uint64(args.Gas), (*big.Int)(args.GasPrice), args.Data)
+	}
+
+	signed, err := s.signer.SignTransaction(ctx, args.From, tx, (*big.Int)(args.ChainID))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignTxResult{Raw: raw, Tx: data}, nil
+}
@@ -0,0 +1,150 @@
+package tx
+
+import (
+	"context"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	stderr "github.com/pkg/errors"
+
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/log"
+)
+
+// ConfirmationProps configures the number of blocks that have to be
+// mined on top of the block of a transaction before its result is
+// reported, so that results are not reverted by a reorganization
+type ConfirmationProps struct {
+	// Depth is the number of blocks mined after the block of the
+	// transaction. If not set the result of a transaction is
+	// reported as soon as the transaction has a receipt
+	Depth uint64
+
+	// PollInterval is the time between checks of the
+	// block of the transaction
+	PollInterval time.Duration
+
+	// Timeout is the maximum time to wait for the confirmations
+	// before giving up with ErrTransactionNotConfirmed
+	Timeout time.Duration
+}
+
+// Enabled returns true if the executor waits for confirmations
+func (p ConfirmationProps) Enabled() bool {
+	return p.Depth > 0
+}
+
+// awaitConfirmations waits until the configured number of blocks is mined
+// on top of the block of the transaction. The block of the transaction is
+// checked again on every poll, so that a transaction moved to another
+// block by a reorganization waits for the confirmations of its new
+// block. A transaction removed from the chain may be included again in
+// a later block, so it only fails with ErrTransactionDropped if it is
+// still not part of the chain when the timeout expires
+func (s *Executor) awaitConfirmations(ctx context.Context, req ExecuteRequest, res ExecuteResponse) errors.Err {
+	if !s.confirmation.Enabled() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.confirmation.Timeout)
+	defer cancel()
+
+	hash := common.HexToHash(res.Hash)
+	var included *eth.TransactionBlock
+	dropped := false
+	for {
+		block, head, err := s.fetchConfirmations(ctx, hash)
+		switch {
+		case err != nil && stderr.Cause(err) == ethereum.NotFound:
+			if !dropped {
+				s.logger.Warn(ctx, "transaction removed from the chain by a reorganization", log.MapFields{
+					"call_type": "TransactionRemoved",
+					"id":        req.ID,
+					"address":   res.Address,
+					"hash":      res.Hash,
+				})
+			}
+			dropped = true
+
+		case err != nil:
+			// the error may be transient, so the confirmations
+			// keep being checked until the timeout
+			s.logger.Debug(ctx, "failed to check transaction confirmations", log.MapFields{
+				"call_type": "CheckConfirmationsFailure",
+				"id":        req.ID,
+				"hash":      res.Hash,
+				"err":       err.Error(),
+			})
+
+		default:
+			if included != nil && included.Hash != block.Hash {
+				s.logger.Warn(ctx, "transaction moved to another block by a reorganization", log.MapFields{
+					"call_type": "TransactionReorganized",
+					"id":        req.ID,
+					"hash":      res.Hash,
+					"block":     included.Hash.Hex(),
+					"newBlock":  block.Hash.Hex(),
+				})
+			}
+			included = &block
+			dropped = false
+
+			if head >= block.Number+s.confirmation.Depth {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return s.confirmationsFailure(ctx, req, res, dropped)
+		case <-time.After(s.confirmation.PollInterval):
+		}
+	}
+}
+
+// confirmationsFailure returns the error reported when a transaction is
+// not confirmed before the timeout. A transaction that was last seen
+// outside of the chain is reported as dropped
+func (s *Executor) confirmationsFailure(ctx context.Context, req ExecuteRequest, res ExecuteResponse, dropped bool) errors.Err {
+	if dropped {
+		err := errors.New(errors.ErrTransactionDropped, stderr.Errorf(
+			"transaction %s was dropped from the chain", res.Hash))
+		s.logger.Warn(ctx, "transaction dropped from the chain by a reorganization", log.MapFields{
+			"call_type": "TransactionDroppedFailure",
+			"id":        req.ID,
+			"address":   res.Address,
+			"hash":      res.Hash,
+		}, err)
+		return err
+	}
+
+	err := errors.New(errors.ErrTransactionNotConfirmed, stderr.Errorf(
+		"transaction %s did not reach %d confirmations", res.Hash, s.confirmation.Depth))
+	s.logger.Warn(ctx, "giving up on transaction confirmations", log.MapFields{
+		"call_type": "TransactionNotConfirmedFailure",
+		"id":        req.ID,
+		"address":   res.Address,
+		"hash":      res.Hash,
+	}, err)
+	return err
+}
+
+// fetchConfirmations returns the block in which the transaction is
+// included and the number of the latest block. The block of the
+// transaction is fetched last, so that a reorganization that happens
+// in between is noticed before the transaction is confirmed
+func (s *Executor) fetchConfirmations(ctx context.Context, hash common.Hash) (eth.TransactionBlock, uint64, error) {
+	head, err := s.client.BlockByNumber(ctx, nil)
+	if err != nil {
+		return eth.TransactionBlock{}, 0, err
+	}
+
+	block, err := s.client.TransactionBlock(ctx, hash)
+	if err != nil {
+		return eth.TransactionBlock{}, 0, err
+	}
+
+	return block, head.NumberU64(), nil
+}
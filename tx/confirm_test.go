@@ -0,0 +1,190 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	stderr "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	confirmationProps = ConfirmationProps{
+		Depth:        2,
+		PollInterval: time.Millisecond,
+		Timeout:      100 * time.Millisecond,
+	}
+
+	confirmedResponse = ExecuteResponse{
+		Address: address,
+		Hash:    common.HexToHash("0x01").Hex(),
+	}
+)
+
+func newTestConfirmationExecutor(client *ethtest.MockClient) *Executor {
+	return &Executor{
+		client:       client,
+		confirmation: confirmationProps,
+		logger:       Logger,
+	}
+}
+
+func mockClientForConfirmations(client *ethtest.MockClient) {
+	methods := ethtest.OverwriteDefaults(nil)
+	delete(methods, "BlockByNumber")
+	delete(methods, "TransactionBlock")
+	ethtest.ImplementMockWithMethods(client, methods)
+}
+
+func mockHead(client *ethtest.MockClient, number int64) *mock.Call {
+	return client.On("BlockByNumber", mock.Anything, mock.Anything).
+		Return(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number)}), nil)
+}
+
+func mockTransactionBlock(client *ethtest.MockClient, hash string, number uint64) *mock.Call {
+	return client.On("TransactionBlock", mock.Anything, mock.Anything).
+		Return(eth.TransactionBlock{Hash: common.HexToHash(hash), Number: number}, nil)
+}
+
+func TestAwaitConfirmationsDisabled(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	executor := newTestConfirmationExecutor(mockclient)
+	executor.confirmation = ConfirmationProps{}
+
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Nil(t, err)
+	mockclient.AssertNotCalled(t, "TransactionBlock", mock.Anything, mock.Anything)
+}
+
+func TestAwaitConfirmationsConfirmed(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 2).Once()
+	mockHead(mockclient, 3)
+	mockTransactionBlock(mockclient, "0x0a", 1)
+	executor := newTestConfirmationExecutor(mockclient)
+
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Nil(t, err)
+	mockclient.AssertNumberOfCalls(t, "TransactionBlock", 2)
+}
+
+func TestAwaitConfirmationsReorganized(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 2).Once()
+	mockHead(mockclient, 3).Once()
+	mockHead(mockclient, 4)
+	mockTransactionBlock(mockclient, "0x0a", 1).Once()
+	mockTransactionBlock(mockclient, "0x0b", 2)
+	executor := newTestConfirmationExecutor(mockclient)
+
+	// the transaction is moved to block 2, so it needs
+	// block 4 to be confirmed instead of block 3
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Nil(t, err)
+	mockclient.AssertNumberOfCalls(t, "TransactionBlock", 3)
+}
+
+func TestAwaitConfirmationsDropped(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 2)
+	mockTransactionBlock(mockclient, "0x0a", 1).Once()
+	mockclient.On("TransactionBlock", mock.Anything, mock.Anything).
+		Return(eth.TransactionBlock{}, stderr.WithStack(ethereum.NotFound))
+	executor := newTestConfirmationExecutor(mockclient)
+
+	// the transaction keeps being checked until the timeout
+	// in case it is included again in a later block
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Error(t, err)
+	assert.Equal(t, errors.ErrTransactionDropped, err.ErrorCode())
+	assert.True(t, len(mockclient.Calls) > 4)
+}
+
+func TestAwaitConfirmationsReincluded(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 2).Once()
+	mockHead(mockclient, 3).Once()
+	mockHead(mockclient, 4)
+	mockTransactionBlock(mockclient, "0x0a", 1).Once()
+	mockclient.On("TransactionBlock", mock.Anything, mock.Anything).
+		Return(eth.TransactionBlock{}, stderr.WithStack(ethereum.NotFound)).Once()
+	mockTransactionBlock(mockclient, "0x0b", 2)
+	executor := newTestConfirmationExecutor(mockclient)
+
+	// the transaction is removed from the chain and included
+	// again in block 2, so it is confirmed by block 4
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Nil(t, err)
+	mockclient.AssertNumberOfCalls(t, "TransactionBlock", 3)
+}
+
+func TestAwaitConfirmationsRetriesErrors(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 3)
+	mockclient.On("TransactionBlock", mock.Anything, mock.Anything).
+		Return(eth.TransactionBlock{}, stderr.New("connection closed")).Once()
+	mockTransactionBlock(mockclient, "0x0a", 1)
+	executor := newTestConfirmationExecutor(mockclient)
+
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Nil(t, err)
+	mockclient.AssertNumberOfCalls(t, "TransactionBlock", 2)
+}
+
+func TestAwaitConfirmationsTimeout(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientForConfirmations(mockclient)
+	mockHead(mockclient, 2)
+	mockTransactionBlock(mockclient, "0x0a", 1)
+	executor := newTestConfirmationExecutor(mockclient)
+
+	err := executor.awaitConfirmations(context.Background(), ExecuteRequest{}, confirmedResponse)
+	assert.Error(t, err)
+	assert.Equal(t, errors.ErrTransactionNotConfirmed, err.ErrorCode())
+}
+
+func TestExecutorTransactionDropped(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	methods := ethtest.OverwriteDefaults(nil)
+	delete(methods, "TransactionBlock")
+	ethtest.ImplementMockWithMethods(mockclient, methods)
+	mockclient.On("TransactionBlock", mock.Anything, mock.Anything).
+		Return(eth.TransactionBlock{}, stderr.WithStack(ethereum.NotFound))
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys:  []*ecdsa.PrivateKey{GetPrivateKey()},
+		Confirmation: confirmationProps,
+	})
+	assert.Nil(t, err)
+
+	_, eerr := executor.Execute(context.Background(), ExecuteRequest{
+		ID:      1,
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Error(t, eerr)
+	assert.Equal(t, errors.ErrTransactionDropped, eerr.ErrorCode())
+}
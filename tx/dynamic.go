@@ -0,0 +1,249 @@
+package tx
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	stderr "github.com/pkg/errors"
+)
+
+// DynamicFeeTxType is the EIP-2718 type of the EIP-1559
+// dynamic fee transactions
+const DynamicFeeTxType byte = 0x02
+
+// signatureLength is the length of a [R || S || V] signature
+const signatureLength = 65
+
+// DynamicFeeTransaction is an EIP-1559 transaction. The version of
+// go-ethereum used by the gateway predates typed transactions, so
+// their encoding and signing hash are implemented here
+type DynamicFeeTransaction struct {
+	chainID   *big.Int
+	nonce     uint64
+	gasTipCap *big.Int
+	gasFeeCap *big.Int
+	gas       uint64
+	to        *common.Address
+	value     *big.Int
+	data      []byte
+
+	v, r, s *big.Int
+}
+
+// accessTuple is an element of the access list of a transaction.
+// The gateway always sends an empty access list
+type accessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// dynamicFeeTxPayload is the rlp payload of a DynamicFeeTransaction
+// without the signature, which is used for the signing hash
+type dynamicFeeTxPayload struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         []byte
+	Value      *big.Int
+	Data       []byte
+	AccessList []accessTuple
+}
+
+// signedDynamicFeeTxPayload is the rlp payload of a signed
+// DynamicFeeTransaction
+type signedDynamicFeeTxPayload struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         []byte
+	Value      *big.Int
+	Data       []byte
+	AccessList []accessTuple
+	V          *big.Int
+	R          *big.Int
+	S          *big.Int
+}
+
+// NewDynamicFeeTransaction creates a new unsigned DynamicFeeTransaction.
+// If to is nil the transaction is a contract creation
+func NewDynamicFeeTransaction(
+	chainID *big.Int,
+	nonce uint64,
+	to *common.Address,
+	value *big.Int,
+	gas uint64,
+	gasFeeCap *big.Int,
+	gasTipCap *big.Int,
+	data []byte,
+) *DynamicFeeTransaction {
+	return &DynamicFeeTransaction{
+		chainID:   new(big.Int).Set(chainID),
+		nonce:     nonce,
+		gasTipCap: new(big.Int).Set(gasTipCap),
+		gasFeeCap: new(big.Int).Set(gasFeeCap),
+		gas:       gas,
+		to:        to,
+		value:     new(big.Int).Set(value),
+		data:      common.CopyBytes(data),
+	}
+}
+
+func (tx *DynamicFeeTransaction) ChainID() *big.Int   { return new(big.Int).Set(tx.chainID) }
+func (tx *DynamicFeeTransaction) Nonce() uint64       { return tx.nonce }
+func (tx *DynamicFeeTransaction) GasTipCap() *big.Int { return new(big.Int).Set(tx.gasTipCap) }
+func (tx *DynamicFeeTransaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.gasFeeCap) }
+func (tx *DynamicFeeTransaction) Gas() uint64         { return tx.gas }
+func (tx *DynamicFeeTransaction) To() *common.Address { return tx.to }
+func (tx *DynamicFeeTransaction) Value() *big.Int     { return new(big.Int).Set(tx.value) }
+func (tx *DynamicFeeTransaction) Data() []byte        { return common.CopyBytes(tx.data) }
+
+// RawSignatureValues returns the y parity and the r and s
+// values of the signature of the transaction
+func (tx *DynamicFeeTransaction) RawSignatureValues() (*big.Int, *big.Int, *big.Int) {
+	return tx.v, tx.r, tx.s
+}
+
+func (tx *DynamicFeeTransaction) toBytes() []byte {
+	if tx.to == nil {
+		return nil
+	}
+
+	return tx.to.Bytes()
+}
+
+// SigningHash returns the hash that is signed by the
+// sender of the transaction
+func (tx *DynamicFeeTransaction) SigningHash() common.Hash {
+	// encoding the payload cannot fail because all its
+	// fields have a valid rlp encoding
+	payload, _ := rlp.EncodeToBytes(dynamicFeeTxPayload{
+		ChainID:   tx.chainID,
+		Nonce:     tx.nonce,
+		GasTipCap: tx.gasTipCap,
+		GasFeeCap: tx.gasFeeCap,
+		Gas:       tx.gas,
+		To:        tx.toBytes(),
+		Value:     tx.value,
+		Data:      tx.data,
+	})
+
+	return crypto.Keccak256Hash([]byte{DynamicFeeTxType}, payload)
+}
+
+// WithSignature returns a copy of the transaction with the provided
+// signature, which must be in the [R || S || V] format with V
+// being 0 or 1
+func (tx *DynamicFeeTransaction) WithSignature(sig []byte) (*DynamicFeeTransaction, error) {
+	if len(sig) != signatureLength {
+		return nil, stderr.Errorf("wrong size for signature: got %d, want %d", len(sig), signatureLength)
+	}
+
+	if sig[signatureLength-1] > 1 {
+		return nil, stderr.Errorf("invalid signature recovery id %d", sig[signatureLength-1])
+	}
+
+	cpy := *tx
+	cpy.r = new(big.Int).SetBytes(sig[:32])
+	cpy.s = new(big.Int).SetBytes(sig[32:64])
+	cpy.v = new(big.Int).SetBytes(sig[64:])
+	return &cpy, nil
+}
+
+// MarshalBinary returns the EIP-2718 encoding of the signed
+// transaction that is sent to the node
+func (tx *DynamicFeeTransaction) MarshalBinary() ([]byte, error) {
+	if tx.v == nil || tx.r == nil || tx.s == nil {
+		return nil, stderr.New("transaction is not signed")
+	}
+
+	payload, err := rlp.EncodeToBytes(signedDynamicFeeTxPayload{
+		ChainID:   tx.chainID,
+		Nonce:     tx.nonce,
+		GasTipCap: tx.gasTipCap,
+		GasFeeCap: tx.gasFeeCap,
+		Gas:       tx.gas,
+		To:        tx.toBytes(),
+		Value:     tx.value,
+		Data:      tx.data,
+		V:         tx.v,
+		R:         tx.r,
+		S:         tx.s,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{DynamicFeeTxType}, payload...), nil
+}
+
+// Hash returns the hash of the signed transaction
+func (tx *DynamicFeeTransaction) Hash() common.Hash {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}
+	}
+
+	return crypto.Keccak256Hash(data)
+}
+
+// Sender recovers the address of the account that
+// signed the transaction
+func (tx *DynamicFeeTransaction) Sender() (common.Address, error) {
+	if tx.v == nil || tx.r == nil || tx.s == nil {
+		return common.Address{}, stderr.New("transaction is not signed")
+	}
+
+	sig := make([]byte, signatureLength)
+	copy(sig[32-len(tx.r.Bytes()):32], tx.r.Bytes())
+	copy(sig[64-len(tx.s.Bytes()):64], tx.s.Bytes())
+	sig[64] = byte(tx.v.Uint64())
+
+	pub, err := crypto.SigToPub(tx.SigningHash().Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// UnmarshalBinary decodes the EIP-2718 encoding of a signed transaction
+func (tx *DynamicFeeTransaction) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != DynamicFeeTxType {
+		return stderr.New("data is not a dynamic fee transaction")
+	}
+
+	var payload signedDynamicFeeTxPayload
+	if err := rlp.DecodeBytes(data[1:], &payload); err != nil {
+		return err
+	}
+
+	var to *common.Address
+	if len(payload.To) > 0 {
+		if len(payload.To) != common.AddressLength {
+			return stderr.Errorf("invalid recipient length %d", len(payload.To))
+		}
+		addr := common.BytesToAddress(payload.To)
+		to = &addr
+	}
+
+	*tx = DynamicFeeTransaction{
+		chainID:   payload.ChainID,
+		nonce:     payload.Nonce,
+		gasTipCap: payload.GasTipCap,
+		gasFeeCap: payload.GasFeeCap,
+		gas:       payload.Gas,
+		to:        to,
+		value:     payload.Value,
+		data:      payload.Data,
+		v:         payload.V,
+		r:         payload.R,
+		s:         payload.S,
+	}
+	return nil
+}
@@ -3,20 +3,25 @@ package tx
 import (
 	"context"
 	"crypto/ecdsa"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/oasislabs/oasis-gateway/concurrent"
 	"github.com/oasislabs/oasis-gateway/errors"
 	"github.com/oasislabs/oasis-gateway/eth"
 	"github.com/oasislabs/oasis-gateway/log"
 	"github.com/oasislabs/oasis-gateway/stats"
+	stderr "github.com/pkg/errors"
 )
 
 const maxInactivityTimeout = time.Duration(10) * time.Minute
 
+// MaxHDWallets is the maximum number of wallets that
+// the executor derives from an HDWallet
+const MaxHDWallets = 1000
+
 type ExecutorServices struct {
 	Logger    log.Logger
 	Client    eth.Client
@@ -25,22 +30,117 @@ type ExecutorServices struct {
 
 type ExecutorProps struct {
 	PrivateKeys []*ecdsa.PrivateKey
+
+	// Signer signs the transactions of the accounts whose private
+	// keys are not provided to the executor
+	Signer Signer
+
+	// SignerAccounts are the accounts of the Signer used by the
+	// executor. If not set all the accounts of the Signer are used
+	SignerAccounts []common.Address
+
+	// HDWallet derives the keys of additional wallets. The
+	// executor derives HDCount wallets when it starts and
+	// more through DeriveWallets
+	HDWallet *HDWallet
+	HDCount  int
+
+	// GasPrice configures the gas price oracle shared by
+	// all the wallet owners
+	GasPrice GasPriceProps
+
+	// Fee configures the type of the transactions and the
+	// fees of the dynamic fee transactions
+	Fee FeeProps
+
+	// MaxInFlight is the maximum number of transactions that each
+	// wallet can have in flight. If it is greater than 1 transactions
+	// are pipelined, so a wallet sends new transactions while waiting
+	// for the previous ones to complete
+	MaxInFlight int
+
+	// Bump configures the rebroadcast of the transactions
+	// that are not mined
+	Bump BumpProps
+
+	// Selection configures how the wallet that
+	// sends a transaction is chosen
+	Selection SelectionProps
+
+	// Treasury configures the automatic top up of the
+	// wallets that fall below a balance threshold
+	Treasury TreasuryProps
+
+	// Confirmation configures the number of blocks mined on
+	// top of a transaction before its result is reported
+	Confirmation ConfirmationProps
 }
 
 type Executor struct {
-	WalletAddresses []common.Address
-	master          *concurrent.Master
-	client          eth.Client
-	logger          log.Logger
-	callbacks       Callbacks
+	// lock protects addresses, which grows when
+	// wallets are derived at runtime
+	lock      sync.RWMutex
+	addresses []common.Address
+
+	// hdLock serializes the derivation of wallets
+	hdLock  sync.Mutex
+	hd      *HDWallet
+	hdCount int
+
+	master       *concurrent.Master
+	client       eth.Client
+	gasPrice     GasPriceOracle
+	fees         FeeOracle
+	chain        ChainConfig
+	maxInFlight  int
+	bump         BumpProps
+	signer       Signer
+	selector     *walletSelector
+	treasury     *Treasury
+	confirmation ConfirmationProps
+	logger       log.Logger
+	callbacks    Callbacks
 }
 
 func NewExecutor(ctx context.Context, services *ExecutorServices, props *ExecutorProps) (*Executor, error) {
 	s := &Executor{
-		WalletAddresses: make([]common.Address, 0, len(props.PrivateKeys)),
-		client:          services.Client,
-		callbacks:       services.Callbacks,
-		logger:          services.Logger.ForClass("tx/wallet", "Executor"),
+		addresses:    make([]common.Address, 0, len(props.PrivateKeys)),
+		hd:           props.HDWallet,
+		client:       services.Client,
+		gasPrice:     NewGasPriceOracle(services.Client, props.GasPrice),
+		fees:         NewFeeOracle(services.Client, props.Fee),
+		maxInFlight:  props.MaxInFlight,
+		bump:         props.Bump,
+		signer:       props.Signer,
+		selector:     newWalletSelector(props.Selection),
+		confirmation: props.Confirmation,
+		callbacks:    services.Callbacks,
+		logger:       services.Logger.ForClass("tx/wallet", "Executor"),
+	}
+
+	chain, err := DiscoverChainConfig(ctx, services.Client, ChainProps{
+		Fee:             props.Fee,
+		ReplayProtected: props.Signer != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.chain = chain
+
+	if props.Fee.Type == TransactionDynamicFee && !chain.DynamicFee {
+		s.logger.Warn(ctx, "chain does not support base fees, falling back to legacy transactions", log.MapFields{
+			"call_type": "DynamicFeeNotSupported",
+			"chainId":   chain.ChainID.String(),
+		})
+	}
+
+	if props.Treasury.Enabled() {
+		s.treasury = NewTreasury(&TreasuryServices{
+			Client:    services.Client,
+			Callbacks: services.Callbacks,
+			Logger:    services.Logger,
+			GasPrice:  s.gasPrice,
+		}, props.Treasury, chain)
 	}
 
 	s.master = concurrent.NewMaster(concurrent.MasterProps{
@@ -55,12 +155,32 @@ func NewExecutor(ctx context.Context, services *ExecutorServices, props *Executo
 	// Create a worker for each provided private key
 	for _, pk := range props.PrivateKeys {
 		address := crypto.PubkeyToAddress(pk.PublicKey)
-		s.WalletAddresses = append(s.WalletAddresses, address)
-		req := createOwnerRequest{PrivateKey: pk}
-		if err := s.master.Create(ctx, address.Hex(), &req); err != nil {
-			if err := s.master.Stop(); err != nil {
+		if err := s.createOwner(ctx, address, &createOwnerRequest{PrivateKey: pk}); err != nil {
+			return nil, err
+		}
+	}
+
+	// and for each account of the signer
+	if props.Signer != nil {
+		accounts := props.SignerAccounts
+		if len(accounts) == 0 {
+			if accounts, err = props.Signer.Accounts(ctx); err != nil {
+				_ = s.master.Stop()
+				return nil, err
+			}
+		}
+
+		for _, address := range accounts {
+			if err := s.createOwner(ctx, address, &createOwnerRequest{Address: address}); err != nil {
 				return nil, err
 			}
+		}
+	}
+
+	// and for each wallet derived from the HD wallet
+	if props.HDWallet != nil && props.HDCount > 0 {
+		if _, err := s.DeriveWallets(ctx, props.HDCount); err != nil {
+			_ = s.master.Stop()
 			return nil, err
 		}
 	}
@@ -68,6 +188,88 @@ func NewExecutor(ctx context.Context, services *ExecutorServices, props *Executo
 	return s, nil
 }
 
+func (s *Executor) createOwner(ctx context.Context, address common.Address, req *createOwnerRequest) error {
+	if err := s.addOwner(ctx, address, req); err != nil {
+		if err := s.master.Stop(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return nil
+}
+
+// addOwner creates the owner of a wallet. The wallet is added to the
+// selector first so that the owner can report its balance on creation
+func (s *Executor) addOwner(ctx context.Context, address common.Address, req *createOwnerRequest) error {
+	// the treasury cannot top up itself, so its wallet is
+	// rejected before an owner sends transactions with it
+	if s.treasury != nil && address == s.treasury.Address() {
+		return stderr.Errorf("treasury wallet %s is also used to send transactions", address.Hex())
+	}
+
+	s.selector.Add(address)
+	if err := s.master.Create(ctx, address.Hex(), req); err != nil {
+		s.selector.Remove(address)
+		return err
+	}
+
+	s.lock.Lock()
+	s.addresses = append(s.addresses, address)
+	s.lock.Unlock()
+	return nil
+}
+
+// Addresses returns the addresses of the wallets of the executor
+func (s *Executor) Addresses() []common.Address {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	addresses := make([]common.Address, len(s.addresses))
+	copy(addresses, s.addresses)
+	return addresses
+}
+
+// DeriveWallets raises the number of wallets derived from the HD wallet
+// to count, creating a wallet owner for each new wallet. It returns the
+// addresses of the new wallets. The number of derived wallets cannot be
+// lowered, since the wallets may have transactions in flight
+func (s *Executor) DeriveWallets(ctx context.Context, count int) ([]common.Address, errors.Err) {
+	if s.hd == nil {
+		return nil, errors.New(errors.ErrAPINotImplemented, stderr.New("no HD wallet configured"))
+	}
+
+	s.hdLock.Lock()
+	defer s.hdLock.Unlock()
+
+	if count < s.hdCount || count > MaxHDWallets {
+		return nil, errors.New(errors.ErrOutOfRange, stderr.Errorf(
+			"count must be between %d and %d", s.hdCount, MaxHDWallets))
+	}
+
+	addresses := make([]common.Address, 0, count-s.hdCount)
+	for ; s.hdCount < count; s.hdCount++ {
+		pk, err := s.hd.DeriveKey(uint32(s.hdCount))
+		if err != nil {
+			return addresses, errors.New(errors.ErrDeriveWallet, err)
+		}
+
+		address := crypto.PubkeyToAddress(pk.PublicKey)
+		if err := s.addOwner(ctx, address, &createOwnerRequest{PrivateKey: pk}); err != nil {
+			return addresses, errors.New(errors.ErrDeriveWallet, err)
+		}
+
+		addresses = append(addresses, address)
+		s.logger.Info(ctx, "derived wallet", log.MapFields{
+			"call_type": "DeriveWalletSuccess",
+			"index":     s.hdCount,
+			"address":   address.Hex(),
+		})
+	}
+
+	return addresses, nil
+}
+
 func (m *Executor) Name() string {
 	return "tx.Executor"
 }
@@ -85,12 +287,19 @@ func (m *Executor) Stats() stats.Metrics {
 		return metrics
 	}
 
+	if m.treasury != nil {
+		metrics["treasury"] = m.treasury.Stats()
+	}
+
 	for _, res := range responses {
 		if res.Error != nil {
 			metrics[res.Key] = map[string]interface{}{
 				"error": res.Error.Error(),
 			}
 		} else {
+			if wallet, ok := res.Value.(stats.Metrics); ok {
+				wallet["excluded"] = m.selector.Excluded(common.HexToAddress(res.Key))
+			}
 			metrics[res.Key] = res.Value
 		}
 	}
@@ -112,17 +321,33 @@ func (m *Executor) handle(ctx context.Context, ev concurrent.MasterEvent) error
 func (s *Executor) create(ctx context.Context, ev concurrent.CreateWorkerEvent) error {
 	req := ev.Value.(*createOwnerRequest)
 
+	var wallet Wallet
+	if req.PrivateKey == nil {
+		wallet = NewExternalWallet(req.Address, s.signer, s.chain)
+	}
+
+	var callbacks Callbacks = selectorCallbacks{Callbacks: s.callbacks, selector: s.selector}
+	if s.treasury != nil {
+		callbacks = treasuryCallbacks{Callbacks: callbacks, executor: s}
+	}
+
 	owner, err := NewWalletOwner(
 		ctx,
 		&WalletOwnerServices{
 			Client:    s.client,
-			Callbacks: s.callbacks,
+			Callbacks: callbacks,
 			Logger:    s.logger,
+			GasPrice:  s.gasPrice,
+			Fees:      s.fees,
 		},
 		&WalletOwnerProps{
-			PrivateKey: req.PrivateKey,
-			Signer:     types.FrontierSigner{},
-			Nonce:      0,
+			PrivateKey:  req.PrivateKey,
+			Signer:      s.chain.Signer(),
+			Wallet:      wallet,
+			Nonce:       0,
+			Chain:       s.chain,
+			MaxInFlight: s.maxInFlight,
+			Bump:        s.bump,
 		})
 	if err != nil {
 		return err
@@ -142,14 +367,150 @@ func (s *Executor) destroy(ctx context.Context, ev concurrent.DestroyWorkerEvent
 
 // Executes the desired transaction.
 func (s *Executor) Execute(ctx context.Context, req ExecuteRequest) (ExecuteResponse, errors.Err) {
-	res, err := s.master.Execute(ctx, req)
+	var (
+		res ExecuteResponse
+		err errors.Err
+	)
+	if s.maxInFlight > 1 {
+		res, err = s.executePipelined(ctx, req)
+	} else {
+		res, err = s.executeSerial(ctx, req)
+	}
+
 	if err != nil {
-		if e, ok := err.(errors.Err); ok {
+		return ExecuteResponse{}, err
+	}
+
+	if err := s.awaitConfirmations(ctx, req, res); err != nil {
+		return ExecuteResponse{}, err
+	}
+
+	return res, nil
+}
+
+// executeSerial executes a transaction in the worker of the
+// selected wallet owner, one transaction at a time
+func (s *Executor) executeSerial(ctx context.Context, req ExecuteRequest) (ExecuteResponse, errors.Err) {
+	address, err := s.selectWallet(ctx, req)
+	if err != nil {
+		return ExecuteResponse{}, err
+	}
+	defer s.selector.Release(address)
+
+	res, rerr := s.master.Request(ctx, address.Hex(), req)
+	if rerr != nil {
+		if e, ok := rerr.(errors.Err); ok {
 			return ExecuteResponse{}, e
 		}
 
-		return ExecuteResponse{}, errors.New(errors.ErrExecuteTransaction, err)
+		return ExecuteResponse{}, errors.New(errors.ErrExecuteTransaction, rerr)
 	}
 
 	return res.(ExecuteResponse), nil
 }
+
+// executePipelined executes a transaction in three steps. A wallet owner
+// prepares the transaction, which is then sent and awaited outside of the
+// owner's worker so that the owner can prepare other transactions in the
+// meantime, and the result is reported back to the same owner to keep its
+// nonces and balance up to date
+func (s *Executor) executePipelined(ctx context.Context, req ExecuteRequest) (ExecuteResponse, errors.Err) {
+	v, err := concurrent.RetryWithConfig(ctx, concurrent.SupplierFunc(func() (interface{}, error) {
+		prepared, err := s.prepare(ctx, req)
+		if err != nil {
+			return nil, concurrent.ErrCannotRecover{Cause: err}
+		}
+		address := prepared.owner.wallet.Address()
+		defer s.selector.Release(address)
+
+		result := prepared.owner.submitTransaction(ctx, prepared)
+
+		// the owner must mark the nonce of the transaction as consumed
+		// or release it even if the execution is cancelled while the
+		// transaction is submitted, so the result is not reported with
+		// the context of the execution
+		return s.master.Request(context.Background(), address.Hex(), completeRequest{
+			Prepared: prepared,
+			Result:   result,
+		})
+	}), retryConfig)
+
+	if err != nil {
+		return ExecuteResponse{}, asError(err, errors.ErrExecuteTransaction)
+	}
+
+	return v.(ExecuteResponse), nil
+}
+
+// prepare asks the selected wallet owner to prepare the transaction,
+// selecting a wallet again while the selected owner has the maximum
+// number of transactions in flight. The selected wallet is released
+// by the caller once the transaction completes
+func (s *Executor) prepare(ctx context.Context, req ExecuteRequest) (preparedTransaction, error) {
+	v, err := concurrent.RetryWithConfig(ctx, concurrent.SupplierFunc(func() (interface{}, error) {
+		address, err := s.selectWallet(ctx, req)
+		if err != nil {
+			return nil, concurrent.ErrCannotRecover{Cause: err}
+		}
+
+		v, rerr := s.master.Request(ctx, address.Hex(), prepareRequest{Request: req})
+		if rerr != nil {
+			s.selector.Release(address)
+			if e, ok := rerr.(errors.Err); ok && e.ErrorCode() == errors.ErrWalletBusy {
+				return nil, rerr
+			}
+
+			return nil, concurrent.ErrCannotRecover{Cause: rerr}
+		}
+
+		return v, nil
+	}), busyRetryConfig)
+
+	if err != nil {
+		return preparedTransaction{}, err
+	}
+
+	return v.(preparedTransaction), nil
+}
+
+// selectWallet selects the wallet for the request and refreshes the
+// balance of the excluded wallets that may have been funded
+func (s *Executor) selectWallet(ctx context.Context, req ExecuteRequest) (common.Address, errors.Err) {
+	address, stale, err := s.selector.Select(req.AAD)
+	for _, addr := range stale {
+		go s.refreshBalance(addr)
+	}
+
+	if err != nil {
+		s.logger.Warn(ctx, "no wallet available to execute transaction", log.MapFields{
+			"call_type": "SelectWalletFailure",
+			"id":        req.ID,
+		}, err)
+		return common.Address{}, err
+	}
+
+	return address, nil
+}
+
+// refreshBalance asks the owner of the wallet to update its balance,
+// which is reported to the selector through the owner's callbacks
+func (s *Executor) refreshBalance(address common.Address) {
+	ctx := context.Background()
+	if _, err := s.master.Request(ctx, address.Hex(), balanceRequest{}); err != nil {
+		s.selector.RefreshFailed(address)
+		s.logger.Debug(ctx, "failed to refresh wallet balance", log.MapFields{
+			"call_type": "RefreshBalanceFailure",
+			"address":   address.Hex(),
+			"err":       err.Error(),
+		})
+	}
+}
+
+// topUp asks the treasury to top up the wallet and refreshes
+// the balance of the wallet if funds were transferred
+func (s *Executor) topUp(address common.Address) {
+	amount, err := s.treasury.TopUp(context.Background(), address)
+	if err == nil && amount != nil {
+		s.refreshBalance(address)
+	}
+}
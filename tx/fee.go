@@ -0,0 +1,229 @@
+package tx
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	stderr "github.com/pkg/errors"
+
+	"github.com/oasislabs/oasis-gateway/eth"
+)
+
+// DefaultPriorityFee is the priority fee used for dynamic fee
+// transactions when the fee history does not have any reward
+const DefaultPriorityFee int64 = 1000000000
+
+// TransactionType defines the type of the transactions
+// sent by the wallet owners
+type TransactionType string
+
+const (
+	// TransactionLegacy sends legacy transactions with a gas price
+	TransactionLegacy TransactionType = "legacy"
+
+	// TransactionDynamicFee sends EIP-1559 dynamic fee transactions
+	// if the chain supports base fees, and legacy transactions
+	// otherwise
+	TransactionDynamicFee TransactionType = "dynamic"
+)
+
+// FeeProps are the properties used to derive the fees of
+// dynamic fee transactions
+type FeeProps struct {
+	// Type of the transactions. If not set TransactionLegacy is used
+	Type TransactionType
+
+	// Blocks is the number of recent blocks requested
+	// from eth_feeHistory
+	Blocks uint64
+
+	// Percentile of the priority fees of each block used to
+	// derive the priority fee, from 0 to 100
+	Percentile float64
+
+	// BaseFeeMultiplier is applied to the base fee of the next block
+	// to derive the max fee, so that the transaction remains valid
+	// if the base fee increases. If not set 2 is used
+	BaseFeeMultiplier float64
+
+	// MaxFee is the upper bound of the max fee if set
+	MaxFee *big.Int
+
+	// MaxPriorityFee is the upper bound of the priority fee if set
+	MaxPriorityFee *big.Int
+}
+
+// Fees are the fees of a dynamic fee transaction
+type Fees struct {
+	// GasFeeCap is the max fee per gas
+	GasFeeCap *big.Int
+
+	// GasTipCap is the max priority fee per gas
+	GasTipCap *big.Int
+}
+
+// FeeOracle provides the fees used for new dynamic fee transactions
+type FeeOracle interface {
+	// Fees returns the fees to use for the next transaction
+	Fees(ctx context.Context) (Fees, error)
+}
+
+// FeeOracleFunc allows a function to be used as a FeeOracle
+type FeeOracleFunc func(ctx context.Context) (Fees, error)
+
+// Fees implementation of FeeOracle for FeeOracleFunc
+func (f FeeOracleFunc) Fees(ctx context.Context) (Fees, error) {
+	return f(ctx)
+}
+
+// NewFeeOracle creates a FeeOracle that derives the fees
+// from eth_feeHistory
+func NewFeeOracle(client eth.Client, props FeeProps) FeeOracle {
+	multiplier := props.BaseFeeMultiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	blocks := props.Blocks
+	if blocks == 0 {
+		blocks = 1
+	}
+
+	return &feeHistoryOracle{
+		client:         client,
+		blocks:         blocks,
+		percentile:     props.Percentile,
+		multiplier:     multiplier,
+		maxFee:         props.MaxFee,
+		maxPriorityFee: props.MaxPriorityFee,
+	}
+}
+
+// feeHistoryOracle derives the priority fee from the median of the
+// rewards of the recent blocks at the configured percentile, and the
+// max fee from the base fee of the next block
+type feeHistoryOracle struct {
+	client         eth.Client
+	blocks         uint64
+	percentile     float64
+	multiplier     float64
+	maxFee         *big.Int
+	maxPriorityFee *big.Int
+}
+
+func (o *feeHistoryOracle) Fees(ctx context.Context) (Fees, error) {
+	history, err := o.client.FeeHistory(ctx, o.blocks, []float64{o.percentile})
+	if err != nil {
+		return Fees{}, stderr.Wrap(err, "failed to fetch fee history")
+	}
+
+	if len(history.BaseFee) == 0 {
+		return Fees{}, stderr.New("fee history does not have base fees")
+	}
+
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0])
+		}
+	}
+
+	tip := big.NewInt(DefaultPriorityFee)
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool {
+			return rewards[i].Cmp(rewards[j]) < 0
+		})
+		tip = new(big.Int).Set(rewards[len(rewards)/2])
+	}
+
+	if o.maxPriorityFee != nil && tip.Cmp(o.maxPriorityFee) > 0 {
+		tip = new(big.Int).Set(o.maxPriorityFee)
+	}
+
+	// the last base fee of the history is the base
+	// fee of the next block
+	f := new(big.Float).SetInt(history.BaseFee[len(history.BaseFee)-1])
+	f.Mul(f, big.NewFloat(o.multiplier))
+	feeCap, _ := f.Int(nil)
+	feeCap.Add(feeCap, tip)
+
+	if o.maxFee != nil && feeCap.Cmp(o.maxFee) > 0 {
+		feeCap = new(big.Int).Set(o.maxFee)
+	}
+
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+
+	return Fees{GasFeeCap: feeCap, GasTipCap: tip}, nil
+}
+
+// ChainConfig is the configuration of the chain that is
+// relevant for signing transactions
+type ChainConfig struct {
+	// ChainID used for replay protected signatures. If nil
+	// transactions are not replay protected
+	ChainID *big.Int
+
+	// DynamicFee is true if the chain supports base fees
+	// and dynamic fee transactions are sent
+	DynamicFee bool
+}
+
+// Signer returns the signer for the legacy transactions
+// of the chain
+func (c ChainConfig) Signer() types.Signer {
+	if c.ChainID == nil {
+		return types.FrontierSigner{}
+	}
+
+	return types.NewEIP155Signer(c.ChainID)
+}
+
+// ChainProps configures how the configuration
+// of the chain is discovered
+type ChainProps struct {
+	// Fee configures the type of the transactions sent
+	Fee FeeProps
+
+	// ReplayProtected requires legacy transactions to be signed for
+	// the chain ID. External signers such as Clef always sign
+	// replay protected transactions, so it must be set when
+	// transactions are signed by a Signer
+	ReplayProtected bool
+}
+
+// DiscoverChainConfig queries the node for the configuration of the
+// chain. Legacy transactions keep using unprotected signatures unless
+// replay protection is required. For dynamic fee transactions the chain
+// ID is required, and if the chain does not report base fees legacy
+// transactions signed for the chain ID are used instead
+func DiscoverChainConfig(ctx context.Context, client eth.Client, props ChainProps) (ChainConfig, error) {
+	if props.Fee.Type != TransactionDynamicFee && !props.ReplayProtected {
+		return ChainConfig{}, nil
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return ChainConfig{}, stderr.Wrap(err, "failed to fetch chain id")
+	}
+
+	if props.Fee.Type != TransactionDynamicFee {
+		return ChainConfig{ChainID: chainID}, nil
+	}
+
+	history, err := client.FeeHistory(ctx, 1, nil)
+	if err != nil || len(history.BaseFee) == 0 {
+		return ChainConfig{ChainID: chainID}, nil
+	}
+
+	for _, fee := range history.BaseFee {
+		if fee != nil && fee.Sign() > 0 {
+			return ChainConfig{ChainID: chainID, DynamicFee: true}, nil
+		}
+	}
+
+	return ChainConfig{ChainID: chainID}, nil
+}
@@ -0,0 +1,186 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newFeeHistory(baseFees []int64, rewards ...int64) *eth.FeeHistory {
+	history := &eth.FeeHistory{OldestBlock: big.NewInt(1)}
+	for _, fee := range baseFees {
+		history.BaseFee = append(history.BaseFee, big.NewInt(fee))
+	}
+	for _, reward := range rewards {
+		history.Reward = append(history.Reward, []*big.Int{big.NewInt(reward)})
+	}
+	return history
+}
+
+func TestDynamicFeeTransactionSign(t *testing.T) {
+	wallet, err := initializeWallet()
+	assert.Nil(t, err)
+
+	to := common.HexToAddress(address)
+	tx := NewDynamicFeeTransaction(big.NewInt(1), 3, &to, big.NewInt(0),
+		21000, big.NewInt(200), big.NewInt(10), []byte("data"))
+
+	_, err = tx.MarshalBinary()
+	assert.Error(t, err)
+
+	signed, err := wallet.SignDynamicFeeTransaction(tx)
+	assert.Nil(t, err)
+
+	sender, err := signed.Sender()
+	assert.Nil(t, err)
+	assert.Equal(t, wallet.Address(), sender)
+
+	data, err := signed.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, DynamicFeeTxType, data[0])
+
+	var payload signedDynamicFeeTxPayload
+	assert.Nil(t, rlp.DecodeBytes(data[1:], &payload))
+	assert.Equal(t, uint64(3), payload.Nonce)
+	assert.Equal(t, to.Bytes(), payload.To)
+	assert.Equal(t, big.NewInt(200), payload.GasFeeCap)
+	assert.Equal(t, big.NewInt(10), payload.GasTipCap)
+	assert.Equal(t, []byte("data"), payload.Data)
+	assert.Empty(t, payload.AccessList)
+	assert.NotEqual(t, common.Hash{}, signed.Hash())
+}
+
+func TestDynamicFeeTransactionContractCreation(t *testing.T) {
+	wallet, err := initializeWallet()
+	assert.Nil(t, err)
+
+	tx := NewDynamicFeeTransaction(big.NewInt(1), 0, nil, big.NewInt(0),
+		21000, big.NewInt(200), big.NewInt(10), nil)
+	signed, err := wallet.SignDynamicFeeTransaction(tx)
+	assert.Nil(t, err)
+
+	data, err := signed.MarshalBinary()
+	assert.Nil(t, err)
+
+	var payload signedDynamicFeeTxPayload
+	assert.Nil(t, rlp.DecodeBytes(data[1:], &payload))
+	assert.Empty(t, payload.To)
+}
+
+func TestFeeOracle(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("FeeHistory", mock.Anything, uint64(3), []float64{50}).
+		Return(newFeeHistory([]int64{100, 110, 120, 130}, 5, 30, 10), nil)
+
+	fees, err := NewFeeOracle(client, FeeProps{Blocks: 3, Percentile: 50}).Fees(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(10), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(270), fees.GasFeeCap)
+
+	fees, err = NewFeeOracle(client, FeeProps{
+		Blocks:         3,
+		Percentile:     50,
+		MaxFee:         big.NewInt(150),
+		MaxPriorityFee: big.NewInt(8),
+	}).Fees(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(8), fees.GasTipCap)
+	assert.Equal(t, big.NewInt(150), fees.GasFeeCap)
+}
+
+func TestFeeOracleNoBaseFee(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("FeeHistory", mock.Anything, mock.Anything, mock.Anything).
+		Return(newFeeHistory(nil), nil)
+
+	_, err := NewFeeOracle(client, FeeProps{}).Fees(context.Background())
+	assert.Error(t, err)
+}
+
+func TestDiscoverChainConfig(t *testing.T) {
+	client := &ethtest.MockClient{}
+	chain, err := DiscoverChainConfig(context.Background(), client, ChainProps{Fee: FeeProps{Type: TransactionLegacy}})
+	assert.Nil(t, err)
+	assert.Equal(t, ChainConfig{}, chain)
+	assert.Equal(t, types.FrontierSigner{}, chain.Signer())
+
+	// legacy transactions are signed for the chain
+	// ID if replay protection is required
+	client = &ethtest.MockClient{}
+	ethtest.ImplementMock(client)
+	chain, err = DiscoverChainConfig(context.Background(), client, ChainProps{
+		Fee:             FeeProps{Type: TransactionLegacy},
+		ReplayProtected: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ChainConfig{ChainID: big.NewInt(1)}, chain)
+	assert.Equal(t, types.NewEIP155Signer(big.NewInt(1)), chain.Signer())
+	client.AssertNotCalled(t, "FeeHistory", mock.Anything, mock.Anything, mock.Anything)
+
+	client = &ethtest.MockClient{}
+	ethtest.ImplementMock(client)
+	chain, err = DiscoverChainConfig(context.Background(), client, ChainProps{Fee: FeeProps{Type: TransactionDynamicFee}})
+	assert.Nil(t, err)
+	assert.Equal(t, ChainConfig{ChainID: big.NewInt(1), DynamicFee: true}, chain)
+
+	client = &ethtest.MockClient{}
+	ethtest.ImplementMockWithOverwrite(client, ethtest.MockMethods{
+		"FeeHistory": {
+			Arguments: []interface{}{mock.Anything, mock.Anything, mock.Anything},
+			Return:    []interface{}{nil, errors.New("method not found")},
+		},
+	})
+	chain, err = DiscoverChainConfig(context.Background(), client, ChainProps{Fee: FeeProps{Type: TransactionDynamicFee}})
+	assert.Nil(t, err)
+	assert.Equal(t, ChainConfig{ChainID: big.NewInt(1)}, chain)
+	assert.Equal(t, types.NewEIP155Signer(big.NewInt(1)), chain.Signer())
+}
+
+func TestOwnerExecuteDynamicFeeTransaction(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	owner, err := NewWalletOwner(
+		context.TODO(),
+		&WalletOwnerServices{
+			Client:    mockclient,
+			Callbacks: callbackclient,
+			Logger:    Logger,
+			Fees:      NewFeeOracle(mockclient, FeeProps{Type: TransactionDynamicFee}),
+		},
+		&WalletOwnerProps{
+			PrivateKey: GetPrivateKey(),
+			Signer:     types.NewEIP155Signer(big.NewInt(1)),
+			Chain:      ChainConfig{ChainID: big.NewInt(1), DynamicFee: true},
+		})
+	assert.Nil(t, err)
+
+	_, err = owner.executeTransaction(context.TODO(), ExecuteRequest{
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Nil(t, err)
+
+	mockclient.AssertNumberOfCalls(t, "SendTransaction", 0)
+	mockclient.AssertCalled(t, "SendRawTransaction", mock.Anything,
+		mock.MatchedBy(func(data []byte) bool {
+			return data[0] == DynamicFeeTxType
+		}))
+
+	metrics := owner.getStats(context.TODO())
+	assert.Equal(t, "dynamic", metrics["transactionType"])
+	assert.Equal(t, "0x7d2b7500", metrics["maxFeePerGas"])
+	assert.Equal(t, "0x5f5e100", metrics["maxPriorityFeePerGas"])
+}
@@ -0,0 +1,215 @@
+package tx
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	stderr "github.com/pkg/errors"
+
+	"github.com/oasislabs/oasis-gateway/eth"
+)
+
+// DefaultGasPrice is the gas price used when no other
+// gas price is configured or can be estimated
+const DefaultGasPrice int64 = 1000000000
+
+// GasPriceMode defines how the gas price of the
+// transactions is derived
+type GasPriceMode string
+
+const (
+	// GasPriceFixed uses the same configured gas price
+	// for all the transactions
+	GasPriceFixed GasPriceMode = "fixed"
+
+	// GasPriceNode uses the gas price suggested by the
+	// node through eth_gasPrice
+	GasPriceNode GasPriceMode = "node"
+
+	// GasPricePercentile uses a percentile of the gas prices
+	// of the transactions included in the most recent blocks
+	GasPricePercentile GasPriceMode = "percentile"
+)
+
+// GasPriceOracle provides the gas price used for
+// new transactions
+type GasPriceOracle interface {
+	// GasPrice returns the gas price to use for the next transaction
+	GasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// GasPriceOracleFunc allows a function to be used as a GasPriceOracle
+type GasPriceOracleFunc func(ctx context.Context) (*big.Int, error)
+
+// GasPrice implementation of GasPriceOracle for GasPriceOracleFunc
+func (f GasPriceOracleFunc) GasPrice(ctx context.Context) (*big.Int, error) {
+	return f(ctx)
+}
+
+// GasPriceProps are the properties used to create a GasPriceOracle
+type GasPriceProps struct {
+	// Mode used to derive the gas price. If not set GasPriceFixed
+	// is used
+	Mode GasPriceMode
+
+	// Price is the gas price used by GasPriceFixed and by
+	// GasPricePercentile when the recent blocks do not have
+	// any transactions. If not set DefaultGasPrice is used
+	Price *big.Int
+
+	// Blocks is the number of recent blocks inspected by
+	// GasPricePercentile
+	Blocks uint64
+
+	// Percentile of the gas prices of the recent blocks used
+	// by GasPricePercentile, from 0 to 100
+	Percentile uint64
+
+	// MinPrice is the lower bound of the gas price if set
+	MinPrice *big.Int
+
+	// MaxPrice is the upper bound of the gas price if set
+	MaxPrice *big.Int
+
+	// Multiplier is applied to the derived gas price before the
+	// bounds. If not set the gas price is not modified
+	Multiplier float64
+
+	// RefreshInterval is the time for which a derived gas price
+	// is reused before asking the node again
+	RefreshInterval time.Duration
+}
+
+// NewGasPriceOracle creates the GasPriceOracle defined by the
+// props. The multiplier and the bounds are applied to the
+// gas price of every mode
+func NewGasPriceOracle(client eth.Client, props GasPriceProps) GasPriceOracle {
+	price := big.NewInt(DefaultGasPrice)
+	if props.Price != nil {
+		price = new(big.Int).Set(props.Price)
+	}
+
+	var oracle GasPriceOracle
+	switch props.Mode {
+	case GasPriceNode:
+		oracle = GasPriceOracleFunc(client.SuggestGasPrice)
+	case GasPricePercentile:
+		oracle = &percentileGasPriceOracle{
+			client:     client,
+			blocks:     props.Blocks,
+			percentile: props.Percentile,
+			fallback:   price,
+		}
+	default:
+		oracle = GasPriceOracleFunc(func(context.Context) (*big.Int, error) {
+			return new(big.Int).Set(price), nil
+		})
+	}
+
+	return &boundedGasPriceOracle{
+		oracle:          oracle,
+		min:             props.MinPrice,
+		max:             props.MaxPrice,
+		multiplier:      props.Multiplier,
+		refreshInterval: props.RefreshInterval,
+	}
+}
+
+// boundedGasPriceOracle applies the multiplier and the bounds to
+// the gas price of the underlying oracle and caches the result
+// for the refresh interval
+type boundedGasPriceOracle struct {
+	oracle          GasPriceOracle
+	min             *big.Int
+	max             *big.Int
+	multiplier      float64
+	refreshInterval time.Duration
+
+	mu        sync.Mutex
+	price     *big.Int
+	refreshed time.Time
+}
+
+func (o *boundedGasPriceOracle) GasPrice(ctx context.Context) (*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.price != nil && time.Since(o.refreshed) < o.refreshInterval {
+		return new(big.Int).Set(o.price), nil
+	}
+
+	price, err := o.oracle.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	price = o.bound(price)
+	o.price = price
+	o.refreshed = time.Now()
+	return new(big.Int).Set(price), nil
+}
+
+func (o *boundedGasPriceOracle) bound(price *big.Int) *big.Int {
+	if o.multiplier > 0 && o.multiplier != 1 {
+		f := new(big.Float).SetInt(price)
+		f.Mul(f, big.NewFloat(o.multiplier))
+		price, _ = f.Int(nil)
+	}
+
+	if o.min != nil && price.Cmp(o.min) < 0 {
+		price = new(big.Int).Set(o.min)
+	}
+
+	if o.max != nil && price.Cmp(o.max) > 0 {
+		price = new(big.Int).Set(o.max)
+	}
+
+	return price
+}
+
+// percentileGasPriceOracle derives the gas price from the gas prices
+// of the transactions included in the most recent blocks
+type percentileGasPriceOracle struct {
+	client     eth.Client
+	blocks     uint64
+	percentile uint64
+	fallback   *big.Int
+}
+
+func (o *percentileGasPriceOracle) GasPrice(ctx context.Context) (*big.Int, error) {
+	block, err := o.client.BlockByNumber(ctx, nil)
+	if err != nil {
+		return nil, stderr.Wrap(err, "failed to fetch latest block")
+	}
+
+	var prices []*big.Int
+	for i := uint64(0); i < o.blocks; i++ {
+		for _, tx := range block.Transactions() {
+			prices = append(prices, tx.GasPrice())
+		}
+
+		number := block.Number()
+		if i+1 == o.blocks || number.Sign() == 0 {
+			break
+		}
+
+		block, err = o.client.BlockByNumber(ctx, new(big.Int).Sub(number, big.NewInt(1)))
+		if err != nil {
+			return nil, stderr.Wrapf(err, "failed to fetch block %s", number.String())
+		}
+	}
+
+	if len(prices) == 0 {
+		return new(big.Int).Set(o.fallback), nil
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+
+	index := (uint64(len(prices)) - 1) * o.percentile / 100
+	return new(big.Int).Set(prices[index]), nil
+}
@@ -0,0 +1,143 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newBlock(number int64, gasPrices ...int64) *types.Block {
+	var txs []*types.Transaction
+	for i, price := range gasPrices {
+		txs = append(txs, types.NewTransaction(uint64(i), common.HexToAddress(address),
+			big.NewInt(0), 21000, big.NewInt(price), nil))
+	}
+
+	return types.NewBlock(&types.Header{Number: big.NewInt(number)}, txs, nil, nil)
+}
+
+func TestGasPriceOracleFixed(t *testing.T) {
+	oracle := NewGasPriceOracle(&ethtest.MockClient{}, GasPriceProps{
+		Mode:  GasPriceFixed,
+		Price: big.NewInt(10),
+	})
+
+	price, err := oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(10), price)
+
+	oracle = NewGasPriceOracle(&ethtest.MockClient{}, GasPriceProps{})
+	price, err = oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(DefaultGasPrice), price)
+}
+
+func TestGasPriceOracleNodeBounds(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("SuggestGasPrice", mock.Anything).Return(big.NewInt(100), nil).Once()
+	client.On("SuggestGasPrice", mock.Anything).Return(big.NewInt(10), nil).Once()
+	client.On("SuggestGasPrice", mock.Anything).Return(nil, errors.New("error")).Once()
+
+	oracle := NewGasPriceOracle(client, GasPriceProps{
+		Mode:       GasPriceNode,
+		MinPrice:   big.NewInt(50),
+		MaxPrice:   big.NewInt(120),
+		Multiplier: 1.5,
+	})
+
+	price, err := oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(120), price)
+
+	price, err = oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(50), price)
+
+	_, err = oracle.GasPrice(context.Background())
+	assert.Error(t, err)
+}
+
+func TestGasPriceOracleRefreshInterval(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("SuggestGasPrice", mock.Anything).Return(big.NewInt(100), nil)
+
+	oracle := NewGasPriceOracle(client, GasPriceProps{
+		Mode:            GasPriceNode,
+		RefreshInterval: time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		price, err := oracle.GasPrice(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, big.NewInt(100), price)
+	}
+
+	client.AssertNumberOfCalls(t, "SuggestGasPrice", 1)
+}
+
+func TestGasPriceOraclePercentile(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("BlockByNumber", mock.Anything, (*big.Int)(nil)).Return(newBlock(3, 40, 10), nil)
+	client.On("BlockByNumber", mock.Anything, big.NewInt(2)).Return(newBlock(2), nil)
+	client.On("BlockByNumber", mock.Anything, big.NewInt(1)).Return(newBlock(1, 30, 20, 50), nil)
+
+	oracle := NewGasPriceOracle(client, GasPriceProps{
+		Mode:       GasPricePercentile,
+		Blocks:     3,
+		Percentile: 50,
+	})
+
+	price, err := oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(30), price)
+	client.AssertNumberOfCalls(t, "BlockByNumber", 3)
+}
+
+func TestGasPriceOraclePercentileEmptyBlocks(t *testing.T) {
+	client := &ethtest.MockClient{}
+	client.On("BlockByNumber", mock.Anything, mock.Anything).Return(newBlock(0), nil)
+
+	oracle := NewGasPriceOracle(client, GasPriceProps{
+		Mode:       GasPricePercentile,
+		Price:      big.NewInt(7),
+		Blocks:     10,
+		Percentile: 50,
+	})
+
+	price, err := oracle.GasPrice(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(7), price)
+	client.AssertNumberOfCalls(t, "BlockByNumber", 1)
+}
+
+func TestOwnerGasPriceStats(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	owner, err := newOwner(mockclient)
+	assert.Nil(t, err)
+
+	owner.gasOracle = GasPriceOracleFunc(func(context.Context) (*big.Int, error) {
+		return big.NewInt(0x10), nil
+	})
+
+	_, err = owner.executeTransaction(context.TODO(), ExecuteRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "0x10", owner.getStats(context.TODO())["gasPrice"])
+
+	// a failing oracle keeps the last gas price
+	owner.gasOracle = GasPriceOracleFunc(func(context.Context) (*big.Int, error) {
+		return nil, errors.New("error")
+	})
+
+	_, err = owner.executeTransaction(context.TODO(), ExecuteRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "0x10", owner.getStats(context.TODO())["gasPrice"])
+}
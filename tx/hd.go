@@ -0,0 +1,124 @@
+package tx
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	stderr "github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultHDPath is the default path of the HD wallets. The wallets
+// are derived from the children of the path, so that the first
+// wallet is derived at m/44'/60'/0'/0/0 as most Ethereum wallets do
+const DefaultHDPath = "m/44'/60'/0'/0"
+
+// hardenedKeyStart is the index of the first hardened child key
+const hardenedKeyStart uint32 = 0x80000000
+
+// HDWallet derives the private keys of wallets from a BIP-39 mnemonic
+// following BIP-32. Only the extended key of the base path is kept
+// in memory, the mnemonic and the seed are discarded
+type HDWallet struct {
+	key       []byte
+	chainCode []byte
+}
+
+// NewHDWallet creates an HDWallet that derives the keys of the children
+// of the path of the mnemonic. The words and the checksum of the mnemonic
+// are verified, and the mnemonic and the passphrase are normalized with
+// NFKD before the seed is derived, as BIP-39 requires
+func NewHDWallet(mnemonic, passphrase string, path accounts.DerivationPath) (*HDWallet, error) {
+	mnemonic = strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, norm.NFKD.String(passphrase))
+	if err != nil {
+		return nil, stderr.Wrap(err, "invalid mnemonic")
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	_, _ = mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	if err := verifyKey(new(big.Int).SetBytes(key)); err != nil {
+		return nil, err
+	}
+
+	for _, index := range path {
+		var err error
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, stderr.Wrapf(err, "failed to derive path %s", path.String())
+		}
+	}
+
+	return &HDWallet{key: key, chainCode: chainCode}, nil
+}
+
+// DeriveKey derives the private key of the child of the base
+// path with the provided index
+func (w *HDWallet) DeriveKey(index uint32) (*ecdsa.PrivateKey, error) {
+	if index >= hardenedKeyStart {
+		return nil, stderr.Errorf("index %d is out of range", index)
+	}
+
+	key, _, err := deriveChildKey(w.key, w.chainCode, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.ToECDSA(key)
+}
+
+// deriveChildKey derives the extended private key of the
+// child with the provided index as defined by BIP-32
+func deriveChildKey(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= hardenedKeyStart {
+		data = append([]byte{0}, key...)
+	} else {
+		privateKey, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&privateKey.PublicKey)
+	}
+
+	var ser [4]byte
+	binary.BigEndian.PutUint32(ser[:], index)
+	data = append(data, ser[:]...)
+
+	mac := hmac.New(sha512.New, chainCode)
+	_, _ = mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, stderr.Errorf("derived invalid key for index %d", index)
+	}
+
+	child := new(big.Int).Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, n)
+	if err := verifyKey(child); err != nil {
+		return nil, nil, err
+	}
+
+	return math.PaddedBigBytes(child, 32), sum[32:], nil
+}
+
+func verifyKey(key *big.Int) error {
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return stderr.New("derived invalid key")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/stretchr/testify/assert"
+)
+
+const mnemonic = "test test test test test test test test test test test junk"
+
+var hdAddresses = []common.Address{
+	common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+	common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+	common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"),
+}
+
+func newTestHDWallet(t *testing.T) *HDWallet {
+	path, err := accounts.ParseDerivationPath(DefaultHDPath)
+	assert.Nil(t, err)
+
+	wallet, err := NewHDWallet(mnemonic, "", path)
+	assert.Nil(t, err)
+	return wallet
+}
+
+func TestHDWalletDeriveKey(t *testing.T) {
+	wallet := newTestHDWallet(t)
+
+	for i, address := range hdAddresses {
+		key, err := wallet.DeriveKey(uint32(i))
+		assert.Nil(t, err)
+		assert.Equal(t, address, crypto.PubkeyToAddress(key.PublicKey))
+	}
+
+	_, err := wallet.DeriveKey(hardenedKeyStart)
+	assert.Error(t, err)
+}
+
+func TestHDWalletNormalizesMnemonic(t *testing.T) {
+	path, err := accounts.ParseDerivationPath(DefaultHDPath)
+	assert.Nil(t, err)
+
+	wallet, err := NewHDWallet("  test test test test\ntest test test test test test test junk\n", "", path)
+	assert.Nil(t, err)
+
+	key, err := wallet.DeriveKey(0)
+	assert.Nil(t, err)
+	assert.Equal(t, hdAddresses[0], crypto.PubkeyToAddress(key.PublicKey))
+}
+
+func TestHDWalletInvalidMnemonic(t *testing.T) {
+	path, err := accounts.ParseDerivationPath(DefaultHDPath)
+	assert.Nil(t, err)
+
+	_, err = NewHDWallet("test test test", "", path)
+	assert.Error(t, err)
+
+	// the last word does not match the checksum
+	_, err = NewHDWallet("test test test test test test test test test test test test", "", path)
+	assert.Error(t, err)
+
+	// the word is not in the word list
+	_, err = NewHDWallet("test test test test test test test test test test test jnuk", "", path)
+	assert.Error(t, err)
+}
+
+func TestHDWalletNormalizesPassphrase(t *testing.T) {
+	path, err := accounts.ParseDerivationPath(DefaultHDPath)
+	assert.Nil(t, err)
+
+	// the composed and decomposed forms of the passphrase
+	// derive the same keys
+	composed, err := NewHDWallet(mnemonic, "caf\u00e9", path)
+	assert.Nil(t, err)
+	decomposed, err := NewHDWallet(mnemonic, "cafe\u0301", path)
+	assert.Nil(t, err)
+
+	composedKey, err := composed.DeriveKey(0)
+	assert.Nil(t, err)
+	decomposedKey, err := decomposed.DeriveKey(0)
+	assert.Nil(t, err)
+	assert.Equal(t, composedKey, decomposedKey)
+
+	key, err := newTestHDWallet(t).DeriveKey(0)
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, composedKey)
+}
+
+func TestExecutorDeriveWallets(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		HDWallet:  newTestHDWallet(t),
+		HDCount:   1,
+		Selection: SelectionProps{Strategy: SelectRoundRobin},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, hdAddresses[:1], executor.Addresses())
+
+	derived, err := executor.DeriveWallets(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, hdAddresses[1:], derived)
+	assert.Equal(t, hdAddresses, executor.Addresses())
+
+	// the number of wallets cannot be lowered
+	_, err = executor.DeriveWallets(context.Background(), 2)
+	assert.Equal(t, errors.ErrOutOfRange, err.(errors.Err).ErrorCode())
+
+	derived, err = executor.DeriveWallets(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(derived))
+
+	// the derived wallets send transactions
+	for i := 0; i < 3; i++ {
+		_, err = executor.Execute(context.Background(), ExecuteRequest{
+			Address: address,
+			Data:    []byte("data"),
+		})
+		assert.Nil(t, err)
+	}
+
+	senders := make(map[common.Address]bool)
+	for _, call := range mockclient.Calls {
+		if call.Method == "SendTransaction" {
+			sender, err := types.Sender(types.FrontierSigner{}, call.Arguments.Get(1).(*types.Transaction))
+			assert.Nil(t, err)
+			senders[sender] = true
+		}
+	}
+	for _, address := range hdAddresses {
+		assert.True(t, senders[address])
+	}
+}
+
+func TestExecutorDeriveWalletsNoHDWallet(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: []*ecdsa.PrivateKey{GetPrivateKey()},
+	})
+	assert.Nil(t, err)
+
+	_, err = executor.DeriveWallets(context.Background(), 2)
+	assert.Equal(t, errors.ErrAPINotImplemented, err.(errors.Err).ErrorCode())
+}
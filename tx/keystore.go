@@ -0,0 +1,65 @@
+package tx
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	stderr "github.com/pkg/errors"
+)
+
+// DecryptKeystoreFile decrypts the private key stored in a go-ethereum
+// encrypted JSON keystore file. The key is only kept in memory
+func DecryptKeystoreFile(path, passphrase string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, stderr.Wrapf(err, "failed to read keystore file %s", path)
+	}
+
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, stderr.Wrapf(err, "failed to decrypt keystore file %s", path)
+	}
+
+	return key.PrivateKey, nil
+}
+
+// DecryptKeystoreDir decrypts the private keys stored in the keystore
+// files of a directory, sorted by file name. As go-ethereum does, hidden
+// files, backup files and subdirectories are ignored
+func DecryptKeystoreDir(dir, passphrase string) ([]*ecdsa.PrivateKey, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, stderr.Wrapf(err, "failed to read keystore directory %s", dir)
+	}
+
+	var keys []*ecdsa.PrivateKey
+	for _, file := range files {
+		if !isKeystoreFile(file) {
+			continue
+		}
+
+		key, err := DecryptKeystoreFile(filepath.Join(dir, file.Name()), passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, stderr.Errorf("keystore directory %s has no keystore files", dir)
+	}
+
+	return keys, nil
+}
+
+func isKeystoreFile(file os.FileInfo) bool {
+	name := file.Name()
+	return file.Mode().IsRegular() &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.HasSuffix(name, "~")
+}
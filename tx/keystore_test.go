@@ -0,0 +1,74 @@
+package tx
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func storeKeystoreFile(t *testing.T, dir, passphrase string) (string, common.Address) {
+	address, err := keystore.StoreKey(dir, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	assert.Nil(t, err)
+
+	// keystore files are named after the address of their key
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+hex.EncodeToString(address.Bytes())))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(paths))
+	return paths[0], address
+}
+
+func TestDecryptKeystoreFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path, address := storeKeystoreFile(t, dir, "passphrase")
+
+	key, err := DecryptKeystoreFile(path, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, address, crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = DecryptKeystoreFile(path, "wrong")
+	assert.Error(t, err)
+
+	_, err = DecryptKeystoreFile(filepath.Join(dir, "missing"), "passphrase")
+	assert.Error(t, err)
+}
+
+func TestDecryptKeystoreDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = DecryptKeystoreDir(dir, "passphrase")
+	assert.Error(t, err)
+
+	_, address1 := storeKeystoreFile(t, dir, "passphrase")
+	_, address2 := storeKeystoreFile(t, dir, "passphrase")
+
+	// hidden files, backups and subdirectories are ignored
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("{}"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "backup~"), []byte("{}"), 0600))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+
+	keys, err := DecryptKeystoreDir(dir, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(keys))
+
+	addresses := []common.Address{
+		crypto.PubkeyToAddress(keys[0].PublicKey),
+		crypto.PubkeyToAddress(keys[1].PublicKey),
+	}
+	assert.Contains(t, addresses, address1)
+	assert.Contains(t, addresses, address2)
+
+	_, err = DecryptKeystoreDir(dir, "wrong")
+	assert.Error(t, err)
+}
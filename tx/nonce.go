@@ -0,0 +1,146 @@
+package tx
+
+import (
+	"sort"
+)
+
+// NonceManager allocates consecutive nonces to the transactions
+// of a wallet and keeps track of the transactions in flight, so
+// that several transactions of the same wallet can be sent before
+// the previous ones are completed.
+//
+// A gap is a nonce lower than the next nonce that was allocated
+// but not consumed, because its transaction failed before being
+// accepted by the node. The transactions with higher nonces cannot
+// be included until the gap is filled, so gaps are always allocated
+// before new nonces. NonceManager is not safe for concurrent use,
+// it is expected to be owned by a WalletOwner
+type NonceManager struct {
+	next     uint64
+	inFlight map[uint64]struct{}
+	gaps     []uint64
+	detected uint64
+}
+
+// NewNonceManager creates a new NonceManager that
+// starts allocating at the provided nonce
+func NewNonceManager(nonce uint64) *NonceManager {
+	return &NonceManager{
+		next:     nonce,
+		inFlight: make(map[uint64]struct{}),
+	}
+}
+
+// Next allocates the nonce for a new transaction
+func (m *NonceManager) Next() uint64 {
+	var nonce uint64
+	if len(m.gaps) > 0 {
+		nonce = m.gaps[0]
+		m.gaps = m.gaps[1:]
+	} else {
+		nonce = m.next
+		m.next++
+	}
+
+	m.inFlight[nonce] = struct{}{}
+	return nonce
+}
+
+// Done marks the nonce as consumed by a transaction
+// accepted by the node
+func (m *NonceManager) Done(nonce uint64) {
+	delete(m.inFlight, nonce)
+}
+
+// Release returns the nonce of a transaction that was not accepted by
+// the node. If transactions with higher nonces have been allocated the
+// nonce becomes a gap, which is reported by returning true
+func (m *NonceManager) Release(nonce uint64) bool {
+	if _, ok := m.inFlight[nonce]; !ok {
+		return false
+	}
+	delete(m.inFlight, nonce)
+
+	if nonce+1 == m.next {
+		m.next--
+		// the gaps right below the released nonce are not
+		// gaps anymore once the nonce is returned
+		for len(m.gaps) > 0 && m.gaps[len(m.gaps)-1]+1 == m.next {
+			m.gaps = m.gaps[:len(m.gaps)-1]
+			m.next--
+		}
+		return false
+	}
+
+	m.addGap(nonce)
+	return true
+}
+
+// Reset synchronizes the manager with the nonce reported by the node,
+// which is the nonce of the next transaction the node expects. Nonces
+// lower than it are considered consumed, and the nonces between it and
+// the next nonce that are not in flight are considered gaps
+func (m *NonceManager) Reset(nonce uint64) {
+	gaps := m.gaps[:0]
+	for _, gap := range m.gaps {
+		if gap >= nonce {
+			gaps = append(gaps, gap)
+		}
+	}
+	m.gaps = gaps
+
+	for n := range m.inFlight {
+		if n < nonce {
+			delete(m.inFlight, n)
+		}
+	}
+
+	if m.next <= nonce {
+		m.next = nonce
+		return
+	}
+
+	for n := nonce; n < m.next; n++ {
+		if _, ok := m.inFlight[n]; !ok && !m.isGap(n) {
+			m.addGap(n)
+		}
+	}
+}
+
+// InFlight returns the number of transactions that have
+// been allocated a nonce and have not completed yet
+func (m *NonceManager) InFlight() int {
+	return len(m.inFlight)
+}
+
+// Pending returns the next nonce that will be allocated
+// if there are no gaps
+func (m *NonceManager) Pending() uint64 {
+	return m.next
+}
+
+// Gaps returns the gaps that have not been filled yet
+func (m *NonceManager) Gaps() []uint64 {
+	gaps := make([]uint64, len(m.gaps))
+	copy(gaps, m.gaps)
+	return gaps
+}
+
+// Detected returns the number of gaps detected
+// during the lifetime of the manager
+func (m *NonceManager) Detected() uint64 {
+	return m.detected
+}
+
+func (m *NonceManager) isGap(nonce uint64) bool {
+	i := sort.Search(len(m.gaps), func(i int) bool { return m.gaps[i] >= nonce })
+	return i < len(m.gaps) && m.gaps[i] == nonce
+}
+
+func (m *NonceManager) addGap(nonce uint64) {
+	m.detected++
+	i := sort.Search(len(m.gaps), func(i int) bool { return m.gaps[i] >= nonce })
+	m.gaps = append(m.gaps, 0)
+	copy(m.gaps[i+1:], m.gaps[i:])
+	m.gaps[i] = nonce
+}
@@ -0,0 +1,223 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/oasislabs/oasis-gateway/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNonceManagerNext(t *testing.T) {
+	m := NewNonceManager(5)
+
+	assert.Equal(t, uint64(5), m.Next())
+	assert.Equal(t, uint64(6), m.Next())
+	assert.Equal(t, 2, m.InFlight())
+
+	m.Done(5)
+	m.Done(6)
+	assert.Equal(t, 0, m.InFlight())
+	assert.Equal(t, uint64(7), m.Pending())
+}
+
+func TestNonceManagerReleaseLast(t *testing.T) {
+	m := NewNonceManager(0)
+	m.Next()
+	m.Next()
+
+	assert.False(t, m.Release(1))
+	assert.Equal(t, uint64(1), m.Pending())
+	assert.Empty(t, m.Gaps())
+	assert.Equal(t, uint64(1), m.Next())
+}
+
+func TestNonceManagerReleaseGap(t *testing.T) {
+	m := NewNonceManager(0)
+	m.Next()
+	m.Next()
+	m.Next()
+
+	assert.True(t, m.Release(1))
+	assert.Equal(t, []uint64{1}, m.Gaps())
+	assert.Equal(t, uint64(1), m.Detected())
+
+	// the gap is filled before allocating new nonces
+	assert.Equal(t, uint64(1), m.Next())
+	assert.Equal(t, uint64(3), m.Next())
+	assert.Empty(t, m.Gaps())
+}
+
+func TestNonceManagerReleaseCollapsesGaps(t *testing.T) {
+	m := NewNonceManager(0)
+	m.Next()
+	m.Next()
+	m.Next()
+
+	assert.True(t, m.Release(1))
+	assert.False(t, m.Release(2))
+	assert.Empty(t, m.Gaps())
+	assert.Equal(t, uint64(1), m.Pending())
+	assert.Equal(t, 1, m.InFlight())
+}
+
+func TestNonceManagerReset(t *testing.T) {
+	m := NewNonceManager(0)
+	for i := 0; i < 5; i++ {
+		m.Next()
+	}
+	m.Done(0)
+	m.Done(1)
+	m.Done(3)
+
+	// the node only saw nonces up to 1, so 2 is still in flight and
+	// 3 was not accepted and is a gap
+	m.Reset(2)
+	assert.Equal(t, []uint64{3}, m.Gaps())
+	assert.Equal(t, 2, m.InFlight())
+
+	m.Reset(10)
+	assert.Empty(t, m.Gaps())
+	assert.Equal(t, 0, m.InFlight())
+	assert.Equal(t, uint64(10), m.Next())
+}
+
+func TestOwnerCompleteTransactionReleasesNonce(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMockWithOverwrite(mockclient, ethtest.MockMethods{
+		"SendTransaction": {
+			Arguments: []interface{}{mock.Anything, mock.Anything},
+			Return:    []interface{}{eth.SendTransactionResponse{}, errors.New("error")},
+		},
+	})
+	owner, err := newOwnerWithMaxInFlight(mockclient, 2)
+	assert.Nil(t, err)
+
+	first, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+	second, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+
+	_, err = owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Error(t, err)
+
+	_, err = owner.completeTransaction(context.TODO(), completeRequest{
+		Prepared: first,
+		Result:   owner.submitTransaction(context.TODO(), first),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), owner.getStats(context.TODO())["nonceGaps"])
+
+	// the next transaction fills the gap left by the first one
+	third, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, first.Tx.Nonce(), third.Tx.Nonce())
+	assert.Equal(t, first.Tx.Nonce()+1, second.Tx.Nonce())
+}
+
+func TestOwnerReleaseNonceResyncsGap(t *testing.T) {
+	methods := ethtest.OverwriteDefaults(ethtest.MockMethods{
+		"SendTransaction": {
+			Arguments: []interface{}{mock.Anything, mock.Anything},
+			Return:    []interface{}{eth.SendTransactionResponse{}, errors.New("error")},
+		},
+	})
+	delete(methods, "NonceAt")
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMockWithMethods(mockclient, methods)
+	mockclient.On("NonceAt", mock.Anything, mock.Anything).Return(uint64(1), nil).Once()
+	mockclient.On("NonceAt", mock.Anything, mock.Anything).Return(uint64(2), nil)
+
+	owner, err := newOwnerWithMaxInFlight(mockclient, 2)
+	assert.Nil(t, err)
+
+	first, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+	second, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+
+	_, err = owner.completeTransaction(context.TODO(), completeRequest{
+		Prepared: first,
+		Result:   owner.submitTransaction(context.TODO(), first),
+	})
+	assert.Error(t, err)
+
+	// the node reports that the nonce of the first transaction was
+	// consumed, so the gap is dropped instead of being reused
+	assert.Empty(t, owner.nonces.Gaps())
+	third, err := owner.prepareTransaction(context.TODO(), prepareRequest{Gas: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, second.Tx.Nonce()+1, third.Tx.Nonce())
+}
+
+func newOwnerWithMaxInFlight(client *ethtest.MockClient, maxInFlight int) (*WalletOwner, error) {
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+	return NewWalletOwner(
+		context.TODO(),
+		&WalletOwnerServices{
+			Client:    client,
+			Callbacks: callbackclient,
+			Logger:    Logger,
+		},
+		&WalletOwnerProps{
+			PrivateKey:  GetPrivateKey(),
+			Signer:      types.FrontierSigner{},
+			MaxInFlight: maxInFlight,
+		})
+}
+
+func TestExecutorPipelined(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: []*ecdsa.PrivateKey{GetPrivateKey()},
+		MaxInFlight: 4,
+	})
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			_, err := executor.Execute(context.Background(), ExecuteRequest{
+				ID:      id,
+				Address: address,
+				Data:    []byte("data"),
+			})
+			assert.Nil(t, err)
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	nonces := make(map[uint64]bool)
+	for _, call := range mockclient.Calls {
+		if call.Method == "SendTransaction" {
+			nonces[call.Arguments.Get(1).(*types.Transaction).Nonce()] = true
+		}
+	}
+	assert.Equal(t, 8, len(nonces))
+	for nonce := uint64(1); nonce <= 8; nonce++ {
+		assert.True(t, nonces[nonce])
+	}
+
+	metrics := executor.Stats()[executor.Addresses()[0].Hex()].(stats.Metrics)
+	assert.Equal(t, 0, metrics["inFlight"])
+	assert.Equal(t, uint64(9), metrics["nonce"])
+}
@@ -34,14 +34,16 @@ type Callbacks interface {
 	// WalletOwner realizes it's wallet balance has go down a certain
 	// threshold
 	WalletReachedFundsThreshold(ctx context.Context, body callback.WalletReachedFundsThresholdBody)
+
+	// WalletToppedUp is called when the treasury transfers
+	// funds to a wallet
+	WalletToppedUp(ctx context.Context, body callback.WalletToppedUpBody)
 }
 
 // StatusOK defined by ethereum is the value of status
 // for a transaction that succeeds
 const StatusOK = 1
 
-const gasPrice int64 = 1000000000
-
 var retryConfig = concurrent.RetryConfig{
 	Random:            false,
 	UnlimitedAttempts: false,
@@ -51,41 +53,90 @@ var retryConfig = concurrent.RetryConfig{
 	MaxRetryTimeout:   5 * time.Second,
 }
 
+// busyRetryConfig is used to retry the preparation of a transaction
+// while all the wallet owners have the maximum number of transactions
+// in flight
+var busyRetryConfig = concurrent.RetryConfig{
+	Random:            false,
+	UnlimitedAttempts: false,
+	Attempts:          20,
+	BaseExp:           2,
+	BaseTimeout:       10 * time.Millisecond,
+	MaxRetryTimeout:   time.Second,
+}
+
 type signRequest struct {
 	Transaction *types.Transaction
 }
 
 type createOwnerRequest struct {
 	PrivateKey *ecdsa.PrivateKey
+	Address    common.Address
 }
 
 type statsRequest struct{}
 
+type balanceRequest struct{}
+
 // WalletOwner is the only instance that should interact
 // with a wallet. Its main goal is to send transactions
 // and keep the funding and nonce of the wallet up to
 // date
 type WalletOwner struct {
 	wallet          Wallet
-	nonce           uint64
+	nonces          *NonceManager
+	maxInFlight     int
 	currentBalance  *big.Int
 	startBalance    *big.Int
 	consumedBalance *big.Int
+	gasPrice        *big.Int
+	fees            Fees
+	chain           ChainConfig
 	client          eth.Client
+	gasOracle       GasPriceOracle
+	feeOracle       FeeOracle
 	callbacks       Callbacks
 	logger          log.Logger
+	bump            BumpProps
+	bumps           stats.Counter
+	stuck           stats.Counter
 }
 
 type WalletOwnerServices struct {
 	Client    eth.Client
 	Callbacks Callbacks
 	Logger    log.Logger
+
+	// GasPrice provides the gas price of the transactions. If not
+	// set DefaultGasPrice is used for all the transactions
+	GasPrice GasPriceOracle
+
+	// Fees provides the fees of the dynamic fee transactions. It
+	// must be set if Chain.DynamicFee is set
+	Fees FeeOracle
 }
 
 type WalletOwnerProps struct {
 	PrivateKey *ecdsa.PrivateKey
 	Signer     types.Signer
 	Nonce      uint64
+
+	// Wallet signs the transactions of the owner. If set
+	// PrivateKey and Signer are ignored
+	Wallet Wallet
+
+	// Chain is the configuration of the chain. If Chain.DynamicFee is
+	// set the owner sends dynamic fee transactions
+	Chain ChainConfig
+
+	// MaxInFlight is the maximum number of transactions the owner
+	// can have in flight. If not set only one transaction at a
+	// time is allowed
+	MaxInFlight int
+
+	// Bump configures the rebroadcast of the transactions
+	// that are not mined
+	Bump BumpProps
 }
 
 // NewWalletOwner creates a new instance of a wallet
@@ -96,13 +147,32 @@ func NewWalletOwner(
 	services *WalletOwnerServices,
 	props *WalletOwnerProps,
 ) (*WalletOwner, error) {
-	wallet := NewWallet(props.PrivateKey, props.Signer)
+	wallet := props.Wallet
+	if wallet == nil {
+		wallet = NewWallet(props.PrivateKey, props.Signer)
+	}
+	maxInFlight := props.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+
+	gasOracle := services.GasPrice
+	if gasOracle == nil {
+		gasOracle = NewGasPriceOracle(services.Client, GasPriceProps{Mode: GasPriceFixed})
+	}
+
 	owner := &WalletOwner{
-		wallet:    wallet,
-		nonce:     props.Nonce,
-		client:    services.Client,
-		callbacks: services.Callbacks,
-		logger:    services.Logger.ForClass("tx", "WalletOwner"),
+		wallet:      wallet,
+		nonces:      NewNonceManager(props.Nonce),
+		maxInFlight: maxInFlight,
+		gasPrice:    big.NewInt(DefaultGasPrice),
+		chain:       props.Chain,
+		client:      services.Client,
+		gasOracle:   gasOracle,
+		feeOracle:   services.Fees,
+		callbacks:   services.Callbacks,
+		logger:      services.Logger.ForClass("tx", "WalletOwner"),
+		bump:        props.Bump,
 	}
 
 	if err := owner.updateBalance(ctx); err != nil {
@@ -161,8 +231,14 @@ func (e *WalletOwner) handleRequestEvent(ctx context.Context, ev concurrent.Requ
 		return e.signTransaction(req.Transaction)
 	case statsRequest:
 		return e.getStats(ctx), nil
+	case balanceRequest:
+		return nil, e.updateBalance(ctx)
 	case ExecuteRequest:
 		return e.executeTransaction(ctx, req)
+	case prepareRequest:
+		return e.prepareTransaction(ctx, req)
+	case completeRequest:
+		return e.completeTransaction(ctx, req)
 	default:
 		panic("invalid request received for worker")
 	}
@@ -173,6 +249,21 @@ func (e *WalletOwner) getStats(ctx context.Context) stats.Metrics {
 	metrics["startingBalance"] = fmt.Sprintf("0x%x", e.startBalance)
 	metrics["consumedBalance"] = fmt.Sprintf("0x%x", e.consumedBalance)
 	metrics["currentBalance"] = fmt.Sprintf("0x%x", e.currentBalance)
+	metrics["nonce"] = e.nonces.Pending()
+	metrics["inFlight"] = e.nonces.InFlight()
+	metrics["nonceGaps"] = e.nonces.Detected()
+	metrics["gasPriceBumps"] = e.bumps.Value()
+	metrics["stuckTransactions"] = e.stuck.Value()
+	if e.chain.DynamicFee {
+		metrics["transactionType"] = string(TransactionDynamicFee)
+		if e.fees.GasFeeCap != nil {
+			metrics["maxFeePerGas"] = fmt.Sprintf("0x%x", e.fees.GasFeeCap)
+			metrics["maxPriorityFeePerGas"] = fmt.Sprintf("0x%x", e.fees.GasTipCap)
+		}
+	} else {
+		metrics["transactionType"] = string(TransactionLegacy)
+		metrics["gasPrice"] = fmt.Sprintf("0x%x", e.gasPrice)
+	}
 	return metrics
 }
 
@@ -184,9 +275,7 @@ func (e *WalletOwner) handleErrorEvent(ctx context.Context, ev concurrent.ErrorW
 }
 
 func (e *WalletOwner) transactionNonce() uint64 {
-	nonce := e.nonce
-	e.nonce++
-	return nonce
+	return e.nonces.Next()
 }
 
 func (e *WalletOwner) updateNonce(ctx context.Context) errors.Err {
@@ -201,7 +290,7 @@ func (e *WalletOwner) updateNonce(ctx context.Context) errors.Err {
 		return err
 	}
 
-	e.nonce = nonce
+	e.nonces.Reset(nonce)
 	e.logger.Debug(ctx, "", log.MapFields{
 		"call_type": "NonceSuccess",
 		"address":   address,
@@ -283,19 +372,106 @@ func (e *WalletOwner) estimateGasNonConfidential(ctx context.Context, id uint64,
 	return gas, nil
 }
 
-func (e *WalletOwner) generateAndSignTransaction(ctx context.Context, req sendTransactionRequest, gas uint64) (*types.Transaction, error) {
+// updateGasPrice asks the gas price oracle for the gas price of
+// the next transaction. If the oracle fails the last gas price
+// is kept
+func (e *WalletOwner) updateGasPrice(ctx context.Context) *big.Int {
+	price, err := e.gasOracle.GasPrice(ctx)
+	if err != nil {
+		e.logger.Warn(ctx, "failed to derive gas price, using last gas price", log.MapFields{
+			"call_type": "GasPriceFailure",
+			"address":   e.wallet.Address().Hex(),
+			"gasPrice":  e.gasPrice.String(),
+			"err":       err.Error(),
+		})
+		return new(big.Int).Set(e.gasPrice)
+	}
+
+	e.gasPrice = price
+	return new(big.Int).Set(price)
+}
+
+// updateFees asks the fee oracle for the fees of the next dynamic
+// fee transaction. If the oracle fails the last fees are kept
+func (e *WalletOwner) updateFees(ctx context.Context) (Fees, error) {
+	fees, err := e.feeOracle.Fees(ctx)
+	if err != nil {
+		if e.fees.GasFeeCap == nil {
+			return Fees{}, err
+		}
+
+		e.logger.Warn(ctx, "failed to derive fees, using last fees", log.MapFields{
+			"call_type":            "FeesFailure",
+			"address":              e.wallet.Address().Hex(),
+			"maxFeePerGas":         e.fees.GasFeeCap.String(),
+			"maxPriorityFeePerGas": e.fees.GasTipCap.String(),
+			"err":                  err.Error(),
+		})
+		return e.fees, nil
+	}
+
+	e.fees = fees
+	return fees, nil
+}
+
+// signedTransaction is a transaction signed by the wallet
+// that can be sent to the node
+type signedTransaction interface {
+	Nonce() uint64
+	Hash() common.Hash
+}
+
+func (e *WalletOwner) generateAndSignTransaction(ctx context.Context, req sendTransactionRequest, gas uint64) (signedTransaction, error) {
+	if e.chain.DynamicFee {
+		return e.generateAndSignDynamicFeeTransaction(ctx, req, gas)
+	}
+
+	return e.generateAndSignLegacyTransaction(ctx, req, gas)
+}
+
+func (e *WalletOwner) generateAndSignDynamicFeeTransaction(ctx context.Context, req sendTransactionRequest, gas uint64) (*DynamicFeeTransaction, error) {
+	fees, err := e.updateFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var to *common.Address
+	if len(req.Address) > 0 {
+		address := common.HexToAddress(req.Address)
+		to = &address
+	}
+
+	tx := NewDynamicFeeTransaction(e.chain.ChainID, e.transactionNonce(), to,
+		big.NewInt(0), gas, fees.GasFeeCap, fees.GasTipCap, req.Data)
+	signed, serr := e.wallet.SignDynamicFeeTransaction(tx)
+	if serr != nil {
+		e.releaseNonce(ctx, tx.Nonce())
+		return nil, serr
+	}
+
+	return signed, nil
+}
+
+func (e *WalletOwner) generateAndSignLegacyTransaction(ctx context.Context, req sendTransactionRequest, gas uint64) (*types.Transaction, error) {
 	nonce := e.transactionNonce()
+	gasPrice := e.updateGasPrice(ctx)
 
 	var tx *types.Transaction
 	if len(req.Address) == 0 {
 		tx = types.NewContractCreation(nonce,
-			big.NewInt(0), gas, big.NewInt(gasPrice), req.Data)
+			big.NewInt(0), gas, gasPrice, req.Data)
 	} else {
 		tx = types.NewTransaction(nonce, common.HexToAddress(req.Address),
-			big.NewInt(0), gas, big.NewInt(gasPrice), req.Data)
+			big.NewInt(0), gas, gasPrice, req.Data)
 	}
 
-	return e.wallet.SignTransaction(tx)
+	signed, err := e.wallet.SignTransaction(tx)
+	if err != nil {
+		e.releaseNonce(ctx, nonce)
+		return nil, err
+	}
+
+	return signed, nil
 }
 
 type sendTransactionRequest struct {
@@ -306,159 +482,317 @@ type sendTransactionRequest struct {
 	Data    []byte
 }
 
-func (e *WalletOwner) sendTransaction(
-	ctx context.Context,
-	req sendTransactionRequest,
-) (eth.SendTransactionResponse, errors.Err) {
-	v, err := concurrent.RetryWithConfig(ctx, concurrent.SupplierFunc(func() (interface{}, error) {
-		tx, err := e.generateAndSignTransaction(ctx, req, req.Gas)
-		if err != nil {
-			return ExecuteResponse{}, errors.New(errors.ErrSignedTx, err)
-		}
+// prepareRequest asks the owner to assign a nonce to a transaction
+// and sign it. If Gas is 0 the gas of the transaction is estimated
+type prepareRequest struct {
+	Request ExecuteRequest
+	Gas     uint64
+}
 
-		res, err := e.client.SendTransaction(ctx, tx)
-		if err != nil {
-			switch {
-			case stderr.Is(err, eth.ErrExceedsBalance):
-				e.callbacks.WalletOutOfFunds(ctx, callback.WalletOutOfFundsBody{
-					Address: e.wallet.Address().Hex(),
-				})
-
-				return eth.SendTransactionResponse{},
-					concurrent.ErrCannotRecover{Cause: errors.New(errors.ErrSendTransaction, err)}
-
-			case stderr.Is(err, eth.ErrExceedsBlockLimit):
-				return eth.SendTransactionResponse{},
-					concurrent.ErrCannotRecover{Cause: errors.New(errors.ErrSendTransaction, err)}
-			case stderr.Is(err, eth.ErrInvalidNonce):
-				if err := e.updateNonce(ctx); err != nil {
-					// if we fail to update the nonce we cannot proceed
-					return eth.SendTransactionResponse{},
-						concurrent.ErrCannotRecover{Cause: err}
-				}
-
-				return eth.SendTransactionResponse{}, err
-			default:
-				return eth.SendTransactionResponse{},
-					concurrent.ErrCannotRecover{
-						Cause: errors.New(errors.ErrSendTransaction, err),
-					}
-			}
-		}
+// preparedTransaction is a transaction that has been assigned a
+// nonce and signed by its owner and is ready to be sent
+type preparedTransaction struct {
+	Request sendTransactionRequest
+	Tx      signedTransaction
+	owner   *WalletOwner
+}
 
-		return res, nil
-	}), retryConfig)
+// transactionResult is the outcome of submitting a prepared transaction
+type transactionResult struct {
+	// Response is the response of the node if the
+	// transaction was accepted
+	Response eth.SendTransactionResponse
 
-	if err != nil {
-		if err, ok := err.(errors.Err); ok {
-			return eth.SendTransactionResponse{}, err
-		}
+	// Receipt of the transaction if it was retrieved
+	Receipt *types.Receipt
 
-		return eth.SendTransactionResponse{}, errors.New(errors.ErrSendTransaction, err)
-	}
+	// Address of the service the transaction was sent to,
+	// or of the service deployed by the transaction
+	Address string
 
-	res := v.(eth.SendTransactionResponse)
-	e.callbacks.TransactionCommitted(ctx, callback.TransactionCommittedBody{
-		AAD:     req.AAD,
-		Address: e.wallet.Address().Hex(),
-		Hash:    res.Hash,
-	})
+	// SendErr is set if the node did not accept the transaction
+	SendErr error
 
-	return res, nil
+	// Err is set if the transaction was accepted by
+	// the node but its execution failed
+	Err errors.Err
 }
 
-func (e *WalletOwner) executeTransaction(ctx context.Context, req ExecuteRequest) (ExecuteResponse, errors.Err) {
-	serviceAddress := req.Address
-	gas, err := e.estimateGas(ctx, req.ID, req.Address, req.Data)
-	if err != nil {
-		e.logger.Debug(ctx, "failed to estimate gas", log.MapFields{
-			"call_type": "ExecuteTransactionFailure",
-			"id":        req.ID,
-			"address":   req.Address,
-		}, err)
+// completeRequest reports the result of a prepared
+// transaction back to its owner
+type completeRequest struct {
+	Prepared preparedTransaction
+	Result   transactionResult
+}
 
-		return ExecuteResponse{}, err
+// prepareTransaction estimates the gas of a transaction if needed,
+// allocates its nonce and signs it. It fails with ErrWalletBusy if
+// the owner already has the maximum number of transactions in flight
+func (e *WalletOwner) prepareTransaction(ctx context.Context, req prepareRequest) (preparedTransaction, errors.Err) {
+	if e.nonces.InFlight() >= e.maxInFlight {
+		return preparedTransaction{}, errors.New(errors.ErrWalletBusy,
+			stderr.Errorf("wallet has %d transactions in flight", e.nonces.InFlight()))
 	}
 
-	res, err := e.sendTransaction(ctx, sendTransactionRequest{
-		AAD:     req.AAD,
-		ID:      req.ID,
-		Address: req.Address,
-		Data:    req.Data,
+	gas := req.Gas
+	if gas == 0 {
+		var err errors.Err
+		gas, err = e.estimateGas(ctx, req.Request.ID, req.Request.Address, req.Request.Data)
+		if err != nil {
+			e.logger.Debug(ctx, "failed to estimate gas", log.MapFields{
+				"call_type": "ExecuteTransactionFailure",
+				"id":        req.Request.ID,
+				"address":   req.Request.Address,
+			}, err)
+
+			return preparedTransaction{}, err
+		}
+	}
+
+	sendReq := sendTransactionRequest{
+		AAD:     req.Request.AAD,
+		ID:      req.Request.ID,
+		Address: req.Request.Address,
+		Data:    req.Request.Data,
 		Gas:     gas,
-	})
+	}
+
+	tx, err := e.generateAndSignTransaction(ctx, sendReq, gas)
 	if err != nil {
-		return ExecuteResponse{}, err
+		return preparedTransaction{}, errors.New(errors.ErrSignedTx, err)
 	}
 
-	// failing to update the balance should not fail the execution of
-	// the transaction
-	_ = e.updateBalance(ctx)
+	return preparedTransaction{Request: sendReq, Tx: tx, owner: e}, nil
+}
+
+// submitTransaction sends a prepared transaction and retrieves its
+// receipt. It only uses the client and does not modify the state of the
+// owner, so it can run outside of the owner's worker while the owner
+// prepares other transactions
+func (e *WalletOwner) submitTransaction(ctx context.Context, prepared preparedTransaction) transactionResult {
+	req := prepared.Request
 
+	var result transactionResult
+	if e.bump.Enabled() {
+		result = e.sendWithBump(ctx, prepared)
+	} else {
+		res, err := e.sendSignedTransaction(ctx, prepared.Tx)
+		result = transactionResult{Response: res, SendErr: err}
+	}
+	if result.SendErr != nil || result.Err != nil {
+		return result
+	}
+
+	res := result.Response
+	result.Address = req.Address
 	if res.Status != StatusOK {
 		msg := fmt.Sprintf("transaction receipt has status %d which indicates a transaction execution failure with error %s", res.Status, res.Output)
-		err := errors.New(errors.NewErrorCode(errors.InternalError, 1000, msg), stderr.New(msg))
+		result.Err = errors.New(errors.NewErrorCode(errors.InternalError, 1000, msg), stderr.New(msg))
 		e.logger.Debug(ctx, "transaction execution failed", log.MapFields{
 			"call_type": "ExecuteTransactionFailure",
 			"id":        req.ID,
 			"address":   req.Address,
-		}, err)
+		}, result.Err)
 
-		return ExecuteResponse{}, err
+		return result
 	}
 
-	receipt, err := e.transactionReceipt(ctx, res.Hash)
-	if err != nil {
+	receipt, rerr := e.awaitReceipt(ctx, res.Hash)
+	if rerr != nil {
+		result.Err = rerr
 		e.logger.Debug(ctx, "failure to retrieve transaction receipt", log.MapFields{
 			"call_type": "ExecuteTransactionFailure",
 			"id":        req.ID,
 			"address":   req.Address,
-		}, err)
+		}, rerr)
 
-		return ExecuteResponse{}, err
+		return result
 	}
+	result.Receipt = receipt
 
-	if len(serviceAddress) == 0 {
+	if len(req.Address) == 0 {
 		// retrieve the code for the service to make sure that it has been deployed
 		// successfully
-		code, err := e.getCode(ctx, receipt.ContractAddress)
-		if err != nil {
+		code, cerr := e.getCode(ctx, receipt.ContractAddress)
+		if cerr != nil {
+			result.Err = cerr
 			e.logger.Debug(ctx, "failure to retrieve service code", log.MapFields{
 				"call_type": "ExecuteTransactionFailure",
 				"id":        req.ID,
 				"address":   req.Address,
-			}, err)
+			}, cerr)
 
-			return ExecuteResponse{}, err
+			return result
 		}
 
 		// if the service's code is "0x" it means that the service failed to
 		// deploy which should be returned as an error
 		if len(code) <= 2 {
-			err := errors.New(errors.ErrServiceCodeNotDeployed, stderr.New("service code is 0x"))
+			result.Err = errors.New(errors.ErrServiceCodeNotDeployed, stderr.New("service code is 0x"))
 			e.logger.Debug(ctx, "failure to deploy service code", log.MapFields{
 				"call_type": "ExecuteTransactionFailure",
 				"id":        req.ID,
 				"address":   req.Address,
-			}, err)
+			}, result.Err)
+			return result
+		}
+
+		result.Address = receipt.ContractAddress.Hex()
+	}
+
+	return result
+}
+
+// completeTransaction updates the state of the owner with the result of a
+// prepared transaction. Errors caused by an invalid nonce can be recovered
+// from by preparing the transaction again, all other errors are returned as
+// concurrent.ErrCannotRecover
+func (e *WalletOwner) completeTransaction(ctx context.Context, req completeRequest) (ExecuteResponse, error) {
+	nonce := req.Prepared.Tx.Nonce()
+	result := req.Result
+
+	if err := result.SendErr; err != nil {
+		switch {
+		case stderr.Is(err, eth.ErrExceedsBalance):
+			e.releaseNonce(ctx, nonce)
+			e.callbacks.WalletOutOfFunds(ctx, callback.WalletOutOfFundsBody{
+				Address: e.wallet.Address().Hex(),
+			})
+
+			return ExecuteResponse{},
+				concurrent.ErrCannotRecover{Cause: errors.New(errors.ErrSendTransaction, err)}
+		case stderr.Is(err, eth.ErrInvalidNonce):
+			// the nonce may have been consumed by another transaction or
+			// be ahead of the node, so it is not released but the nonces
+			// are synchronized with the node instead
+			e.nonces.Done(nonce)
+			if err := e.updateNonce(ctx); err != nil {
+				// if we fail to update the nonce we cannot proceed
+				return ExecuteResponse{}, concurrent.ErrCannotRecover{Cause: err}
+			}
+
 			return ExecuteResponse{}, err
+		default:
+			e.releaseNonce(ctx, nonce)
+			return ExecuteResponse{},
+				concurrent.ErrCannotRecover{Cause: errors.New(errors.ErrSendTransaction, err)}
 		}
+	}
+
+	e.nonces.Done(nonce)
+	e.callbacks.TransactionCommitted(ctx, callback.TransactionCommittedBody{
+		AAD:     req.Prepared.Request.AAD,
+		Address: e.wallet.Address().Hex(),
+		Hash:    result.Response.Hash,
+	})
+
+	// failing to update the balance should not fail the execution of
+	// the transaction
+	_ = e.updateBalance(ctx)
 
-		serviceAddress = receipt.ContractAddress.Hex()
+	if result.Receipt != nil {
+		// update the consumed gas
+		var gasUsed big.Int
+		gasUsed.SetUint64(result.Receipt.GasUsed)
+		e.consumedBalance = e.consumedBalance.Add(e.consumedBalance, &gasUsed)
 	}
 
-	// update the consumed gas
-	var gasUsed big.Int
-	gasUsed.SetUint64(receipt.GasUsed)
-	e.consumedBalance = e.consumedBalance.Add(e.consumedBalance, &gasUsed)
+	if result.Err != nil {
+		return ExecuteResponse{}, concurrent.ErrCannotRecover{Cause: result.Err}
+	}
 
 	return ExecuteResponse{
-		Address: serviceAddress,
-		Output:  res.Output,
-		Hash:    res.Hash,
+		Address: result.Address,
+		Output:  result.Response.Output,
+		Hash:    result.Response.Hash,
 	}, nil
 }
 
+// releaseNonce returns the nonce of a transaction that was not accepted
+// by the node. If transactions with higher nonces are in flight the nonce
+// becomes a gap. The transactions after a gap cannot be included until
+// the gap is filled, so the nonces are synchronized with the node right
+// away, which drops the gap if the node consumed the nonce anyway.
+// Otherwise the gap is filled by the next transaction
+func (e *WalletOwner) releaseNonce(ctx context.Context, nonce uint64) {
+	if !e.nonces.Release(nonce) {
+		return
+	}
+
+	// failing to synchronize the nonces leaves the gap to be
+	// filled by the next transaction
+	_ = e.updateNonce(ctx)
+
+	if gaps := e.nonces.Gaps(); len(gaps) > 0 {
+		e.logger.Warn(ctx, "nonce gap detected, the nonce will be reused by the next transaction", log.MapFields{
+			"call_type": "NonceGapDetected",
+			"address":   e.wallet.Address().Hex(),
+			"nonce":     nonce,
+			"gaps":      gaps,
+		})
+	}
+}
+
+func (e *WalletOwner) sendSignedTransaction(ctx context.Context, tx signedTransaction) (eth.SendTransactionResponse, error) {
+	switch tx := tx.(type) {
+	case *types.Transaction:
+		return e.client.SendTransaction(ctx, tx)
+	case *DynamicFeeTransaction:
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return eth.SendTransactionResponse{}, err
+		}
+
+		return e.client.SendRawTransaction(ctx, data)
+	default:
+		panic("received unexpected transaction type")
+	}
+}
+
+// executeTransaction prepares, submits and completes a transaction
+// in the owner's worker, so transactions are executed one at a time
+func (e *WalletOwner) executeTransaction(ctx context.Context, req ExecuteRequest) (ExecuteResponse, errors.Err) {
+	gas, err := e.estimateGas(ctx, req.ID, req.Address, req.Data)
+	if err != nil {
+		e.logger.Debug(ctx, "failed to estimate gas", log.MapFields{
+			"call_type": "ExecuteTransactionFailure",
+			"id":        req.ID,
+			"address":   req.Address,
+		}, err)
+
+		return ExecuteResponse{}, err
+	}
+
+	v, rerr := concurrent.RetryWithConfig(ctx, concurrent.SupplierFunc(func() (interface{}, error) {
+		prepared, err := e.prepareTransaction(ctx, prepareRequest{Request: req, Gas: gas})
+		if err != nil {
+			return ExecuteResponse{}, err
+		}
+
+		return e.completeTransaction(ctx, completeRequest{
+			Prepared: prepared,
+			Result:   e.submitTransaction(ctx, prepared),
+		})
+	}), retryConfig)
+
+	if rerr != nil {
+		return ExecuteResponse{}, asError(rerr, errors.ErrSendTransaction)
+	}
+
+	return v.(ExecuteResponse), nil
+}
+
+// asError returns the errors.Err wrapped by err, or a new
+// errors.Err with the provided code if there is none
+func asError(err error, code errors.ErrorCode) errors.Err {
+	var e errors.Err
+	if stderr.As(err, &e) {
+		return e
+	}
+
+	return errors.New(code, err)
+}
+
 func (e *WalletOwner) getCode(ctx context.Context, addr common.Address) (string, errors.Err) {
 	code, err := e.client.GetCode(ctx, addr)
 	if err != nil {
@@ -21,36 +21,36 @@ const address string = "0x6f6704e5a10332af6672e50b3d9754dc460dfa4d"
 
 func mockClientForNonce(client *ethtest.MockClient) {
 	client.On("EstimateGas",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("ethereum.CallMsg")).
 		Return(uint64(0), nil)
 	client.On("NonceAt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Address")).
 		Return(uint64(1), nil)
 	client.On("GetCode",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Address")).
 		Return("0x0000000000000000000000000000000000000000", nil)
 	client.On("BalanceAt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Address"),
 		mock.AnythingOfType("*big.Int")).
 		Return(big.NewInt(1), nil)
 	client.On("TransactionReceipt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Hash")).
 		Return(&types.Receipt{
 			ContractAddress: common.HexToAddress(strings.Repeat("0", 20)),
 		}, nil)
 	client.On("SendTransaction",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.MatchedBy(func(tx *types.Transaction) bool {
 			return tx.Nonce() == 0
 		})).
 		Return(eth.SendTransactionResponse{}, eth.ErrInvalidNonce)
 	client.On("SendTransaction",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.MatchedBy(func(tx *types.Transaction) bool {
 			return tx.Nonce() == 1
 		})).
@@ -63,26 +63,26 @@ func mockClientForNonce(client *ethtest.MockClient) {
 
 func mockClientForWalletOutOfFundsBodyCallback(client *ethtest.MockClient) {
 	client.On("EstimateGas",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("ethereum.CallMsg")).
 		Return(uint64(0), nil)
 	client.On("NonceAt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Address")).
 		Return(uint64(1), nil)
 	client.On("BalanceAt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Address"),
 		mock.AnythingOfType("*big.Int")).
 		Return(big.NewInt(1), nil)
 	client.On("TransactionReceipt",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.AnythingOfType("common.Hash")).
 		Return(&types.Receipt{
 			ContractAddress: common.HexToAddress(strings.Repeat("0", 20)),
 		}, nil)
 	client.On("SendTransaction",
-		mock.AnythingOfType("*context.emptyCtx"),
+		mock.Anything,
 		mock.Anything).
 		Return(eth.SendTransactionResponse{}, eth.ErrExceedsBalance)
 }
@@ -150,7 +150,7 @@ func TestExecuteTransactionNoAddressBadNonce(t *testing.T) {
 	owner, err := newOwner(mockclient)
 	assert.Nil(t, err)
 
-	owner.nonce = 0
+	owner.nonces = NewNonceManager(0)
 	_, err = owner.executeTransaction(context.TODO(), ExecuteRequest{
 		ID:      0,
 		Address: "",
@@ -167,7 +167,7 @@ func TestExecuteTransactionAddressBadNonce(t *testing.T) {
 	owner, err := newOwner(mockclient)
 	assert.Nil(t, err)
 
-	owner.nonce = 0
+	owner.nonces = NewNonceManager(0)
 	_, err = owner.executeTransaction(context.TODO(), ExecuteRequest{
 		ID:      0,
 		Address: strings.Repeat("0", 20),
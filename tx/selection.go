@@ -0,0 +1,309 @@
+package tx
+
+import (
+	"context"
+	"hash/fnv"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	stderr "github.com/pkg/errors"
+
+	callback "github.com/oasislabs/oasis-gateway/callback/client"
+	"github.com/oasislabs/oasis-gateway/errors"
+)
+
+// balanceRefreshInterval is the minimum time between the balance
+// refreshes of a wallet excluded for having a low balance
+const balanceRefreshInterval = 30 * time.Second
+
+// SelectionStrategy defines how the Executor chooses the
+// wallet that sends a transaction
+type SelectionStrategy string
+
+const (
+	// SelectRoundRobin uses the wallets in turns
+	SelectRoundRobin SelectionStrategy = "round_robin"
+
+	// SelectLeastPending uses the wallet with the fewest
+	// transactions queued or in flight
+	SelectLeastPending SelectionStrategy = "least_pending"
+
+	// SelectHighestBalance uses the wallet with the
+	// highest known balance
+	SelectHighestBalance SelectionStrategy = "highest_balance"
+
+	// SelectStickyAAD always uses the same wallet for the same AAD,
+	// so that the transactions of a user are sent in order. Requests
+	// without an AAD use the wallets in turns
+	SelectStickyAAD SelectionStrategy = "sticky_aad"
+)
+
+// SelectionProps configures how the Executor chooses the
+// wallet that sends a transaction
+type SelectionProps struct {
+	// Strategy used to choose the wallet. If not set
+	// SelectLeastPending is used
+	Strategy SelectionStrategy
+
+	// MinBalance is the balance below which a wallet is not used. If
+	// not set wallets are used regardless of their balance
+	MinBalance *big.Int
+}
+
+type walletState struct {
+	address    common.Address
+	pending    int
+	balance    *big.Int
+	updated    time.Time
+	refreshing bool
+}
+
+// walletSelector keeps track of the known balance and the pending
+// transactions of the wallets of an Executor to choose the wallet
+// that sends a transaction. It is safe for concurrent use
+type walletSelector struct {
+	mu         sync.Mutex
+	strategy   SelectionStrategy
+	minBalance *big.Int
+	wallets    []*walletState
+	index      map[common.Address]*walletState
+	next       int
+}
+
+func newWalletSelector(props SelectionProps) *walletSelector {
+	strategy := props.Strategy
+	if len(strategy) == 0 {
+		strategy = SelectLeastPending
+	}
+
+	return &walletSelector{
+		strategy:   strategy,
+		minBalance: props.MinBalance,
+		index:      make(map[common.Address]*walletState),
+	}
+}
+
+// Add adds a wallet to the selection
+func (s *walletSelector) Add(address common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[address]; ok {
+		return
+	}
+
+	w := &walletState{address: address}
+	s.wallets = append(s.wallets, w)
+	s.index[address] = w
+}
+
+// Remove removes a wallet from the selection
+func (s *walletSelector) Remove(address common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.index[address]; !ok {
+		return
+	}
+
+	delete(s.index, address)
+	for i, w := range s.wallets {
+		if w.address == address {
+			s.wallets = append(s.wallets[:i], s.wallets[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetBalance updates the known balance of a wallet
+func (s *walletSelector) SetBalance(address common.Address, balance *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.index[address]
+	if !ok {
+		return
+	}
+
+	w.balance = new(big.Int).Set(balance)
+	w.updated = time.Now()
+	w.refreshing = false
+}
+
+// RefreshFailed allows the balance of a wallet to be
+// refreshed again after a failed refresh
+func (s *walletSelector) RefreshFailed(address common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.index[address]; ok {
+		w.refreshing = false
+		w.updated = time.Now()
+	}
+}
+
+// Release marks a transaction sent by the wallet as completed
+func (s *walletSelector) Release(address common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.index[address]; ok && w.pending > 0 {
+		w.pending--
+	}
+}
+
+// Excluded returns true if the wallet is not used
+// because its balance is below the threshold
+func (s *walletSelector) Excluded(address common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.index[address]
+	return ok && !s.eligible(w)
+}
+
+// Select chooses the wallet for a transaction issued by the provided
+// AAD and counts the transaction as pending until it is released. It
+// also returns the excluded wallets whose balance should be refreshed
+// in case they have been funded
+func (s *walletSelector) Select(aad string) (common.Address, []common.Address, errors.Err) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stale []common.Address
+	now := time.Now()
+	for _, w := range s.wallets {
+		if !s.eligible(w) && !w.refreshing && now.Sub(w.updated) >= balanceRefreshInterval {
+			w.refreshing = true
+			stale = append(stale, w.address)
+		}
+	}
+
+	var selected *walletState
+	switch {
+	case s.strategy == SelectStickyAAD && len(aad) > 0:
+		selected = s.selectSticky(aad)
+	case s.strategy == SelectLeastPending:
+		selected = s.selectLeastPending()
+	case s.strategy == SelectHighestBalance:
+		selected = s.selectHighestBalance()
+	default:
+		selected = s.selectRoundRobin()
+	}
+
+	if selected == nil {
+		return common.Address{}, stale, errors.New(errors.ErrNoWalletAvailable,
+			stderr.New("no wallet has a balance above the threshold"))
+	}
+
+	selected.pending++
+	return selected.address, stale, nil
+}
+
+func (s *walletSelector) eligible(w *walletState) bool {
+	return s.minBalance == nil || w.balance == nil || w.balance.Cmp(s.minBalance) >= 0
+}
+
+func (s *walletSelector) selectRoundRobin() *walletState {
+	for i := 0; i < len(s.wallets); i++ {
+		w := s.wallets[(s.next+i)%len(s.wallets)]
+		if s.eligible(w) {
+			s.next = (s.next + i + 1) % len(s.wallets)
+			return w
+		}
+	}
+
+	return nil
+}
+
+func (s *walletSelector) selectLeastPending() *walletState {
+	var selected *walletState
+	for _, w := range s.wallets {
+		if s.eligible(w) && (selected == nil || w.pending < selected.pending) {
+			selected = w
+		}
+	}
+
+	return selected
+}
+
+func (s *walletSelector) selectHighestBalance() *walletState {
+	var selected *walletState
+	for _, w := range s.wallets {
+		if !s.eligible(w) {
+			continue
+		}
+
+		if selected == nil || compareBalance(w.balance, selected.balance) > 0 ||
+			(compareBalance(w.balance, selected.balance) == 0 && w.pending < selected.pending) {
+			selected = w
+		}
+	}
+
+	return selected
+}
+
+// selectSticky uses rendezvous hashing to select the wallet for the AAD,
+// so that adding or removing a wallet only moves the AADs assigned to
+// that wallet. If the wallet of the AAD is not eligible the AAD falls
+// back to the eligible wallet with the next highest score
+func (s *walletSelector) selectSticky(aad string) *walletState {
+	var selected *walletState
+	var highest uint64
+	for _, w := range s.wallets {
+		if !s.eligible(w) {
+			continue
+		}
+
+		score := stickyScore(aad, w.address)
+		if selected == nil || score > highest {
+			selected = w
+			highest = score
+		}
+	}
+
+	return selected
+}
+
+// stickyScore is the score of a wallet for an AAD
+// in the rendezvous hashing of selectSticky
+func stickyScore(aad string, address common.Address) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(aad))
+	_, _ = h.Write(address.Bytes())
+	return h.Sum64()
+}
+
+// compareBalance compares two balances where
+// an unknown balance is the lowest
+func compareBalance(a, b *big.Int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Cmp(b)
+	}
+}
+
+// selectorCallbacks keeps the balances known by the walletSelector
+// up to date with the balances reported by the wallet owners
+type selectorCallbacks struct {
+	Callbacks
+	selector *walletSelector
+}
+
+func (c selectorCallbacks) WalletOutOfFunds(ctx context.Context, body callback.WalletOutOfFundsBody) {
+	c.selector.SetBalance(common.HexToAddress(body.Address), big.NewInt(0))
+	c.Callbacks.WalletOutOfFunds(ctx, body)
+}
+
+func (c selectorCallbacks) WalletReachedFundsThreshold(ctx context.Context, body callback.WalletReachedFundsThresholdBody) {
+	c.selector.SetBalance(common.HexToAddress(body.Address), body.After)
+	c.Callbacks.WalletReachedFundsThreshold(ctx, body)
+}
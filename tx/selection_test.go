@@ -0,0 +1,242 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/oasislabs/oasis-gateway/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	wallet1 = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	wallet2 = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	wallet3 = common.HexToAddress("0x0000000000000000000000000000000000000003")
+)
+
+func newTestSelector(props SelectionProps) *walletSelector {
+	s := newWalletSelector(props)
+	s.Add(wallet1)
+	s.Add(wallet2)
+	s.Add(wallet3)
+	return s
+}
+
+func selectWallet(t *testing.T, s *walletSelector, aad string) common.Address {
+	address, _, err := s.Select(aad)
+	assert.Nil(t, err)
+	return address
+}
+
+func TestSelectRoundRobin(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectRoundRobin})
+
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+	assert.Equal(t, wallet2, selectWallet(t, s, ""))
+	assert.Equal(t, wallet3, selectWallet(t, s, ""))
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+}
+
+func TestSelectRemove(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectRoundRobin})
+
+	s.Remove(wallet2)
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+	assert.Equal(t, wallet3, selectWallet(t, s, ""))
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+}
+
+func TestSelectLeastPending(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectLeastPending})
+
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+	assert.Equal(t, wallet2, selectWallet(t, s, ""))
+	assert.Equal(t, wallet3, selectWallet(t, s, ""))
+
+	s.Release(wallet2)
+	assert.Equal(t, wallet2, selectWallet(t, s, ""))
+}
+
+func TestSelectHighestBalance(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectHighestBalance})
+	s.SetBalance(wallet1, big.NewInt(10))
+	s.SetBalance(wallet2, big.NewInt(30))
+	s.SetBalance(wallet3, big.NewInt(20))
+
+	assert.Equal(t, wallet2, selectWallet(t, s, ""))
+
+	s.SetBalance(wallet2, big.NewInt(5))
+	assert.Equal(t, wallet3, selectWallet(t, s, ""))
+}
+
+func TestSelectStickyAAD(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectStickyAAD})
+
+	selected := selectWallet(t, s, "user")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, selected, selectWallet(t, s, "user"))
+	}
+
+	// requests without an AAD use the wallets in turns
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+	assert.Equal(t, wallet2, selectWallet(t, s, ""))
+}
+
+func TestSelectStickyAADWalletChanges(t *testing.T) {
+	s := newTestSelector(SelectionProps{Strategy: SelectStickyAAD})
+
+	aads := make([]string, 100)
+	assigned := make(map[string]common.Address)
+	for i := range aads {
+		aads[i] = fmt.Sprintf("user%d", i)
+		assigned[aads[i]] = selectWallet(t, s, aads[i])
+	}
+
+	// adding a wallet only moves AADs to the new wallet
+	wallet4 := common.HexToAddress("0x0000000000000000000000000000000000000004")
+	s.Add(wallet4)
+	for _, aad := range aads {
+		if selected := selectWallet(t, s, aad); selected != wallet4 {
+			assert.Equal(t, assigned[aad], selected)
+		}
+	}
+
+	// removing a wallet only moves the AADs assigned to it
+	s.Remove(wallet4)
+	s.Remove(wallet2)
+	for _, aad := range aads {
+		selected := selectWallet(t, s, aad)
+		assert.NotEqual(t, wallet2, selected)
+		if assigned[aad] != wallet2 {
+			assert.Equal(t, assigned[aad], selected)
+		}
+	}
+}
+
+func TestSelectExcludesLowBalance(t *testing.T) {
+	s := newTestSelector(SelectionProps{
+		Strategy:   SelectRoundRobin,
+		MinBalance: big.NewInt(10),
+	})
+	s.SetBalance(wallet1, big.NewInt(10))
+	s.SetBalance(wallet2, big.NewInt(9))
+	s.SetBalance(wallet3, big.NewInt(100))
+
+	assert.True(t, s.Excluded(wallet2))
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+	assert.Equal(t, wallet3, selectWallet(t, s, ""))
+	assert.Equal(t, wallet1, selectWallet(t, s, ""))
+
+	s.SetBalance(wallet1, big.NewInt(0))
+	s.SetBalance(wallet3, big.NewInt(0))
+	_, _, err := s.Select("")
+	assert.Equal(t, errors.ErrNoWalletAvailable, err.ErrorCode())
+}
+
+func TestSelectRefreshesExcludedWallets(t *testing.T) {
+	s := newTestSelector(SelectionProps{MinBalance: big.NewInt(10)})
+	s.SetBalance(wallet1, big.NewInt(0))
+
+	// the balance was just updated so it is not refreshed
+	_, stale, _ := s.Select("")
+	assert.Empty(t, stale)
+
+	s.wallets[0].updated = s.wallets[0].updated.Add(-balanceRefreshInterval)
+	_, stale, _ = s.Select("")
+	assert.Equal(t, []common.Address{wallet1}, stale)
+
+	// a refresh is only requested once until it completes
+	_, stale, _ = s.Select("")
+	assert.Empty(t, stale)
+
+	s.SetBalance(wallet1, big.NewInt(10))
+	assert.False(t, s.Excluded(wallet1))
+}
+
+func TestExecutorSelectionStickyAAD(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	keys := []*ecdsa.PrivateKey{GetPrivateKey()}
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		assert.Nil(t, err)
+		keys = append(keys, key)
+	}
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: keys,
+		Selection:   SelectionProps{Strategy: SelectStickyAAD},
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 6; i++ {
+		_, err := executor.Execute(context.Background(), ExecuteRequest{
+			ID:      uint64(i),
+			AAD:     "user",
+			Address: address,
+			Data:    []byte("data"),
+		})
+		assert.Nil(t, err)
+	}
+
+	senders := make(map[common.Address]int)
+	for _, call := range mockclient.Calls {
+		if call.Method == "SendTransaction" {
+			tx := call.Arguments.Get(1).(*types.Transaction)
+			sender, err := types.Sender(types.FrontierSigner{}, tx)
+			assert.Nil(t, err)
+			senders[sender]++
+		}
+	}
+	assert.Equal(t, 1, len(senders))
+
+	for _, m := range executor.Stats() {
+		assert.Equal(t, false, m.(stats.Metrics)["excluded"])
+	}
+}
+
+func TestExecutorSelectionMinBalance(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMockWithOverwrite(mockclient, ethtest.MockMethods{
+		"BalanceAt": {
+			Arguments: []interface{}{mock.Anything, mock.Anything, mock.Anything},
+			Return:    []interface{}{big.NewInt(1), nil},
+		},
+	})
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: []*ecdsa.PrivateKey{GetPrivateKey()},
+		Selection:   SelectionProps{MinBalance: big.NewInt(2)},
+	})
+	assert.Nil(t, err)
+
+	_, err = executor.Execute(context.Background(), ExecuteRequest{
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Equal(t, errors.ErrNoWalletAvailable, err.(errors.Err).ErrorCode())
+	mockclient.AssertNumberOfCalls(t, "SendTransaction", 0)
+}
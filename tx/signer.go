@@ -0,0 +1,173 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	stderr "github.com/pkg/errors"
+
+	"github.com/oasislabs/oasis-gateway/errors"
+)
+
+// Signer signs transactions on behalf of the accounts it manages. It
+// allows the gateway to send transactions from accounts whose private
+// keys are kept outside of the gateway
+type Signer interface {
+	// Accounts returns the addresses of the accounts
+	// managed by the signer
+	Accounts(ctx context.Context) ([]common.Address, error)
+
+	// SignTransaction signs a legacy transaction on behalf of the
+	// account. If chainID is nil the transaction is signed without
+	// replay protection
+	SignTransaction(ctx context.Context, account common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// SignDynamicFeeTransaction signs a dynamic fee
+	// transaction on behalf of the account
+	SignDynamicFeeTransaction(ctx context.Context, account common.Address, tx *DynamicFeeTransaction) (*DynamicFeeTransaction, error)
+}
+
+// ErrUnknownAccount is returned by a Signer that is asked
+// to sign for an account it does not manage
+type ErrUnknownAccount struct {
+	Account common.Address
+}
+
+func (e ErrUnknownAccount) Error() string {
+	return "signer does not manage account " + e.Account.Hex()
+}
+
+// LocalSigner is a Signer that keeps the private keys of its accounts in
+// memory. It stands in for a remote signer in tests and in deployments
+// without one
+type LocalSigner struct {
+	accounts []common.Address
+	keys     map[common.Address]*ecdsa.PrivateKey
+}
+
+// NewLocalSigner creates a new LocalSigner that
+// manages the accounts of the provided keys
+func NewLocalSigner(keys ...*ecdsa.PrivateKey) *LocalSigner {
+	s := &LocalSigner{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	for _, key := range keys {
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		s.accounts = append(s.accounts, address)
+		s.keys[address] = key
+	}
+
+	return s
+}
+
+// Accounts implementation of Signer
+func (s *LocalSigner) Accounts(ctx context.Context) ([]common.Address, error) {
+	accounts := make([]common.Address, len(s.accounts))
+	copy(accounts, s.accounts)
+	return accounts, nil
+}
+
+// SignTransaction implementation of Signer
+func (s *LocalSigner) SignTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *types.Transaction,
+	chainID *big.Int,
+) (*types.Transaction, error) {
+	key, ok := s.keys[account]
+	if !ok {
+		return nil, ErrUnknownAccount{Account: account}
+	}
+
+	return types.SignTx(tx, ChainConfig{ChainID: chainID}.Signer(), key)
+}
+
+// SignDynamicFeeTransaction implementation of Signer
+func (s *LocalSigner) SignDynamicFeeTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *DynamicFeeTransaction,
+) (*DynamicFeeTransaction, error) {
+	key, ok := s.keys[account]
+	if !ok {
+		return nil, ErrUnknownAccount{Account: account}
+	}
+
+	sig, err := crypto.Sign(tx.SigningHash().Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.WithSignature(sig)
+}
+
+// ExternalWallet is a Wallet for an account whose transactions are
+// signed by a Signer, so the wallet never has access to its private
+// key. The transactions returned by the Signer are verified to be the
+// requested transactions signed by the account
+type ExternalWallet struct {
+	address common.Address
+	signer  Signer
+	chain   ChainConfig
+}
+
+// NewExternalWallet creates a new wallet for the account
+// that signs transactions with the provided signer
+func NewExternalWallet(address common.Address, signer Signer, chain ChainConfig) *ExternalWallet {
+	return &ExternalWallet{
+		address: address,
+		signer:  signer,
+		chain:   chain,
+	}
+}
+
+func (w *ExternalWallet) Address() common.Address {
+	return w.address
+}
+
+func (w *ExternalWallet) SignTransaction(tx *types.Transaction) (*types.Transaction, errors.Err) {
+	signed, err := w.signer.SignTransaction(context.Background(), w.address, tx, w.chain.ChainID)
+	if err != nil {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to sign transaction"))
+	}
+
+	signer := w.chain.Signer()
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, errors.New(errors.ErrSignedTx, stderr.New("signer returned a different transaction"))
+	}
+
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to verify transaction signature"))
+	}
+	if sender != w.address {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Errorf("transaction signed by %s instead of %s",
+			sender.Hex(), w.address.Hex()))
+	}
+
+	return signed, nil
+}
+
+func (w *ExternalWallet) SignDynamicFeeTransaction(tx *DynamicFeeTransaction) (*DynamicFeeTransaction, errors.Err) {
+	signed, err := w.signer.SignDynamicFeeTransaction(context.Background(), w.address, tx)
+	if err != nil {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to sign transaction"))
+	}
+
+	if signed.SigningHash() != tx.SigningHash() {
+		return nil, errors.New(errors.ErrSignedTx, stderr.New("signer returned a different transaction"))
+	}
+
+	sender, err := signed.Sender()
+	if err != nil {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to verify transaction signature"))
+	}
+	if sender != w.address {
+		return nil, errors.New(errors.ErrSignedTx, stderr.Errorf("transaction signed by %s instead of %s",
+			sender.Hex(), w.address.Hex()))
+	}
+
+	return signed, nil
+}
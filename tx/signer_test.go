@@ -0,0 +1,186 @@
+package tx
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRemoteSigner(t *testing.T, signer Signer) *RemoteSigner {
+	server, err := NewSignerServer(signer)
+	assert.Nil(t, err)
+	return NewRemoteSigner(rpc.DialInProc(server), 0)
+}
+
+func TestRemoteSignerAccounts(t *testing.T) {
+	signer := newTestRemoteSigner(t, NewLocalSigner(GetPrivateKey()))
+	defer signer.Close()
+
+	accounts, err := signer.Accounts(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{crypto.PubkeyToAddress(GetPrivateKey().PublicKey)}, accounts)
+}
+
+func TestRemoteSignerSignTransaction(t *testing.T) {
+	signer := newTestRemoteSigner(t, NewLocalSigner(GetPrivateKey()))
+	defer signer.Close()
+	account := crypto.PubkeyToAddress(GetPrivateKey().PublicKey)
+
+	for _, chainID := range []*big.Int{nil, big.NewInt(42)} {
+		tx := types.NewTransaction(1, common.HexToAddress(address), big.NewInt(0),
+			21000, big.NewInt(1000), []byte("data"))
+		signed, err := signer.SignTransaction(context.Background(), account, tx, chainID)
+		assert.Nil(t, err)
+
+		chainSigner := ChainConfig{ChainID: chainID}.Signer()
+		sender, err := types.Sender(chainSigner, signed)
+		assert.Nil(t, err)
+		assert.Equal(t, account, sender)
+		assert.Equal(t, chainSigner.Hash(tx), chainSigner.Hash(signed))
+	}
+
+	deploy := types.NewContractCreation(1, big.NewInt(0), 21000, big.NewInt(1000), []byte("code"))
+	signed, err := signer.SignTransaction(context.Background(), account, deploy, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, signed.To())
+
+	_, err = signer.SignTransaction(context.Background(), common.HexToAddress(address), deploy, nil)
+	assert.Error(t, err)
+}
+
+func TestRemoteSignerSignDynamicFeeTransaction(t *testing.T) {
+	signer := newTestRemoteSigner(t, NewLocalSigner(GetPrivateKey()))
+	defer signer.Close()
+	account := crypto.PubkeyToAddress(GetPrivateKey().PublicKey)
+
+	to := common.HexToAddress(address)
+	tx := NewDynamicFeeTransaction(big.NewInt(1), 3, &to, big.NewInt(0),
+		21000, big.NewInt(200), big.NewInt(10), []byte("data"))
+	signed, err := signer.SignDynamicFeeTransaction(context.Background(), account, tx)
+	assert.Nil(t, err)
+
+	sender, err := signed.Sender()
+	assert.Nil(t, err)
+	assert.Equal(t, account, sender)
+	assert.Equal(t, tx.SigningHash(), signed.SigningHash())
+	assert.Equal(t, to, *signed.To())
+}
+
+// tamperingSigner signs a transaction with a different nonce
+type tamperingSigner struct {
+	*LocalSigner
+}
+
+func (s tamperingSigner) SignTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *types.Transaction,
+	chainID *big.Int,
+) (*types.Transaction, error) {
+	tampered := types.NewTransaction(tx.Nonce()+1, *tx.To(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data())
+	return s.LocalSigner.SignTransaction(ctx, account, tampered, chainID)
+}
+
+func TestExternalWalletVerifiesTransaction(t *testing.T) {
+	account := crypto.PubkeyToAddress(GetPrivateKey().PublicKey)
+	tx := types.NewTransaction(1, common.HexToAddress(address), big.NewInt(0),
+		21000, big.NewInt(1000), []byte("data"))
+
+	wallet := NewExternalWallet(account, NewLocalSigner(GetPrivateKey()), ChainConfig{})
+	_, err := wallet.SignTransaction(tx)
+	assert.Nil(t, err)
+
+	wallet = NewExternalWallet(account, tamperingSigner{NewLocalSigner(GetPrivateKey())}, ChainConfig{})
+	_, err = wallet.SignTransaction(tx)
+	assert.Error(t, err)
+
+	// a signer that signs with a different key is rejected
+	other, kerr := crypto.GenerateKey()
+	assert.Nil(t, kerr)
+	wallet = NewExternalWallet(account, NewLocalSigner(other), ChainConfig{})
+	_, err = wallet.SignTransaction(tx)
+	assert.Error(t, err)
+}
+
+// clefSigner signs transactions as Clef does, always with replay
+// protection for the chain ID it is configured with, regardless of
+// the chain ID requested
+type clefSigner struct {
+	*LocalSigner
+	chainID *big.Int
+}
+
+func (s clefSigner) SignTransaction(
+	ctx context.Context,
+	account common.Address,
+	tx *types.Transaction,
+	chainID *big.Int,
+) (*types.Transaction, error) {
+	return s.LocalSigner.SignTransaction(ctx, account, tx, s.chainID)
+}
+
+func TestExternalWalletReplayProtected(t *testing.T) {
+	account := crypto.PubkeyToAddress(GetPrivateKey().PublicKey)
+	tx := types.NewTransaction(1, common.HexToAddress(address), big.NewInt(0),
+		21000, big.NewInt(1000), []byte("data"))
+	signer := clefSigner{LocalSigner: NewLocalSigner(GetPrivateKey()), chainID: big.NewInt(1)}
+
+	// the replay protected signature is rejected for a chain without ID
+	wallet := NewExternalWallet(account, signer, ChainConfig{})
+	_, err := wallet.SignTransaction(tx)
+	assert.Error(t, err)
+
+	wallet = NewExternalWallet(account, signer, ChainConfig{ChainID: big.NewInt(1)})
+	signed, err := wallet.SignTransaction(tx)
+	assert.Nil(t, err)
+	assert.True(t, signed.Protected())
+}
+
+func TestExecutorWithRemoteSigner(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	signer := newTestRemoteSigner(t, clefSigner{
+		LocalSigner: NewLocalSigner(GetPrivateKey()),
+		chainID:     big.NewInt(1),
+	})
+	defer signer.Close()
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		Signer: signer,
+	})
+	assert.Nil(t, err)
+
+	account := crypto.PubkeyToAddress(GetPrivateKey().PublicKey)
+	assert.Equal(t, []common.Address{account}, executor.Addresses())
+
+	_, err = executor.Execute(context.Background(), ExecuteRequest{
+		Address: address,
+		Data:    []byte("data"),
+	})
+	assert.Nil(t, err)
+
+	for _, call := range mockclient.Calls {
+		if call.Method == "SendTransaction" {
+			// the transactions are signed for the chain ID of the node
+			sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), call.Arguments.Get(1).(*types.Transaction))
+			assert.Nil(t, err)
+			assert.Equal(t, account, sender)
+		}
+	}
+	mockclient.AssertNumberOfCalls(t, "SendTransaction", 1)
+}
@@ -0,0 +1,282 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	stderr "github.com/pkg/errors"
+
+	callback "github.com/oasislabs/oasis-gateway/callback/client"
+	"github.com/oasislabs/oasis-gateway/errors"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/log"
+	"github.com/oasislabs/oasis-gateway/stats"
+)
+
+// transferGas is the gas used by a transfer of funds
+// to an account without code
+const transferGas uint64 = 21000
+
+// treasuryCapWindow is the period of the daily cap of the treasury
+const treasuryCapWindow = 24 * time.Hour
+
+// TreasuryProps are the properties of the treasury that tops
+// up the wallets of the executor
+type TreasuryProps struct {
+	// PrivateKey of the treasury wallet. If not set
+	// the wallets are not topped up
+	PrivateKey *ecdsa.PrivateKey
+
+	// Threshold is the balance below which a wallet is topped up
+	Threshold *big.Int
+
+	// Target is the balance to which a wallet is topped up
+	Target *big.Int
+
+	// MaxTransfer is the maximum amount of a single transfer
+	MaxTransfer *big.Int
+
+	// DailyCap is the maximum amount transferred by the
+	// treasury in a period of 24 hours
+	DailyCap *big.Int
+}
+
+// Enabled returns true if the wallets are topped up
+func (p TreasuryProps) Enabled() bool {
+	return p.PrivateKey != nil
+}
+
+// TreasuryServices are the services required by a Treasury
+type TreasuryServices struct {
+	Client    eth.Client
+	Callbacks Callbacks
+	Logger    log.Logger
+	GasPrice  GasPriceOracle
+}
+
+// Treasury transfers funds from the treasury wallet to the wallets that
+// fall below the threshold. Transfers are bounded by a per transfer cap
+// and by a cap on the amount transferred every 24 hours
+type Treasury struct {
+	// lock protects the wallets being topped up
+	// and the amount transferred in the window
+	lock        sync.Mutex
+	pending     map[common.Address]bool
+	transferred *big.Int
+	windowStart time.Time
+
+	// sendLock serializes the transfers, so that each
+	// transfer uses the nonce after the previous one
+	sendLock sync.Mutex
+
+	wallet    Wallet
+	props     TreasuryProps
+	client    eth.Client
+	callbacks Callbacks
+	gasPrice  GasPriceOracle
+	logger    log.Logger
+	transfers stats.Counter
+	capped    stats.Counter
+}
+
+// NewTreasury creates a new Treasury for the wallet of the private key
+func NewTreasury(services *TreasuryServices, props TreasuryProps, chain ChainConfig) *Treasury {
+	return &Treasury{
+		pending:     make(map[common.Address]bool),
+		transferred: new(big.Int),
+		windowStart: time.Now(),
+		wallet:      NewWallet(props.PrivateKey, chain.Signer()),
+		props:       props,
+		client:      services.Client,
+		callbacks:   services.Callbacks,
+		gasPrice:    services.GasPrice,
+		logger:      services.Logger.ForClass("tx/wallet", "Treasury"),
+	}
+}
+
+// Address returns the address of the treasury wallet
+func (t *Treasury) Address() common.Address {
+	return t.wallet.Address()
+}
+
+// NeedsTopUp returns true if a wallet with the
+// provided balance should be topped up
+func (t *Treasury) NeedsTopUp(balance *big.Int) bool {
+	return balance != nil && balance.Cmp(t.props.Threshold) < 0
+}
+
+// Stats returns the stats of the treasury
+func (t *Treasury) Stats() stats.Metrics {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return stats.Metrics{
+		"address":     t.wallet.Address().Hex(),
+		"transfers":   t.transfers.Value(),
+		"capped":      t.capped.Value(),
+		"transferred": t.transferred.String(),
+	}
+}
+
+// TopUp transfers funds to the wallet if its balance is below the
+// threshold, so that its balance reaches the target. It returns the
+// amount transferred, which is nil if no transfer was needed, the
+// wallet is already being topped up or the daily cap was reached
+func (t *Treasury) TopUp(ctx context.Context, address common.Address) (*big.Int, errors.Err) {
+	t.lock.Lock()
+	if t.pending[address] {
+		t.lock.Unlock()
+		return nil, nil
+	}
+	t.pending[address] = true
+	t.lock.Unlock()
+
+	defer func() {
+		t.lock.Lock()
+		delete(t.pending, address)
+		t.lock.Unlock()
+	}()
+
+	// the balance is retrieved again in case the
+	// wallet was funded since it was reported
+	balance, err := t.client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, errors.New(errors.ErrGetBalance, err)
+	}
+	if !t.NeedsTopUp(balance) {
+		return nil, nil
+	}
+
+	amount := t.reserve(ctx, address, balance)
+	if amount == nil {
+		return nil, nil
+	}
+
+	hash, terr := t.transfer(ctx, address, amount)
+	if terr != nil {
+		t.lock.Lock()
+		t.transferred.Sub(t.transferred, amount)
+		t.lock.Unlock()
+
+		t.logger.Warn(ctx, "failed to top up wallet", log.MapFields{
+			"call_type": "TopUpWalletFailure",
+			"address":   address.Hex(),
+			"treasury":  t.wallet.Address().Hex(),
+			"amount":    amount.String(),
+		}, terr)
+		return nil, terr
+	}
+
+	t.transfers.Incr()
+	t.logger.Info(ctx, "wallet topped up", log.MapFields{
+		"call_type": "TopUpWalletSuccess",
+		"address":   address.Hex(),
+		"treasury":  t.wallet.Address().Hex(),
+		"amount":    amount.String(),
+		"before":    balance.String(),
+		"hash":      hash,
+	})
+
+	t.callbacks.WalletToppedUp(ctx, callback.WalletToppedUpBody{
+		Address:  address.Hex(),
+		Treasury: t.wallet.Address().Hex(),
+		Hash:     hash,
+		Amount:   new(big.Int).Set(amount),
+		Before:   balance,
+	})
+
+	return amount, nil
+}
+
+// reserve returns the amount to transfer to a wallet with the provided
+// balance and adds it to the amount transferred in the window. It
+// returns nil if nothing can be transferred
+func (t *Treasury) reserve(ctx context.Context, address common.Address, balance *big.Int) *big.Int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if time.Since(t.windowStart) >= treasuryCapWindow {
+		t.windowStart = time.Now()
+		t.transferred.SetInt64(0)
+	}
+
+	amount := new(big.Int).Sub(t.props.Target, balance)
+	if amount.Cmp(t.props.MaxTransfer) > 0 {
+		amount.Set(t.props.MaxTransfer)
+	}
+
+	remaining := new(big.Int).Sub(t.props.DailyCap, t.transferred)
+	if amount.Cmp(remaining) > 0 {
+		amount.Set(remaining)
+	}
+
+	if amount.Sign() <= 0 {
+		t.capped.Incr()
+		t.logger.Warn(ctx, "daily cap of the treasury reached, wallet not topped up", log.MapFields{
+			"call_type":   "TopUpWalletCapReached",
+			"address":     address.Hex(),
+			"treasury":    t.wallet.Address().Hex(),
+			"transferred": t.transferred.String(),
+		})
+		return nil
+	}
+
+	t.transferred.Add(t.transferred, amount)
+	return amount
+}
+
+func (t *Treasury) transfer(ctx context.Context, address common.Address, amount *big.Int) (string, errors.Err) {
+	t.sendLock.Lock()
+	defer t.sendLock.Unlock()
+
+	nonce, err := t.client.NonceAt(ctx, t.wallet.Address())
+	if err != nil {
+		return "", errors.New(errors.ErrFetchNonce, err)
+	}
+
+	gasPrice, err := t.gasPrice.GasPrice(ctx)
+	if err != nil {
+		return "", errors.New(errors.ErrSendTransaction, stderr.Wrap(err, "failed to get gas price"))
+	}
+
+	tx, serr := t.wallet.SignTransaction(types.NewTransaction(nonce, address, amount, transferGas, gasPrice, nil))
+	if serr != nil {
+		return "", serr
+	}
+
+	res, err := t.client.SendTransaction(ctx, tx)
+	if err != nil {
+		return "", errors.New(errors.ErrSendTransaction, err)
+	}
+	if res.Status != StatusOK {
+		msg := fmt.Sprintf("transfer has status %d", res.Status)
+		return "", errors.New(errors.ErrTransactionReceiptStatus, stderr.New(msg))
+	}
+
+	return res.Hash, nil
+}
+
+// treasuryCallbacks tops up the wallets whose balance
+// falls below the threshold of the treasury
+type treasuryCallbacks struct {
+	Callbacks
+	executor *Executor
+}
+
+func (c treasuryCallbacks) WalletOutOfFunds(ctx context.Context, body callback.WalletOutOfFundsBody) {
+	go c.executor.topUp(common.HexToAddress(body.Address))
+	c.Callbacks.WalletOutOfFunds(ctx, body)
+}
+
+func (c treasuryCallbacks) WalletReachedFundsThreshold(ctx context.Context, body callback.WalletReachedFundsThresholdBody) {
+	if c.executor.treasury.NeedsTopUp(body.After) {
+		go c.executor.topUp(common.HexToAddress(body.Address))
+	}
+	c.Callbacks.WalletReachedFundsThreshold(ctx, body)
+}
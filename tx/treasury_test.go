@@ -0,0 +1,214 @@
+package tx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/oasislabs/oasis-gateway/callback/callbacktest"
+	callback "github.com/oasislabs/oasis-gateway/callback/client"
+	"github.com/oasislabs/oasis-gateway/eth"
+	"github.com/oasislabs/oasis-gateway/eth/ethtest"
+	"github.com/oasislabs/oasis-gateway/stats"
+	stderr "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestTreasury(client *ethtest.MockClient, callbacks Callbacks, dailyCap int64) *Treasury {
+	return NewTreasury(&TreasuryServices{
+		Client:    client,
+		Callbacks: callbacks,
+		Logger:    Logger,
+		GasPrice:  NewGasPriceOracle(client, GasPriceProps{}),
+	}, TreasuryProps{
+		PrivateKey:  GetPrivateKey(),
+		Threshold:   big.NewInt(50),
+		Target:      big.NewInt(100),
+		MaxTransfer: big.NewInt(60),
+		DailyCap:    big.NewInt(dailyCap),
+	}, ChainConfig{})
+}
+
+func mockClientWithBalance(client *ethtest.MockClient, balance int64) {
+	methods := ethtest.OverwriteDefaults(nil)
+	delete(methods, "BalanceAt")
+	ethtest.ImplementMockWithMethods(client, methods)
+	client.On("BalanceAt", mock.Anything, mock.Anything, mock.Anything).Return(big.NewInt(balance), nil)
+}
+
+func transfers(client *ethtest.MockClient) []*types.Transaction {
+	var txs []*types.Transaction
+	for _, call := range client.Calls {
+		if call.Method == "SendTransaction" {
+			txs = append(txs, call.Arguments.Get(1).(*types.Transaction))
+		}
+	}
+
+	return txs
+}
+
+func TestTreasuryTopUp(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientWithBalance(mockclient, 10)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+	treasury := newTestTreasury(mockclient, callbackclient, 1000)
+
+	amount, err := treasury.TopUp(context.Background(), wallet1)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(60), amount)
+
+	txs := transfers(mockclient)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, wallet1, *txs[0].To())
+	assert.Equal(t, big.NewInt(60), txs[0].Value())
+	sender, serr := types.Sender(types.FrontierSigner{}, txs[0])
+	assert.Nil(t, serr)
+	assert.Equal(t, crypto.PubkeyToAddress(GetPrivateKey().PublicKey), sender)
+
+	callbackclient.AssertCalled(t, "WalletToppedUp", mock.Anything, callback.WalletToppedUpBody{
+		Address:  wallet1.Hex(),
+		Treasury: treasury.Address().Hex(),
+		Hash:     "0x00000000000000000000000000000000000000000000000000000000000000000",
+		Amount:   big.NewInt(60),
+		Before:   big.NewInt(10),
+	})
+}
+
+func TestTreasuryTopUpNotNeeded(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientWithBalance(mockclient, 50)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+	treasury := newTestTreasury(mockclient, callbackclient, 1000)
+
+	amount, err := treasury.TopUp(context.Background(), wallet1)
+	assert.Nil(t, err)
+	assert.Nil(t, amount)
+	mockclient.AssertNotCalled(t, "SendTransaction", mock.Anything, mock.Anything)
+	callbackclient.AssertNotCalled(t, "WalletToppedUp", mock.Anything, mock.Anything)
+}
+
+func TestTreasuryDailyCap(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientWithBalance(mockclient, 10)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+	treasury := newTestTreasury(mockclient, callbackclient, 100)
+
+	amount, err := treasury.TopUp(context.Background(), wallet1)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(60), amount)
+
+	// only the remaining of the daily cap is transferred
+	amount, err = treasury.TopUp(context.Background(), wallet2)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(40), amount)
+
+	amount, err = treasury.TopUp(context.Background(), wallet3)
+	assert.Nil(t, err)
+	assert.Nil(t, amount)
+
+	assert.Equal(t, 2, len(transfers(mockclient)))
+	metrics := treasury.Stats()
+	assert.Equal(t, uint64(2), metrics["transfers"])
+	assert.Equal(t, uint64(1), metrics["capped"])
+	assert.Equal(t, "100", metrics["transferred"])
+
+	// the cap is reset after the window
+	treasury.windowStart = time.Now().Add(-treasuryCapWindow)
+	amount, err = treasury.TopUp(context.Background(), wallet3)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(60), amount)
+}
+
+func TestTreasuryTopUpFailure(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	methods := ethtest.OverwriteDefaults(nil)
+	delete(methods, "BalanceAt")
+	delete(methods, "SendTransaction")
+	ethtest.ImplementMockWithMethods(mockclient, methods)
+	mockclient.On("BalanceAt", mock.Anything, mock.Anything, mock.Anything).Return(big.NewInt(10), nil)
+	mockclient.On("SendTransaction", mock.Anything, mock.Anything).
+		Return(eth.SendTransactionResponse{}, stderr.New("connection refused"))
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+	treasury := newTestTreasury(mockclient, callbackclient, 100)
+
+	amount, err := treasury.TopUp(context.Background(), wallet1)
+	assert.Error(t, err)
+	assert.Nil(t, amount)
+
+	// the failed transfer does not count towards the cap
+	assert.Equal(t, "0", treasury.Stats()["transferred"])
+	callbackclient.AssertNotCalled(t, "WalletToppedUp", mock.Anything, mock.Anything)
+}
+
+func TestExecutorTopsUpWallets(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	mockClientWithBalance(mockclient, 10)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	key, kerr := crypto.GenerateKey()
+	assert.Nil(t, kerr)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	executor, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: []*ecdsa.PrivateKey{key},
+		Treasury: TreasuryProps{
+			PrivateKey:  GetPrivateKey(),
+			Threshold:   big.NewInt(50),
+			Target:      big.NewInt(100),
+			MaxTransfer: big.NewInt(90),
+			DailyCap:    big.NewInt(90),
+		},
+	})
+	assert.Nil(t, err)
+
+	// the owner reports its balance when it is created
+	// which triggers the top up of the wallet
+	assert.Eventually(t, func() bool {
+		return executor.Stats()["treasury"].(stats.Metrics)["transfers"] == uint64(1)
+	}, time.Second, 10*time.Millisecond)
+
+	txs := transfers(mockclient)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, address, *txs[0].To())
+	assert.Equal(t, big.NewInt(90), txs[0].Value())
+}
+
+func TestExecutorTreasuryIsWallet(t *testing.T) {
+	mockclient := &ethtest.MockClient{}
+	ethtest.ImplementMock(mockclient)
+	callbackclient := &callbacktest.MockClient{}
+	callbacktest.ImplementMock(callbackclient)
+
+	_, err := NewExecutor(context.Background(), &ExecutorServices{
+		Logger:    Logger,
+		Client:    mockclient,
+		Callbacks: callbackclient,
+	}, &ExecutorProps{
+		PrivateKeys: []*ecdsa.PrivateKey{GetPrivateKey()},
+		Treasury: TreasuryProps{
+			PrivateKey:  GetPrivateKey(),
+			Threshold:   big.NewInt(50),
+			Target:      big.NewInt(100),
+			MaxTransfer: big.NewInt(90),
+			DailyCap:    big.NewInt(90),
+		},
+	})
+	assert.Error(t, err)
+
+	// the wallet is rejected before its owner is created
+	mockclient.AssertNotCalled(t, "NonceAt", mock.Anything, mock.Anything)
+}
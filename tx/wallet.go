@@ -16,6 +16,7 @@ import (
 type Wallet interface {
 	Address() common.Address
 	SignTransaction(tx *types.Transaction) (*types.Transaction, errors.Err)
+	SignDynamicFeeTransaction(tx *DynamicFeeTransaction) (*DynamicFeeTransaction, errors.Err)
 }
 
 type InternalWallet struct {
@@ -49,3 +50,19 @@ func (w *InternalWallet) SignTransaction(tx *types.Transaction) (*types.Transact
 
 	return tx, nil
 }
+
+func (w *InternalWallet) SignDynamicFeeTransaction(tx *DynamicFeeTransaction) (*DynamicFeeTransaction, errors.Err) {
+	sig, err := crypto.Sign(tx.SigningHash().Bytes(), w.privateKey)
+	if err != nil {
+		err := errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to sign transaction"))
+		return nil, err
+	}
+
+	tx, err = tx.WithSignature(sig)
+	if err != nil {
+		err := errors.New(errors.ErrSignedTx, stderr.Wrap(err, "Failed to sign transaction"))
+		return nil, err
+	}
+
+	return tx, nil
+}